@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/klauern/muse/config"
+	"github.com/klauern/muse/internal/credentials"
+	"github.com/klauern/muse/internal/security"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/term"
+)
+
+func NewAuthCmd(cfg *config.Config) *cli.Command {
+	return &cli.Command{
+		Name:  "auth",
+		Usage: "Store provider API keys outside the config file",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "login",
+				Usage:     "Save an API key in the OS keyring (or a private file when no keyring is available)",
+				ArgsUsage: "[provider]",
+				Action: func(c *cli.Context) error {
+					return authLogin(cfg, authProvider(c, cfg))
+				},
+			},
+			{
+				Name:      "logout",
+				Usage:     "Remove a saved API key",
+				ArgsUsage: "[provider]",
+				Action: func(c *cli.Context) error {
+					provider := authProvider(c, cfg)
+					if err := credentials.NewResolver().Remove(provider); err != nil {
+						return err
+					}
+					fmt.Printf("Removed the saved %s API key\n", provider)
+					return nil
+				},
+			},
+		},
+	}
+}
+
+// authProvider returns the provider argument, defaulting to llm.provider
+func authProvider(c *cli.Context, cfg *config.Config) string {
+	if c.NArg() > 0 {
+		return c.Args().First()
+	}
+	if cfg.LLM.Provider != "" {
+		return cfg.LLM.Provider
+	}
+	return "openai"
+}
+
+func authLogin(cfg *config.Config, provider string) error {
+	key, err := readAPIKey(provider)
+	if err != nil {
+		return err
+	}
+	if key == "" {
+		return fmt.Errorf("no API key entered")
+	}
+
+	if err := security.ValidateCredential(key); err != nil {
+		var credErr *security.CredentialError
+		if errors.As(err, &credErr) && credErr.Type == "placeholder" {
+			return fmt.Errorf("refusing to store a placeholder key: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	location, err := credentials.NewResolver().Store(provider, key)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Stored the %s API key (%s) in %s\n", provider, security.MaskCredential(key), location)
+
+	if provider == cfg.LLM.Provider {
+		for _, setting := range []string{credentials.KeyAPIKey, credentials.KeyAPIKeyCmd, credentials.KeyAPIKeyFile} {
+			if value, _ := cfg.LLM.Config[setting].(string); value != "" {
+				fmt.Printf("Note: llm.config.%s is set and takes precedence over the stored key\n", setting)
+			}
+		}
+	}
+	return nil
+}
+
+// readAPIKey prompts for a key without echo on a terminal, or reads the
+// first line of stdin so keys can be piped in
+func readAPIKey(provider string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		fmt.Fprintf(os.Stderr, "Enter the %s API key: ", provider)
+		key, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("failed to read API key: %w", err)
+		}
+		return strings.TrimSpace(string(key)), nil
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("failed to read API key from stdin: %w", err)
+	}
+	return strings.TrimSpace(line), nil
+}
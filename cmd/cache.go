@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/klauern/muse/config"
+	"github.com/klauern/muse/internal/cache"
+	"github.com/urfave/cli/v2"
+)
+
+func NewCacheCmd(cfg *config.Config) *cli.Command {
+	return &cli.Command{
+		Name:  "cache",
+		Usage: "Manage the cache of generated commit messages",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "clear",
+				Usage: "Remove every cached message",
+				Action: func(c *cli.Context) error {
+					responseCache, err := cache.FromConfig(cfg.Cache)
+					if err != nil {
+						return err
+					}
+					removed, err := responseCache.Clear()
+					if err != nil {
+						return err
+					}
+					fmt.Printf("Removed %d cached messages from %s\n", removed, responseCache.Dir)
+					return nil
+				},
+			},
+			{
+				Name:  "info",
+				Usage: "Show the cache location, size and limits",
+				Action: func(c *cli.Context) error {
+					return cacheInfo(cfg)
+				},
+			},
+		},
+	}
+}
+
+func cacheInfo(cfg *config.Config) error {
+	responseCache, err := cache.FromConfig(cfg.Cache)
+	if err != nil {
+		return err
+	}
+	count, size, err := responseCache.Stats()
+	if err != nil {
+		return err
+	}
+
+	state := "enabled"
+	if !cfg.Cache.Enabled {
+		state = "disabled"
+	}
+	fmt.Printf("Cache: %s\n", state)
+	fmt.Printf("Directory: %s\n", responseCache.Dir)
+	fmt.Printf("Entries: %d (%.1f KiB)\n", count, float64(size)/1024)
+	fmt.Printf("TTL: %s\n", cfg.Cache.TTL)
+	fmt.Printf("Size limit: %d MB\n", cfg.Cache.MaxSizeMB)
+	return nil
+}
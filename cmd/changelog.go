@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/klauern/muse/config"
+	"github.com/klauern/muse/internal/changelog"
+	"github.com/klauern/muse/internal/fileops"
+	"github.com/klauern/muse/internal/git"
+	"github.com/klauern/muse/internal/ledger"
+	"github.com/klauern/muse/llm"
+	"github.com/urfave/cli/v2"
+)
+
+func NewChangelogCmd(cfg *config.Config) *cli.Command {
+	return &cli.Command{
+		Name:      "changelog",
+		Usage:     "Generate a changelog from the conventional commits in a range",
+		ArgsUsage: "<from>[..<to>]",
+		Description: "Groups the commits by type and scope and renders a Keep a Changelog section.\n" +
+			"When <to> is omitted it defaults to HEAD. The suggested version bump is printed to stderr.",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "release",
+				Usage: "Version heading (default: the next version when <from> is a version tag, otherwise Unreleased)",
+			},
+			&cli.StringFlag{
+				Name:  "template",
+				Usage: "Go template file to render instead of Keep a Changelog",
+			},
+			&cli.BoolFlag{
+				Name:  "rewrite",
+				Usage: "Have the LLM rewrite the changelog into user-facing release notes",
+			},
+			&cli.StringFlag{
+				Name:    "file",
+				Aliases: []string{"f"},
+				Usage:   "Write the changelog to a file instead of stdout",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			return runChangelog(c, cfg)
+		},
+	}
+}
+
+func runChangelog(c *cli.Context, cfg *config.Config) error {
+	if c.NArg() != 1 {
+		return fmt.Errorf("expected a revision range such as v1.2.0..HEAD")
+	}
+	from, to, found := strings.Cut(c.Args().First(), "..")
+	if !found || to == "" {
+		to = "HEAD"
+	}
+
+	ops, err := git.NewGitOperations("")
+	if err != nil {
+		return fmt.Errorf("failed to initialize git operations: %w", err)
+	}
+	commits, err := ops.GetLog(from+".."+to, 0)
+	if err != nil {
+		return err
+	}
+	if len(commits) == 0 {
+		return fmt.Errorf("no commits in %s..%s", from, to)
+	}
+
+	log := changelog.New(commits, c.String("release"), time.Now())
+	next, isVersion := changelog.NextVersion(from, log.Bump)
+	if log.Version == "" {
+		log.Version = "Unreleased"
+		if isVersion && log.Bump != changelog.BumpNone {
+			log.Version = next
+		}
+	}
+	if isVersion {
+		fmt.Fprintf(os.Stderr, "Suggested version bump: %s (%s -> %s)\n", log.Bump, from, next)
+	} else {
+		fmt.Fprintf(os.Stderr, "Suggested version bump: %s\n", log.Bump)
+	}
+
+	var tmpl string
+	if path := c.String("template"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read changelog template: %w", err)
+		}
+		tmpl = string(data)
+	}
+	output, err := changelog.Render(log, tmpl)
+	if err != nil {
+		return err
+	}
+
+	if c.Bool("rewrite") {
+		service, err := llm.NewLLMService(&cfg.LLM)
+		if err != nil {
+			return fmt.Errorf("failed to create LLM service: %w", err)
+		}
+		ctx, gen := ledger.Start(c.Context, cfg, "changelog")
+		if output, err = changelog.Rewrite(ctx, service, output); err != nil {
+			return fmt.Errorf("failed to rewrite release notes: %w", err)
+		}
+		gen.Record("")
+	}
+
+	if path := c.String("file"); path != "" {
+		if err := fileops.AtomicWriteFile(path, []byte(output), 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		fmt.Printf("Wrote %s\n", path)
+		return nil
+	}
+	fmt.Print(output)
+	return nil
+}
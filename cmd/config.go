@@ -0,0 +1,497 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/klauern/muse/config"
+	"github.com/klauern/muse/internal/configloader"
+	"github.com/klauern/muse/internal/fileops"
+	"github.com/klauern/muse/internal/security"
+	"github.com/klauern/muse/internal/userinput"
+	"github.com/klauern/muse/llm"
+	"github.com/knadh/koanf"
+	"github.com/urfave/cli/v2"
+)
+
+// Config file scopes selected with --global or --repo
+const (
+	scopeGlobal = "global"
+	scopeRepo   = "repo"
+)
+
+func NewConfigCmd(cfg *config.Config) *cli.Command {
+	return &cli.Command{
+		Name:  "config",
+		Usage: "Inspect and edit the configuration",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "show",
+				Usage: "Print the effective configuration, or one file with --global or --repo",
+				Flags: append(scopeFlags(), &cli.BoolFlag{
+					Name:  "origin",
+					Usage: "Show which layer each value came from",
+				}),
+				Action: func(c *cli.Context) error {
+					return showConfig(c, c.Bool("origin"))
+				},
+			},
+			{
+				Name:      "get",
+				Usage:     "Print a single configuration value",
+				ArgsUsage: "<key>",
+				Flags: append(scopeFlags(), &cli.BoolFlag{
+					Name:  "reveal",
+					Usage: "Print credentials without masking them",
+				}),
+				Action: getConfigValue,
+			},
+			{
+				Name:      "set",
+				Usage:     "Set a configuration value in the global (default) or repository config file",
+				ArgsUsage: "<key> <value>",
+				Flags:     scopeFlags(),
+				Action: func(c *cli.Context) error {
+					return setConfigValue(c, cfg)
+				},
+			},
+			{
+				Name:  "edit",
+				Usage: "Open the global (default) or repository config file in $EDITOR",
+				Flags: scopeFlags(),
+				Action: func(c *cli.Context) error {
+					return editConfig(c, cfg)
+				},
+			},
+			{
+				Name:  "validate",
+				Usage: "Check the configuration for errors",
+				Flags: scopeFlags(),
+				Action: func(c *cli.Context) error {
+					return validateConfig(c, cfg)
+				},
+			},
+			{
+				Name:   "path",
+				Usage:  "Print the config file locations",
+				Flags:  scopeFlags(),
+				Action: printConfigPath,
+			},
+			{
+				Name:  "env",
+				Usage: "List the environment variables that set config values",
+				Action: func(c *cli.Context) error {
+					return printEnvVars()
+				},
+			},
+			{
+				Name:  "schema",
+				Usage: "Print the JSON Schema of muse.yaml for editor integration",
+				Action: func(c *cli.Context) error {
+					return printSchema()
+				},
+			},
+		},
+	}
+}
+
+func scopeFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "global",
+			Usage: "Use the global config file",
+		},
+		&cli.BoolFlag{
+			Name:  "repo",
+			Usage: "Use the repository config file",
+		},
+	}
+}
+
+// configScope returns the scope selected with --global or --repo, or
+// fallback when neither is set
+func configScope(c *cli.Context, fallback string) (string, error) {
+	switch {
+	case c.Bool("global") && c.Bool("repo"):
+		return "", fmt.Errorf("--global and --repo cannot be used together")
+	case c.Bool("global"):
+		return scopeGlobal, nil
+	case c.Bool("repo"):
+		return scopeRepo, nil
+	}
+	return fallback, nil
+}
+
+// scopePath returns the config file of a scope, whether or not it exists
+func scopePath(scope string) (string, error) {
+	if scope == scopeGlobal {
+		return config.GlobalConfigPath()
+	}
+
+	root := config.RepoRoot()
+	if root == "" {
+		return "", fmt.Errorf("not inside a git repository")
+	}
+	if path := config.RepoConfigPath(root); path != "" {
+		return path, nil
+	}
+	return filepath.Join(root, config.RepoConfigNames[0]), nil
+}
+
+// loadScope merges the effective configuration, or only the file of scope
+func loadScope(scope string) (*koanf.Koanf, config.Origins, error) {
+	if scope == "" {
+		sources, err := configloader.GetConfigLoader().Sources()
+		if err != nil {
+			return nil, nil, err
+		}
+		return config.Merge(sources)
+	}
+
+	path, err := scopePath(scope)
+	if err != nil {
+		return nil, nil, err
+	}
+	source, err := config.FileSource(scope, path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return config.Merge([]config.Source{source})
+}
+
+func showConfig(c *cli.Context, withOrigin bool) error {
+	scope, err := configScope(c, "")
+	if err != nil {
+		return err
+	}
+
+	k, origins, err := loadScope(scope)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, key := range origins.Keys() {
+		value := fmt.Sprintf("%v", k.Get(key))
+		if isSecretKey(key) {
+			value = security.MaskCredential(value)
+		}
+
+		if withOrigin {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", key, value, origins[key])
+		} else {
+			fmt.Fprintf(w, "%s\t%s\n", key, value)
+		}
+	}
+	return w.Flush()
+}
+
+func getConfigValue(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return fmt.Errorf("usage: muse config get <key>")
+	}
+	key := c.Args().First()
+
+	scope, err := configScope(c, "")
+	if err != nil {
+		return err
+	}
+
+	k, origins, err := loadScope(scope)
+	if err != nil {
+		return err
+	}
+	if !k.Exists(key) {
+		return fmt.Errorf("%s is not set", key)
+	}
+
+	// A parent key prints every value beneath it
+	if _, isLeaf := origins[key]; !isLeaf {
+		sub := k.Cut(key)
+		for _, subKey := range sub.Keys() {
+			fmt.Printf("%s.%s\t%s\n", key, subKey, displayValue(key+"."+subKey, sub.Get(subKey), c.Bool("reveal")))
+		}
+		return nil
+	}
+
+	fmt.Println(displayValue(key, k.Get(key), c.Bool("reveal")))
+	return nil
+}
+
+func displayValue(key string, value any, reveal bool) string {
+	text := fmt.Sprintf("%v", value)
+	if isSecretKey(key) && !reveal {
+		return security.MaskCredential(text)
+	}
+	return text
+}
+
+func setConfigValue(c *cli.Context, cfg *config.Config) error {
+	if c.NArg() != 2 {
+		return fmt.Errorf("usage: muse config set <key> <value>")
+	}
+	key, value := c.Args().Get(0), c.Args().Get(1)
+
+	scope, err := configScope(c, scopeGlobal)
+	if err != nil {
+		return err
+	}
+	path, err := scopePath(scope)
+	if err != nil {
+		return err
+	}
+
+	data, perm, err := readConfigFile(path)
+	if err != nil {
+		return err
+	}
+
+	updated, err := config.SetValue(data, key, value)
+	if err != nil {
+		return err
+	}
+	if err := validateConfigData(path, updated, cfg); err != nil {
+		return fmt.Errorf("refusing to write an invalid config: %w", err)
+	}
+
+	if err := fileops.AtomicWriteFile(path, updated, perm); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+
+	fmt.Printf("Set %s in %s\n", key, path)
+	return nil
+}
+
+// readConfigFile returns the contents and mode of a config file, or no
+// contents and the default mode if it does not exist yet
+func readConfigFile(path string) ([]byte, os.FileMode, error) {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil, 0o644, nil
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read config: %w", err)
+	}
+	return data, info.Mode().Perm(), nil
+}
+
+// validateConfigData checks a config file against the schema of the provider
+// it names, falling back to the effective provider
+func validateConfigData(path string, data []byte, cfg *config.Config) error {
+	provider := cfg.LLM.Provider
+	if value, ok, err := config.GetValue(data, "llm.provider"); err == nil && ok {
+		provider = fmt.Sprintf("%v", value)
+	}
+	schema := config.SchemaForProvider(provider, llm.ProviderConfigSchemas())
+	return config.ValidateYAML(path, data, schema)
+}
+
+func editConfig(c *cli.Context, cfg *config.Config) error {
+	scope, err := configScope(c, scopeGlobal)
+	if err != nil {
+		return err
+	}
+	path, err := scopePath(scope)
+	if err != nil {
+		return err
+	}
+
+	original, perm, err := readConfigFile(path)
+	if err != nil {
+		return err
+	}
+	if original == nil {
+		original = config.ExampleConfig
+	}
+
+	tmp, err := os.CreateTemp("", "muse-*.yaml")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(original); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write temporary file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write temporary file: %w", err)
+	}
+
+	for {
+		if err := runEditor(tmp.Name()); err != nil {
+			return err
+		}
+
+		edited, err := os.ReadFile(tmp.Name())
+		if err != nil {
+			return fmt.Errorf("failed to read edited config: %w", err)
+		}
+
+		validationErr := validateConfigData(path, edited, cfg)
+		if validationErr == nil {
+			if bytes.Equal(edited, original) {
+				if _, err := os.Stat(path); err == nil {
+					fmt.Println("No changes made")
+					return nil
+				}
+			}
+			if err := fileops.AtomicWriteFile(path, edited, perm); err != nil {
+				return fmt.Errorf("failed to write config: %w", err)
+			}
+			fmt.Printf("Saved %s\n", path)
+			return nil
+		}
+
+		fmt.Fprintf(os.Stderr, "Config is invalid:\n%s\n", formatValidationError(validationErr))
+		retry, err := userinput.NewSecureInputHandler().PromptYesNo(context.Background(), "Re-open the editor to fix it?")
+		if err != nil || !retry {
+			return fmt.Errorf("changes to %s discarded: %w", path, validationErr)
+		}
+	}
+}
+
+// runEditor opens path in $EDITOR, which may include arguments such as
+// "code --wait"
+func runEditor(path string) error {
+	editor := strings.Fields(os.Getenv("EDITOR"))
+	if len(editor) == 0 {
+		editor = []string{"vi"}
+	}
+
+	editorCmd := exec.Command(editor[0], append(editor[1:], path)...)
+	editorCmd.Stdin = os.Stdin
+	editorCmd.Stdout = os.Stdout
+	editorCmd.Stderr = os.Stderr
+	if err := editorCmd.Run(); err != nil {
+		return fmt.Errorf("editor %s failed: %w", editor[0], err)
+	}
+	return nil
+}
+
+// formatValidationError puts each schema error on its own line
+func formatValidationError(err error) string {
+	var schemaErrs config.SchemaErrors
+	if !errors.As(err, &schemaErrs) {
+		return "  " + err.Error()
+	}
+	lines := make([]string, 0, len(schemaErrs))
+	for _, schemaErr := range schemaErrs {
+		lines = append(lines, "  "+schemaErr.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+func validateConfig(c *cli.Context, cfg *config.Config) error {
+	scope, err := configScope(c, "")
+	if err != nil {
+		return err
+	}
+
+	if scope == "" {
+		// Reload so the check reflects the files as they are now
+		loader := configloader.GetConfigLoader()
+		loader.Invalidate()
+		sources, err := loader.Sources()
+		if err != nil {
+			return err
+		}
+		fmt.Println("Configuration is valid")
+		for _, source := range sources {
+			if source.Path != "" {
+				fmt.Printf("  %s (%s)\n", source.Path, source.Layer)
+			}
+		}
+		return nil
+	}
+
+	path, err := scopePath(scope)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	if err := validateConfigData(path, data, cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "%s is invalid:\n%s\n", path, formatValidationError(err))
+		return fmt.Errorf("%s is invalid", path)
+	}
+	fmt.Printf("%s is valid\n", path)
+	return nil
+}
+
+func printConfigPath(c *cli.Context) error {
+	scope, err := configScope(c, "")
+	if err != nil {
+		return err
+	}
+
+	if scope != "" {
+		path, err := scopePath(scope)
+		if err != nil {
+			return err
+		}
+		fmt.Println(path)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, s := range []string{scopeGlobal, scopeRepo} {
+		path, err := scopePath(s)
+		if err != nil {
+			continue
+		}
+		state := "exists"
+		if _, err := os.Stat(path); err != nil {
+			state = "not found"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", s, path, state)
+	}
+	return w.Flush()
+}
+
+func printEnvVars() error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VARIABLE\tTYPE\tCURRENT\tDESCRIPTION")
+	for _, v := range config.EnvVars(llm.ProviderConfigSchemas()) {
+		current := "-"
+		if value, ok := os.LookupEnv(v.Name); ok {
+			current = displayValue(v.Key, value, false)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", v.Name, v.Type, current, v.Description)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Printf("\nUse %q between nesting levels; a single underscore is part of the key name.\n", config.EnvSeparator)
+	return nil
+}
+
+func printSchema() error {
+	schema := config.Schema(llm.ProviderConfigSchemas())
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode config schema: %w", err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// isSecretKey reports whether a flattened config key holds a credential
+func isSecretKey(key string) bool {
+	return strings.HasSuffix(key, "api_key")
+}
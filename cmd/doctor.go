@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/klauern/muse/config"
+	"github.com/klauern/muse/internal/doctor"
+	"github.com/urfave/cli/v2"
+)
+
+// ConfigErrorKey is the App.Metadata key holding the error from loading the
+// config, so doctor can report it instead of aborting
+const ConfigErrorKey = "config_error"
+
+func NewDoctorCmd(cfg *config.Config) *cli.Command {
+	return &cli.Command{
+		Name:  "doctor",
+		Usage: "Diagnose configuration, credentials, provider and hook problems",
+		Flags: []cli.Flag{outputFlag()},
+		Action: func(c *cli.Context) error {
+			output, err := outputFormat(c)
+			if err != nil {
+				return err
+			}
+			d := doctor.New(cfg, nil)
+			if cfgErr, ok := c.App.Metadata[ConfigErrorKey].(error); ok {
+				d = doctor.New(nil, cfgErr)
+			}
+			results := doctor.RunChecks(c.Context, d.Checks())
+
+			write := doctor.WriteReport
+			if output == "json" {
+				write = doctor.WriteJSON
+			}
+			if err := write(os.Stdout, results); err != nil {
+				return err
+			}
+
+			if failures := doctor.Failures(results); failures > 0 {
+				return fmt.Errorf("doctor found %d failing checks", failures)
+			}
+			return nil
+		},
+	}
+}
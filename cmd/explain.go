@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/klauern/muse/config"
+	"github.com/klauern/muse/internal/explain"
+	"github.com/klauern/muse/internal/git"
+	"github.com/klauern/muse/internal/ledger"
+	"github.com/klauern/muse/llm"
+	"github.com/klauern/muse/templates"
+	"github.com/urfave/cli/v2"
+)
+
+func NewExplainCmd(cfg *config.Config) *cli.Command {
+	return &cli.Command{
+		Name:      "explain",
+		Usage:     "Explain a commit or range of commits in plain language",
+		ArgsUsage: "<rev|range>",
+		Description: "Sends the git show output of a commit, such as HEAD or abc123, or a range, such as v1.2.0..v1.3.0,\n" +
+			"to the model and prints an explanation of what changed and why it might matter.",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "audience",
+				Aliases: []string{"a"},
+				Value:   explain.DefaultAudience,
+				Usage:   "Who the explanation is for: reviewer, release-manager or newcomer",
+			},
+			&cli.StringFlag{
+				Name:  "format",
+				Value: "markdown",
+				Usage: "Output format: markdown or json",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			return runExplain(c, cfg)
+		},
+	}
+}
+
+func runExplain(c *cli.Context, cfg *config.Config) error {
+	if c.NArg() != 1 {
+		return fmt.Errorf("expected one revision or range, such as HEAD or v1.2.0..HEAD")
+	}
+	rev := c.Args().First()
+	format := c.String("format")
+	if format != "markdown" && format != "json" {
+		return fmt.Errorf("unknown format %q; use markdown or json", format)
+	}
+	audience := c.String("audience")
+	audiences, err := templates.Audiences()
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(audiences, audience) {
+		return fmt.Errorf("unknown audience %q; available audiences: %s", audience, strings.Join(audiences, ", "))
+	}
+
+	ops, err := git.NewGitOperations("")
+	if err != nil {
+		return fmt.Errorf("failed to initialize git operations: %w", err)
+	}
+	change, err := explain.Collect(ops, rev)
+	if err != nil {
+		return err
+	}
+
+	service, err := llm.NewLLMService(&cfg.LLM)
+	if err != nil {
+		return fmt.Errorf("failed to create LLM service: %w", err)
+	}
+
+	ctx, gen := ledger.Start(c.Context, cfg, "explain")
+	if format == "markdown" {
+		text, err := explain.Markdown(ctx, service, change, audience)
+		if err != nil {
+			return fmt.Errorf("failed to explain %s: %w", rev, err)
+		}
+		gen.Record("")
+		fmt.Println(text)
+		return nil
+	}
+
+	explanation, err := explain.Structured(ctx, service, change, audience)
+	if err != nil {
+		return fmt.Errorf("failed to explain %s: %w", rev, err)
+	}
+	gen.Record("")
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Revision string `json:"revision"`
+		Audience string `json:"audience"`
+		*templates.Explanation
+	}{rev, audience, explanation})
+}
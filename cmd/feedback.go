@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/klauern/muse/config"
+	"github.com/klauern/muse/internal/feedback"
+	"github.com/klauern/muse/internal/fileops"
+	"github.com/klauern/muse/internal/ledger"
+	"github.com/urfave/cli/v2"
+)
+
+func NewFeedbackCmd(cfg *config.Config) *cli.Command {
+	return &cli.Command{
+		Name:  "feedback",
+		Usage: "Work with the recorded edits to generated commit messages",
+		Description: "The post-commit hook installed with 'muse install --post-commit' compares each\n" +
+			"commit with the message muse generated for it and records both in\n" +
+			"feedback.jsonl under $XDG_STATE_HOME/muse (~/.local/state/muse by default).",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "export",
+				Usage: "Print the recorded message pairs as JSON lines for offline prompt tuning",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "repo",
+						Usage: "Only export pairs from the repository containing `DIR`",
+					},
+					&cli.IntFlag{
+						Name:  "days",
+						Usage: "Only export pairs from the last `N` days, or every pair when 0",
+					},
+					&cli.BoolFlag{
+						Name:  "edited",
+						Usage: "Only export messages that were edited or rewritten, not those accepted as generated",
+					},
+					&cli.StringFlag{
+						Name:  "file",
+						Usage: "Write the pairs to `FILE` instead of stdout",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					return exportFeedback(c)
+				},
+			},
+		},
+	}
+}
+
+func exportFeedback(c *cli.Context) error {
+	if c.Int("days") < 0 {
+		return fmt.Errorf("--days must be 0 or more, got %d", c.Int("days"))
+	}
+	repo := ""
+	if c.IsSet("repo") {
+		if repo = config.RepoRootOf(c.String("repo")); repo == "" {
+			return fmt.Errorf("%s is not in a git repository", c.String("repo"))
+		}
+	}
+
+	store, err := feedback.DefaultStore()
+	if err != nil {
+		return err
+	}
+	var since time.Time
+	if days := c.Int("days"); days > 0 {
+		since = time.Now().AddDate(0, 0, -days)
+	}
+	pairs, err := store.Pairs(since)
+	if err != nil {
+		return err
+	}
+	pairs = slices.DeleteFunc(pairs, func(p feedback.Pair) bool {
+		return (repo != "" && p.Repo != repo) || (c.Bool("edited") && p.Outcome == ledger.OutcomeAccepted)
+	})
+
+	if path := c.String("file"); path != "" {
+		var buf bytes.Buffer
+		if err := writePairs(&buf, pairs); err != nil {
+			return err
+		}
+		if err := fileops.AtomicWriteFile(path, buf.Bytes(), 0o600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		fmt.Fprintf(os.Stderr, "Wrote %d pairs to %s\n", len(pairs), path)
+		return nil
+	}
+	return writePairs(os.Stdout, pairs)
+}
+
+// writePairs writes one JSON object per line
+func writePairs(w io.Writer, pairs []feedback.Pair) error {
+	out := bufio.NewWriter(w)
+	enc := json.NewEncoder(out)
+	for _, p := range pairs {
+		if err := enc.Encode(p); err != nil {
+			return fmt.Errorf("failed to encode feedback: %w", err)
+		}
+	}
+	return out.Flush()
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/klauern/muse/config"
+	"github.com/klauern/muse/internal/configloader"
+	"github.com/urfave/cli/v2"
+)
+
+// GlobalFlags returns the flags that override configuration values for any
+// command. They form the highest-precedence config layer.
+func GlobalFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "provider",
+			Usage: "Override llm.provider",
+		},
+		&cli.StringFlag{
+			Name:  "model",
+			Usage: "Override llm.config.model",
+		},
+		&cli.StringFlag{
+			Name:  "style",
+			Usage: "Override hook.commit_style",
+		},
+		&cli.StringFlag{
+			Name:  "profile",
+			Usage: "Apply a named profile from the config",
+		},
+		&cli.BoolFlag{
+			Name:  "no-cache",
+			Usage: "Always call the LLM instead of reusing a cached message",
+		},
+		outputFlag(),
+	}
+}
+
+// ConfigOverrides returns the flattened config keys set by GlobalFlags
+func ConfigOverrides(c *cli.Context) map[string]any {
+	flagKeys := map[string]string{
+		"provider": "llm.provider",
+		"model":    "llm.config.model",
+		"style":    "hook.commit_style",
+		"profile":  "profile",
+	}
+
+	overrides := map[string]any{}
+	for flag, key := range flagKeys {
+		if c.IsSet(flag) {
+			overrides[key] = c.String(flag)
+		}
+	}
+	if c.Bool("no-cache") {
+		overrides["cache.enabled"] = false
+	}
+	return overrides
+}
+
+// commandOverrideFlags returns the GlobalFlags that commands generating
+// messages also accept after their name, as in muse generate --style gitmoji
+func commandOverrideFlags() []cli.Flag {
+	var flags []cli.Flag
+	for _, f := range GlobalFlags() {
+		if slices.Contains([]string{"provider", "model", "style", "no-cache"}, f.Names()[0]) {
+			flags = append(flags, f)
+		}
+	}
+	return flags
+}
+
+// applyCommandOverrides reloads cfg with the overrides given after the
+// command name, which the app's Before runs too early to see. Overrides of
+// the command win over those given before it.
+func applyCommandOverrides(c *cli.Context, cfg *config.Config) error {
+	overrides := map[string]any{}
+	lineage := c.Lineage()
+	for i := len(lineage) - 1; i >= 0; i-- {
+		maps.Copy(overrides, ConfigOverrides(lineage[i]))
+	}
+
+	loader := configloader.GetConfigLoader()
+	loader.SetOverrides(overrides)
+	loaded, err := loader.LoadConfigSafe()
+	if err != nil {
+		return fmt.Errorf("error loading config: %w", err)
+	}
+	*cfg = *loaded
+	return nil
+}
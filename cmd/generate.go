@@ -0,0 +1,220 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/klauern/muse/config"
+	"github.com/klauern/muse/internal/git"
+	"github.com/klauern/muse/internal/ledger"
+	"github.com/klauern/muse/llm"
+	"github.com/klauern/muse/templates"
+	"github.com/urfave/cli/v2"
+)
+
+// maxDiffFileSize matches the limit git diffs are held to
+const maxDiffFileSize = 1024 * 1024
+
+func NewGenerateCmd(cfg *config.Config) *cli.Command {
+	flags := append(commandOverrideFlags(),
+		&cli.BoolFlag{
+			Name:  "working-tree",
+			Usage: "Describe the staged and unstaged changes to tracked files instead of the staged changes",
+		},
+		&cli.StringFlag{
+			Name:  "rev",
+			Usage: "Describe the changes made by a commit",
+		},
+		&cli.StringFlag{
+			Name:  "range",
+			Usage: "Describe the changes between two revisions, such as main..HEAD",
+		},
+		&cli.StringFlag{
+			Name:  "diff-file",
+			Usage: "Describe the diff in a file, or on stdin with -",
+		},
+		outputFlag(),
+		&cli.BoolFlag{
+			Name:  "commit",
+			Usage: "Commit the staged changes with the generated message",
+		},
+	)
+
+	return &cli.Command{
+		Name:  "generate",
+		Usage: "Generate a commit message and print it",
+		Description: "Describes the staged changes unless another diff source is given.\n" +
+			"--provider, --model and --style override the configuration for this run.",
+		Flags: flags,
+		Action: func(c *cli.Context) error {
+			if err := applyCommandOverrides(c, cfg); err != nil {
+				return err
+			}
+			return runGenerate(c, cfg)
+		},
+	}
+}
+
+// generateResult is the json output of muse generate
+type generateResult struct {
+	Message string `json:"message"`
+	// Commit is the message split into its parts; messages that are not
+	// conventional commits only have a subject and body
+	Commit     *templates.ParsedCommit `json:"commit"`
+	Style      string                  `json:"style"`
+	Provider   string                  `json:"provider"`
+	Model      string                  `json:"model,omitempty"`
+	Source     string                  `json:"source"`
+	Usage      llm.Usage               `json:"usage"`
+	DurationMS int64                   `json:"duration_ms"`
+	// Hash is the commit created with --commit
+	Hash string `json:"hash,omitempty"`
+}
+
+func runGenerate(c *cli.Context, cfg *config.Config) error {
+	output, err := outputFormat(c)
+	if err != nil {
+		return err
+	}
+
+	ops, err := git.NewGitOperations("")
+	if err != nil {
+		return fmt.Errorf("failed to initialize git operations: %w", err)
+	}
+	source, diff, err := readGenerateDiff(c, ops)
+	if err != nil {
+		return err
+	}
+	if c.Bool("commit") && source != "staged" {
+		return fmt.Errorf("--commit only applies to the staged changes, not to --%s", source)
+	}
+	if strings.TrimSpace(diff) == "" {
+		if source == "staged" {
+			return fmt.Errorf("nothing is staged; stage changes with git add or choose another diff source")
+		}
+		return fmt.Errorf("no changes to describe in the %s diff", source)
+	}
+
+	ctx, gen := ledger.Start(c.Context, cfg, "generate")
+	message, err := generateCommitMessage(ctx, cfg, diff)
+	if err != nil {
+		return err
+	}
+	gen.Stop()
+
+	result := generateResult{
+		Message:    message,
+		Commit:     templates.ParseCommit(message),
+		Style:      string(cfg.Hook.CommitStyle),
+		Provider:   cfg.LLM.Provider,
+		Source:     source,
+		Usage:      gen.Usage(),
+		DurationMS: gen.Elapsed().Milliseconds(),
+	}
+	if model, ok := cfg.LLM.Config["model"].(string); ok {
+		result.Model = model
+	}
+	// A message committed as generated was accepted; otherwise the caller
+	// decides what to do with it
+	outcome := ""
+	if c.Bool("commit") {
+		result.Hash, err = ops.Commit(c.Context, message)
+		if err == nil {
+			outcome = ledger.OutcomeAccepted
+		}
+	}
+	gen.Record(outcome)
+	if err != nil {
+		return err
+	}
+
+	if output == "json" {
+		return writeJSON(result)
+	}
+	fmt.Println(strings.TrimSpace(message))
+	if result.Hash != "" {
+		fmt.Printf("\nCommitted %s\n", result.Hash[:7])
+	}
+	return nil
+}
+
+// readGenerateDiff returns the diff selected by the flags and the name of
+// its source
+func readGenerateDiff(c *cli.Context, ops *git.GitOperations) (string, string, error) {
+	var sources []string
+	for _, name := range []string{"working-tree", "rev", "range", "diff-file"} {
+		if c.IsSet(name) {
+			sources = append(sources, "--"+name)
+		}
+	}
+	if len(sources) > 1 {
+		return "", "", fmt.Errorf("choose one diff source, got %s", strings.Join(sources, " and "))
+	}
+
+	var diff string
+	var err error
+	switch {
+	case c.Bool("working-tree"):
+		diff, err = ops.GetWorkingTreeDiff()
+		return "working-tree", diff, err
+	case c.IsSet("rev"):
+		diff, err = ops.GetCommitDiff(c.String("rev"))
+		return "rev", diff, err
+	case c.IsSet("range"):
+		diff, err = rangeDiff(ops, c.String("range"))
+		return "range", diff, err
+	case c.IsSet("diff-file"):
+		diff, err = readDiffFile(c.String("diff-file"))
+		return "diff-file", diff, err
+	}
+	diff, err = ops.GetStagedDiff()
+	return "staged", diff, err
+}
+
+// rangeDiff returns the diff of a from..to range, or of from...to since the
+// merge base as GitHub shows it. Omitted ends default to HEAD.
+func rangeDiff(ops *git.GitOperations, revRange string) (string, error) {
+	separator := ".."
+	if strings.Contains(revRange, "...") {
+		separator = "..."
+	}
+	from, to, found := strings.Cut(revRange, separator)
+	if !found {
+		return "", fmt.Errorf("expected a range such as main..HEAD, got %q", revRange)
+	}
+	if from == "" {
+		from = "HEAD"
+	}
+	if to == "" {
+		to = "HEAD"
+	}
+
+	if separator == "..." {
+		base, err := ops.GetMergeBase(from, to)
+		if err != nil {
+			return "", err
+		}
+		from = base
+	}
+	return ops.GetRangeDiff(from, to)
+}
+
+// readDiffFile reads a diff from path, or from stdin when path is -
+func readDiffFile(path string) (string, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(io.LimitReader(os.Stdin, maxDiffFileSize+1))
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read diff: %w", err)
+	}
+	if len(data) > maxDiffFileSize {
+		return "", fmt.Errorf("%w, maximum allowed: %d bytes", git.ErrDiffTooLarge, maxDiffFileSize)
+	}
+	return string(data), nil
+}
@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"fmt"
+
 	"github.com/klauern/muse/config"
 	"github.com/klauern/muse/hooks"
 	"github.com/urfave/cli/v2"
@@ -10,9 +12,54 @@ func NewInstallCmd(config *config.Config) *cli.Command {
 	return &cli.Command{
 		Name:  "install",
 		Usage: "Install the prepare-commit-msg hook",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "global",
+				Usage: "Install for all repositories via core.hooksPath",
+			},
+			&cli.BoolFlag{
+				Name:  "template",
+				Usage: "With --global, install via init.templateDir so only new clones get the hook",
+			},
+			&cli.BoolFlag{
+				Name:  "pre-commit",
+				Usage: "Also install a pre-commit hook that runs muse review on the staged changes",
+			},
+			&cli.BoolFlag{
+				Name:  "post-commit",
+				Usage: "Install the post-commit hook that records how you edit generated messages even when usage.enabled and feedback.enabled are false",
+			},
+		},
 		Action: func(c *cli.Context) error {
 			installer := hooks.NewInstaller(config)
-			return installer.Install()
+			if c.Bool("pre-commit") && c.Bool("global") {
+				return fmt.Errorf("--pre-commit cannot be combined with --global")
+			}
+			// The post-commit hook fills in the ledger outcome and the feedback
+			// pairs, so it comes along whenever either is recorded
+			postCommit := c.Bool("post-commit") || config.Usage.Enabled || config.Feedback.Enabled
+			if c.Bool("global") {
+				mode := hooks.HooksPathMode
+				if c.Bool("template") {
+					mode = hooks.TemplateDirMode
+				}
+				return installer.InstallGlobal(mode, postCommit)
+			}
+			if c.Bool("template") {
+				return fmt.Errorf("--template requires --global")
+			}
+			if err := installer.Install(); err != nil {
+				return err
+			}
+			if c.Bool("pre-commit") {
+				if err := installer.InstallPreCommit(); err != nil {
+					return err
+				}
+			}
+			if postCommit {
+				return installer.InstallPostCommit()
+			}
+			return nil
 		},
 	}
 }
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/klauern/muse/config"
+	"github.com/klauern/muse/internal/exitcode"
+	"github.com/klauern/muse/internal/lint"
+	"github.com/urfave/cli/v2"
+)
+
+func NewLintCmd(cfg *config.Config) *cli.Command {
+	return &cli.Command{
+		Name:      "lint",
+		Usage:     "Check a commit message against the configured commit style",
+		ArgsUsage: "[file]",
+		Description: "Reads the message from file, such as .git/COMMIT_EDITMSG, or from stdin.\n" +
+			"Exits with status 6 when the message has errors.",
+		Flags: []cli.Flag{outputFlag()},
+		Action: func(c *cli.Context) error {
+			return runLint(c, cfg)
+		},
+	}
+}
+
+// lintResult is the output of muse lint --output json
+type lintResult struct {
+	File   string       `json:"file"`
+	Valid  bool         `json:"valid"`
+	Issues []lint.Issue `json:"issues"`
+}
+
+func runLint(c *cli.Context, cfg *config.Config) error {
+	output, err := outputFormat(c)
+	if err != nil {
+		return err
+	}
+
+	name := "stdin"
+	var data []byte
+	if c.NArg() > 0 {
+		name = c.Args().First()
+		data, err = os.ReadFile(name)
+	} else {
+		data, err = io.ReadAll(os.Stdin)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read commit message: %w", err)
+	}
+
+	issues := lint.Lint(string(data), cfg.Hook.CommitStyle)
+	if output == "json" {
+		result := lintResult{File: name, Valid: !lint.HasErrors(issues), Issues: issues}
+		if result.Issues == nil {
+			result.Issues = []lint.Issue{}
+		}
+		if err := writeJSON(result); err != nil {
+			return err
+		}
+	} else {
+		for _, issue := range issues {
+			fmt.Printf("%s:%s\n", name, issue)
+		}
+	}
+	if lint.HasErrors(issues) {
+		return cli.Exit("", exitcode.Validation)
+	}
+	return nil
+}
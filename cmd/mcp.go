@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/klauern/muse/config"
+	"github.com/klauern/muse/internal/mcp"
+	"github.com/urfave/cli/v2"
+)
+
+func NewMCPCmd(cfg *config.Config) *cli.Command {
+	return &cli.Command{
+		Name:  "mcp",
+		Usage: "Run a Model Context Protocol server on stdio for coding agents",
+		Action: func(c *cli.Context) error {
+			// stdout carries the protocol, so logs must go to stderr
+			level := slog.LevelInfo
+			if c.Bool("verbose") {
+				level = slog.LevelDebug
+			}
+			slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: level})))
+
+			ctx, stop := signal.NotifyContext(c.Context, os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			return mcp.NewServer(cfg, Version).Serve(ctx, os.Stdin, os.Stdout)
+		},
+	}
+}
@@ -7,23 +7,32 @@ import (
 
 	"github.com/klauern/muse/cmd"
 	"github.com/klauern/muse/config"
+	"github.com/klauern/muse/internal/configloader"
 	"github.com/urfave/cli/v2"
 )
 
-func loadConfig() (*config.Config, error) {
-	cfg, err := config.LoadConfig()
+// configTolerantCommands still run when the config cannot be loaded
+var configTolerantCommands = map[string]bool{
+	"doctor": true,
+	"config": true,
+	"auth":   true,
+	"rpc":    true,
+}
+
+func loadConfig(c *cli.Context) (*config.Config, error) {
+	loader := configloader.GetConfigLoader()
+	loader.SetOverrides(cmd.ConfigOverrides(c))
+
+	cfg, err := loader.LoadConfigSafe()
 	if err != nil {
-		return nil, fmt.Errorf("error loading config: %v", err)
+		return nil, fmt.Errorf("error loading config: %w", err)
 	}
 	return cfg, nil
 }
 
 func main() {
-	cfg, err := loadConfig()
-	if err != nil {
-		fmt.Printf("Error loading config: %v\n", err)
-		os.Exit(1)
-	}
+	// Commands share this config, which is filled in once flags are parsed
+	cfg := &config.Config{}
 
 	app := &cli.App{
 		Name:    "muse",
@@ -35,6 +44,25 @@ func main() {
 			cmd.NewUninstallCmd(cfg),
 			cmd.NewConfigureCmd(cfg),
 			cmd.NewPrepareCommitMsgCmd(cfg),
+			cmd.NewGenerateCmd(cfg),
+			cmd.NewDoctorCmd(cfg),
+			cmd.NewConfigCmd(cfg),
+			cmd.NewAuthCmd(cfg),
+			cmd.NewCacheCmd(cfg),
+			cmd.NewWatchCmd(cfg),
+			cmd.NewServeCmd(cfg),
+			cmd.NewLintCmd(cfg),
+			cmd.NewMCPCmd(cfg),
+			cmd.NewPRCmd(cfg),
+			cmd.NewChangelogCmd(cfg),
+			cmd.NewRewordCmd(cfg),
+			cmd.NewSplitCmd(cfg),
+			cmd.NewReviewCmd(cfg),
+			cmd.NewExplainCmd(cfg),
+			cmd.NewRPCCmd(cfg),
+			cmd.NewStatsCmd(cfg),
+			cmd.NewPostCommitCmd(cfg),
+			cmd.NewFeedbackCmd(cfg),
 			{
 				Name:  "version",
 				Usage: "Print the version",
@@ -44,7 +72,21 @@ func main() {
 				},
 			},
 		},
+		Flags:          cmd.GlobalFlags(),
+		ExitErrHandler: cmd.HandleExitError,
 		Before: func(c *cli.Context) error {
+			loaded, err := loadConfig(c)
+			if err != nil {
+				// A broken config is reported by doctor and config, and by
+				// rpc for each request, rather than aborting them
+				if !configTolerantCommands[c.Args().First()] {
+					return err
+				}
+				c.App.Metadata[cmd.ConfigErrorKey] = err
+			} else {
+				*cfg = *loaded
+			}
+
 			if c.Bool("verbose") {
 				slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug})))
 			}
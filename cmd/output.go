@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/klauern/muse/internal/exitcode"
+	"github.com/urfave/cli/v2"
+)
+
+// outputFlag selects between text and json output. It is a global flag and
+// is repeated on the commands that support json, so it works on either side
+// of the command name.
+func outputFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:    "output",
+		Aliases: []string{"o"},
+		Value:   "text",
+		Usage:   "Output format: text or json",
+	}
+}
+
+// outputFormat returns the --output given after the command name, or else
+// the global one
+func outputFormat(c *cli.Context) (string, error) {
+	format := "text"
+	for _, ctx := range c.Lineage() {
+		if ctx.IsSet("output") {
+			format = ctx.String("output")
+			break
+		}
+	}
+	if format != "text" && format != "json" {
+		return "", fmt.Errorf("unknown output format %q; use text or json", format)
+	}
+	return format, nil
+}
+
+// writeJSON prints v to stdout as indented JSON
+func writeJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// errorOutput is how errors are printed with --output json
+type errorOutput struct {
+	Class   string `json:"class"`
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// HandleExitError prints err, as JSON with --output json, and exits with
+// the status of its class. It is the app's ExitErrHandler.
+func HandleExitError(c *cli.Context, err error) {
+	if err == nil {
+		return
+	}
+
+	code := exitcode.Classify(err)
+	// Errors without a message, such as lint failures, have already been
+	// reported by the command
+	if message := err.Error(); message != "" {
+		if format, _ := outputFormat(c); format == "json" {
+			_ = writeJSON(map[string]errorOutput{"error": {Class: exitcode.Class(code), Code: code, Message: message}})
+		} else {
+			fmt.Printf("Error: %v\n", err)
+		}
+	}
+	cli.OsExiter(code)
+}
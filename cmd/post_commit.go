@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/klauern/muse/config"
+	"github.com/klauern/muse/hooks"
+	"github.com/klauern/muse/internal/feedback"
+	"github.com/klauern/muse/internal/git"
+	"github.com/klauern/muse/internal/ledger"
+	"github.com/urfave/cli/v2"
+)
+
+func NewPostCommitCmd(cfg *config.Config) *cli.Command {
+	return &cli.Command{
+		Name:  "post-commit",
+		Usage: "Run the post-commit hook, which records how the generated message was edited",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "verbose",
+				Usage: "Enable verbose logging",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if c.Bool("verbose") {
+				slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug})))
+			}
+			// The commit is already made, so failures are only reported
+			if err := runPostCommit(cfg); err != nil {
+				slog.Warn("Failed to record commit message feedback", "error", err)
+			}
+			return nil
+		},
+	}
+}
+
+func runPostCommit(cfg *config.Config) error {
+	ops, err := git.NewGitOperations("")
+	if err != nil {
+		return fmt.Errorf("failed to initialize git operations: %w", err)
+	}
+	gitDir, err := ops.GetGitDir()
+	if err != nil {
+		return err
+	}
+	pending, err := feedback.TakePending(gitDir)
+	if err != nil || pending == nil {
+		return err
+	}
+
+	head, err := ops.GetCommitInfo("HEAD")
+	if err != nil {
+		return err
+	}
+	parent := ""
+	if len(head.Parents) > 0 {
+		parent = head.Parents[0]
+	}
+	if parent != pending.Parent {
+		// The commit was not made from the generated message, such as an
+		// amend or a commit made without the prepare-commit-msg hook
+		slog.Debug("Skipping feedback for a commit muse did not write", "parent", parent, "pending_parent", pending.Parent)
+		return nil
+	}
+
+	message, err := ops.GetCommitMessage("HEAD")
+	if err != nil {
+		return err
+	}
+	outcome, edit := feedback.Compare(pending.Message, message)
+	slog.Debug("Generated message compared with the commit", "outcome", outcome)
+	ledger.RecordOutcome(cfg, pending.LedgerID, outcome)
+
+	if !cfg.Feedback.Enabled {
+		return nil
+	}
+	store, err := feedback.DefaultStore()
+	if err != nil {
+		return err
+	}
+	return store.Append(feedback.Pair{
+		Time:      time.Now().UTC(),
+		Repo:      config.RepoRoot(),
+		Commit:    head.Hash,
+		Style:     pending.Style,
+		Provider:  pending.Provider,
+		Model:     pending.Model,
+		Files:     pending.Files,
+		Generated: pending.Message,
+		Committed: message,
+		Diff:      edit,
+		Outcome:   outcome,
+	})
+}
+
+// trackPendingMessage keeps the message the hook wrote so the post-commit
+// hook can compare it with the commit. Nothing is kept when no post-commit
+// hook would read it. Failures are only logged.
+func trackPendingMessage(cfg *config.Config, gen *ledger.Generation, diff, message string) {
+	if !cfg.Feedback.Enabled && !cfg.Usage.Enabled {
+		return
+	}
+	if !hooks.PostCommitInstalled() {
+		slog.Debug("Not keeping the generated message; the post-commit hook is not installed")
+		return
+	}
+	ops, err := git.NewGitOperations("")
+	if err == nil {
+		var gitDir string
+		if gitDir, err = ops.GetGitDir(); err == nil {
+			pending := feedback.Pending{
+				LedgerID: gen.ID,
+				Time:     time.Now().UTC(),
+				Parent:   headOrEmpty(ops),
+				Style:    string(cfg.Hook.CommitStyle),
+				Provider: cfg.LLM.Provider,
+				Files:    feedback.DiffFiles(diff),
+				Message:  message,
+			}
+			pending.Model, _ = cfg.LLM.Config["model"].(string)
+			err = feedback.SavePending(gitDir, pending)
+		}
+	}
+	if err != nil {
+		slog.Warn("Failed to keep the generated message for feedback", "error", err)
+	}
+}
+
+// settleAbandonedMessage looks at the message kept by an earlier run of the
+// hook. When HEAD has not moved since, that commit was aborted and its
+// message rejected.
+func settleAbandonedMessage(cfg *config.Config) {
+	ops, err := git.NewGitOperations("")
+	if err != nil {
+		return
+	}
+	gitDir, err := ops.GetGitDir()
+	if err != nil {
+		return
+	}
+	pending, err := feedback.TakePending(gitDir)
+	if err != nil {
+		slog.Debug("Discarding pending message", "error", err)
+		return
+	}
+	if pending != nil && pending.Parent == headOrEmpty(ops) {
+		ledger.RecordOutcome(cfg, pending.LedgerID, ledger.OutcomeRejected)
+	}
+}
+
+// headOrEmpty returns the commit HEAD points to, or an empty string on an
+// unborn branch
+func headOrEmpty(ops *git.GitOperations) string {
+	head, err := ops.ResolveCommit("HEAD")
+	if err != nil {
+		return ""
+	}
+	return head
+}
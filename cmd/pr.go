@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/klauern/muse/config"
+	"github.com/klauern/muse/internal/fileops"
+	"github.com/klauern/muse/internal/git"
+	"github.com/klauern/muse/internal/ledger"
+	"github.com/klauern/muse/internal/pr"
+	"github.com/klauern/muse/llm"
+	"github.com/urfave/cli/v2"
+)
+
+func NewPRCmd(cfg *config.Config) *cli.Command {
+	return &cli.Command{
+		Name:  "pr",
+		Usage: "Generate a pull request title and description for the current branch",
+		Description: "Describes the commits and changes between the merge base with the target branch and HEAD.\n" +
+			"Prints the title as a heading followed by the description.",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "target",
+				Aliases: []string{"t"},
+				Usage:   "Branch the pull request merges into (default: origin's HEAD, main or master)",
+			},
+			&cli.StringFlag{
+				Name:    "file",
+				Aliases: []string{"f"},
+				Usage:   "Write the description to a file instead of stdout",
+			},
+			&cli.BoolFlag{
+				Name:  "fill-template",
+				Usage: "Follow the structure of the repository's .github/pull_request_template.md",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			return runPR(c, cfg)
+		},
+	}
+}
+
+func runPR(c *cli.Context, cfg *config.Config) error {
+	ops, err := git.NewGitOperations("")
+	if err != nil {
+		return fmt.Errorf("failed to initialize git operations: %w", err)
+	}
+
+	target := c.String("target")
+	if target == "" {
+		if target, err = ops.GetDefaultBranch(); err != nil {
+			return err
+		}
+	}
+	slog.Debug("Describing branch", "target", target)
+
+	branch, err := pr.Collect(ops, target)
+	if err != nil {
+		return err
+	}
+
+	var template string
+	if c.Bool("fill-template") {
+		root, err := ops.GetRepositoryRoot()
+		if err != nil {
+			return err
+		}
+		if template, err = pr.FindTemplate(root); err != nil {
+			return err
+		}
+		if template == "" {
+			return fmt.Errorf("no pull request template found; looked for %s", strings.Join(pr.TemplatePaths, ", "))
+		}
+	}
+
+	service, err := llm.NewLLMService(&cfg.LLM)
+	if err != nil {
+		return fmt.Errorf("failed to create LLM service: %w", err)
+	}
+	ctx, gen := ledger.Start(c.Context, cfg, "pr")
+	description, err := pr.Generate(ctx, service, branch, template)
+	if err != nil {
+		return fmt.Errorf("failed to generate pull request description: %w", err)
+	}
+	gen.Record("")
+
+	if path := c.String("file"); path != "" {
+		if err := fileops.AtomicWriteFile(path, []byte(description.Markdown()), 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		fmt.Printf("Wrote %s\n", path)
+		return nil
+	}
+	fmt.Print(description.Markdown())
+	return nil
+}
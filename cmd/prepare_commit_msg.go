@@ -9,9 +9,11 @@ import (
 
 	"github.com/briandowns/spinner"
 	"github.com/klauern/muse/config"
+	"github.com/klauern/muse/internal/daemon"
+	"github.com/klauern/muse/internal/feedback"
 	"github.com/klauern/muse/internal/fileops"
 	"github.com/klauern/muse/internal/git"
-	"github.com/klauern/muse/llm"
+	"github.com/klauern/muse/internal/ledger"
 	"github.com/urfave/cli/v2"
 )
 
@@ -28,6 +30,10 @@ func NewPrepareCommitMsgCmd(cfg *config.Config) *cli.Command {
 				Name:  "generate",
 				Usage: "Generate and print a commit message without writing to a file",
 			},
+			&cli.BoolFlag{
+				Name:  "no-cache",
+				Usage: "Always call the LLM instead of reusing a cached message",
+			},
 		},
 		Action: func(c *cli.Context) error {
 			if c.Bool("verbose") {
@@ -40,11 +46,14 @@ func NewPrepareCommitMsgCmd(cfg *config.Config) *cli.Command {
 
 func runPrepareCommitMsg(c *cli.Context, cfg *config.Config) error {
 	generateOnly := c.Bool("generate")
+	if c.Bool("no-cache") {
+		cfg.Cache.Enabled = false
+	}
 
 	slog.Debug("Verbose mode enabled")
 
 	if generateOnly {
-		return generateAndPrintCommitMessage(cfg)
+		return generateAndPrintCommitMessage(c, cfg)
 	}
 
 	commitMsgFile, commitSource, err := parseArguments(c)
@@ -54,6 +63,7 @@ func runPrepareCommitMsg(c *cli.Context, cfg *config.Config) error {
 
 	slog.Debug("Commit message file", "file", commitMsgFile)
 	slog.Debug("Commit source", "source", commitSource)
+	settleAbandonedMessage(cfg)
 
 	if shouldSkipHook(commitSource) {
 		slog.Debug("Skipping hook for commit source", "source", commitSource)
@@ -67,20 +77,24 @@ func runPrepareCommitMsg(c *cli.Context, cfg *config.Config) error {
 
 	slog.Debug("Git diff obtained", "length", len(diff))
 
-	message, err := generateCommitMessage(cfg, diff)
+	// Whether the message is kept is only known once the commit is made
+	ctx, gen := ledger.Start(c.Context, cfg, "prepare-commit-msg")
+	message, err := generateCommitMessage(ctx, cfg, diff)
 	if err != nil {
 		return err
 	}
+	gen.Record("")
 
 	if err := writeCommitMessage(commitMsgFile, message); err != nil {
 		return err
 	}
+	trackPendingMessage(cfg, gen, diff, message)
 
 	slog.Info("Prepare commit message hook executed successfully")
 	return nil
 }
 
-func generateAndPrintCommitMessage(cfg *config.Config) error {
+func generateAndPrintCommitMessage(c *cli.Context, cfg *config.Config) error {
 	diff, err := getGitDiff()
 	if err != nil {
 		return fmt.Errorf("failed to get git diff: %w", err)
@@ -88,13 +102,14 @@ func generateAndPrintCommitMessage(cfg *config.Config) error {
 
 	slog.Debug("Git diff obtained", "length", len(diff))
 
-	message, err := generateCommitMessage(cfg, diff)
+	ctx, gen := ledger.Start(c.Context, cfg, "prepare-commit-msg")
+	message, err := generateCommitMessage(ctx, cfg, diff)
 	if err != nil {
 		return err
 	}
+	gen.Record("")
 
-	slog.Info("Generated commit message", "message", message)
-
+	fmt.Println(message)
 	return nil
 }
 
@@ -135,16 +150,11 @@ func getGitDiff() (string, error) {
 	return diff, nil
 }
 
-func generateCommitMessage(cfg *config.Config, diff string) (string, error) {
+func generateCommitMessage(ctx context.Context, cfg *config.Config, diff string) (string, error) {
 	slog.Debug("Starting commit message generation")
-	generator, err := llm.NewCommitMessageGenerator(cfg)
-	if err != nil {
-		slog.Error("Failed to create commit message generator", "error", err)
-		return "", fmt.Errorf("failed to create commit message generator: %w", err)
-	}
-
-	slog.Debug("Commit message generator created successfully")
-	ctx := context.Background()
+	ctx = feedback.WithExamples(ctx, cfg, "", cfg.Hook.CommitStyle, diff)
+	// Use a running 'muse serve' daemon, or generate in-process
+	generator := daemon.NewGenerator(cfg)
 	slog.Debug("Generating commit message", "diff_length", len(diff), "commit_style", cfg.Hook.CommitStyle)
 
 	// Create and start the spinner
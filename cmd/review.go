@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/klauern/muse/config"
+	"github.com/klauern/muse/internal/exitcode"
+	"github.com/klauern/muse/internal/git"
+	"github.com/klauern/muse/internal/ledger"
+	"github.com/klauern/muse/internal/review"
+	"github.com/klauern/muse/llm"
+	"github.com/klauern/muse/templates"
+	"github.com/urfave/cli/v2"
+)
+
+func NewReviewCmd(cfg *config.Config) *cli.Command {
+	return &cli.Command{
+		Name:  "review",
+		Usage: "Review the staged changes for bugs and risky code",
+		Description: "Asks the model for a structured review of the staged diff.\n" +
+			"Exits with status 1 when an issue is at or above review.fail_on (error by default).",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "format",
+				Value: "text",
+				Usage: "Output format: text, json or sarif",
+			},
+			&cli.StringFlag{
+				Name:  "fail-on",
+				Usage: "Lowest severity that fails: error, warning, info or never (default: review.fail_on)",
+			},
+			&cli.BoolFlag{
+				Name:  "hook",
+				Usage: "Run as a pre-commit hook: report on stderr and let the commit through when the review cannot run",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			return runReview(c, cfg)
+		},
+	}
+}
+
+func runReview(c *cli.Context, cfg *config.Config) error {
+	format := c.String("format")
+	if !slices.Contains(review.Formats, format) {
+		return fmt.Errorf("unknown format %q; use %s", format, strings.Join(review.Formats, ", "))
+	}
+	failOn := cfg.Review.FailOn
+	if c.IsSet("fail-on") {
+		failOn = c.String("fail-on")
+	}
+	if !slices.Contains(config.ReviewSeverities, failOn) {
+		return fmt.Errorf("unknown severity %q; use %s", failOn, strings.Join(config.ReviewSeverities, ", "))
+	}
+
+	hook := c.Bool("hook")
+	var out io.Writer = os.Stdout
+	if hook {
+		// Hook output goes to the terminal running git commit
+		out = os.Stderr
+	}
+
+	// skip lets the commit through when the hook cannot review it
+	skip := func(err error) error {
+		slog.Warn("Skipping review", "error", err)
+		fmt.Fprintf(out, "muse: review skipped: %v\n", err)
+		return nil
+	}
+
+	ops, err := git.NewGitOperations("")
+	if err != nil {
+		err = fmt.Errorf("failed to initialize git operations: %w", err)
+		if hook {
+			return skip(err)
+		}
+		return err
+	}
+	diff, err := ops.GetStagedDiff()
+	if err != nil {
+		if hook {
+			// Such as ErrDiffTooLarge, which should not block the commit
+			return skip(err)
+		}
+		return err
+	}
+	if strings.TrimSpace(diff) == "" {
+		if hook {
+			return nil
+		}
+		return fmt.Errorf("nothing is staged; stage changes with git add")
+	}
+
+	result, err := reviewDiff(c, cfg, diff)
+	if err != nil {
+		if hook {
+			// A failing provider should not stop people from committing
+			return skip(err)
+		}
+		return err
+	}
+
+	if err := review.Write(out, result, format, Version); err != nil {
+		return fmt.Errorf("failed to write review: %w", err)
+	}
+
+	if blocking := review.Blocking(result.Issues, failOn); len(blocking) > 0 {
+		if hook {
+			fmt.Fprintf(out, "muse: %d issue(s) at or above %s; fix them or commit with --no-verify\n", len(blocking), failOn)
+		}
+		return cli.Exit("", exitcode.Validation)
+	}
+	return nil
+}
+
+func reviewDiff(c *cli.Context, cfg *config.Config, diff string) (*templates.CodeReview, error) {
+	service, err := llm.NewLLMService(&cfg.LLM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create LLM service: %w", err)
+	}
+	ctx, gen := ledger.Start(c.Context, cfg, "review")
+	result, err := review.Review(ctx, service, diff)
+	if err != nil {
+		return nil, fmt.Errorf("failed to review changes: %w", err)
+	}
+	gen.Record("")
+	return result, nil
+}
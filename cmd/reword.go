@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/klauern/muse/config"
+	"github.com/klauern/muse/internal/daemon"
+	"github.com/klauern/muse/internal/git"
+	"github.com/klauern/muse/internal/ledger"
+	"github.com/klauern/muse/internal/reword"
+	"github.com/klauern/muse/internal/userinput"
+	"github.com/urfave/cli/v2"
+)
+
+func NewRewordCmd(cfg *config.Config) *cli.Command {
+	return &cli.Command{
+		Name:      "reword",
+		Usage:     "Regenerate the messages of past commits on the current branch",
+		ArgsUsage: "<base>[..HEAD]",
+		Description: "Writes a new message for each commit after <base> from its diff and shows the old and new\n" +
+			"messages for review. On confirmation the commits are recreated with the same trees and authors\n" +
+			"and the branch is moved to the new head. Commits on protected remote branches are never rewritten.",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:    "dry-run",
+				Aliases: []string{"n"},
+				Usage:   "Show the plan without rewriting anything",
+			},
+			&cli.BoolFlag{
+				Name:    "yes",
+				Aliases: []string{"y"},
+				Usage:   "Apply the plan without asking",
+			},
+			&cli.StringSliceFlag{
+				Name:  "protected",
+				Usage: "Remote branch patterns whose commits must not be rewritten",
+				Value: cli.NewStringSlice(reword.DefaultProtectedBranches...),
+			},
+		},
+		Action: func(c *cli.Context) error {
+			return runReword(c, cfg)
+		},
+	}
+}
+
+func runReword(c *cli.Context, cfg *config.Config) error {
+	if c.NArg() != 1 {
+		return fmt.Errorf("expected the commit to reword after, such as HEAD~5 or main..HEAD")
+	}
+	base, to, found := strings.Cut(c.Args().First(), "..")
+	if found && to != "" && to != "HEAD" {
+		return fmt.Errorf("reword rewrites the commits up to HEAD; check out %s first", to)
+	}
+
+	ops, err := git.NewGitOperations("")
+	if err != nil {
+		return fmt.Errorf("failed to initialize git operations: %w", err)
+	}
+	plan, err := reword.NewPlan(ops, base, c.StringSlice("protected"))
+	if err != nil {
+		return err
+	}
+
+	total := len(plan.Steps)
+	ctx, gen := ledger.Start(c.Context, cfg, "reword")
+	err = plan.Generate(ctx, ops, daemon.NewGenerator(cfg), cfg.Hook.CommitStyle, func(i int, s reword.Step) {
+		fmt.Fprintf(os.Stderr, "[%d/%d] %s %s\n", i+1, total, s.Commit.ShortHash(), s.Commit.Subject())
+	})
+	if err != nil {
+		return err
+	}
+	gen.Stop()
+	// The new messages are accepted or rejected together
+	outcome := ""
+	defer func() { gen.Record(outcome) }()
+
+	printRewordPlan(plan)
+	if plan.Changes() == 0 {
+		fmt.Println("Every message is unchanged; nothing to do.")
+		return nil
+	}
+	if len(plan.Pushed) > 0 {
+		fmt.Printf("These commits are already on %s; pushing the result will need --force-with-lease.\n", strings.Join(plan.Pushed, ", "))
+	}
+	if c.Bool("dry-run") {
+		fmt.Println("Dry run; no commits were changed.")
+		return nil
+	}
+
+	if !c.Bool("yes") {
+		apply, err := userinput.NewSecureInputHandler().PromptYesNo(context.Background(), fmt.Sprintf("Reword %d commits?", plan.Changes()))
+		if err != nil {
+			return err
+		}
+		if !apply {
+			outcome = ledger.OutcomeRejected
+			return fmt.Errorf("%w; no commits were changed", userinput.ErrRejected)
+		}
+	}
+
+	head, err := reword.Apply(ops, plan)
+	if err != nil {
+		return err
+	}
+	outcome = ledger.OutcomeAccepted
+	fmt.Printf("Reworded %d commits; %s is now at %s.\n", plan.Changes(), plan.Ref, git.Commit{Hash: head}.ShortHash())
+	fmt.Printf("To undo, run: git update-ref %s %s\n", plan.Ref, plan.Head)
+	return nil
+}
+
+func printRewordPlan(plan *reword.Plan) {
+	for _, step := range plan.Steps {
+		fmt.Printf("\n%s %s\n", step.Commit.ShortHash(), step.Commit.Subject())
+		if !step.Changed() {
+			fmt.Println("  (unchanged)")
+			continue
+		}
+		printMessageLines("  - ", step.Commit.Message)
+		printMessageLines("  + ", step.NewMessage)
+	}
+	fmt.Println()
+}
+
+// printMessageLines prints every line of message after prefix
+func printMessageLines(prefix, message string) {
+	for _, line := range strings.Split(strings.TrimSpace(message), "\n") {
+		fmt.Println(strings.TrimRight(prefix+line, " "))
+	}
+}
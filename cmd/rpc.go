@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"log/slog"
+	"maps"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/klauern/muse/config"
+	"github.com/klauern/muse/internal/rpc"
+	"github.com/urfave/cli/v2"
+)
+
+func NewRPCCmd(cfg *config.Config) *cli.Command {
+	return &cli.Command{
+		Name:  "rpc",
+		Usage: "Serve editor plugins with JSON-RPC on stdio",
+		Description: "Speaks newline-delimited JSON-RPC 2.0 on stdin and stdout. Each request names a\n" +
+			"repository, whose config and profile are loaded as the hook would load them.",
+		Action: func(c *cli.Context) error {
+			// stdout carries the protocol, so logs must go to stderr
+			level := slog.LevelInfo
+			if c.Bool("verbose") {
+				level = slog.LevelDebug
+			}
+			slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: level})))
+
+			ctx, stop := signal.NotifyContext(c.Context, os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			server := rpc.NewServer(Version)
+			server.Overrides = map[string]any{}
+			for _, ctx := range c.Lineage() {
+				maps.Copy(server.Overrides, ConfigOverrides(ctx))
+			}
+			return server.Serve(ctx, os.Stdin, os.Stdout)
+		},
+	}
+}
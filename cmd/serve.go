@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/klauern/muse/config"
+	"github.com/klauern/muse/internal/daemon"
+	"github.com/urfave/cli/v2"
+)
+
+func NewServeCmd(cfg *config.Config) *cli.Command {
+	return &cli.Command{
+		Name:  "serve",
+		Usage: "Run a local daemon that keeps providers warm; the hook uses it when it is running",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "socket",
+				Usage: "Unix socket to listen on",
+				Value: daemon.DefaultSocketPath(),
+			},
+		},
+		Action: func(c *cli.Context) error {
+			ctx, stop := signal.NotifyContext(c.Context, os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			socket := c.String("socket")
+			fmt.Printf("muse daemon listening on %s (press Ctrl+C to stop)\n", socket)
+			return daemon.NewServer(cfg.Cache, Version).ListenAndServe(ctx, socket)
+		},
+	}
+}
@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/klauern/muse/config"
+	"github.com/klauern/muse/internal/daemon"
+	"github.com/klauern/muse/internal/git"
+	"github.com/klauern/muse/internal/ledger"
+	"github.com/klauern/muse/internal/split"
+	"github.com/klauern/muse/internal/userinput"
+	"github.com/klauern/muse/llm"
+	"github.com/klauern/muse/templates"
+	"github.com/urfave/cli/v2"
+)
+
+func NewSplitCmd(cfg *config.Config) *cli.Command {
+	return &cli.Command{
+		Name:  "split",
+		Usage: "Split the staged changes into several commits",
+		Description: "Groups the staged hunks into logical commits and proposes a message for each.\n" +
+			"Each group is then staged with git apply --cached and committed after you confirm it.\n" +
+			"Groups you skip stay staged; unstaged changes in the working tree are not touched.",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:    "dry-run",
+				Aliases: []string{"n"},
+				Usage:   "Show the proposed commits without committing",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			return runSplit(c, cfg)
+		},
+	}
+}
+
+func runSplit(c *cli.Context, cfg *config.Config) error {
+	ops, err := git.NewGitOperations("")
+	if err != nil {
+		return fmt.Errorf("failed to initialize git operations: %w", err)
+	}
+	patch, err := ops.GetStagedPatch()
+	if err != nil {
+		return err
+	}
+	plan, err := split.NewPlan(patch)
+	if err != nil {
+		return err
+	}
+
+	service, err := llm.NewLLMService(&cfg.LLM)
+	if err != nil {
+		return fmt.Errorf("failed to create LLM service: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Grouping %d changes in %d files...\n", len(plan.Units), len(plan.Files))
+	ctx, gen := ledger.Start(c.Context, cfg, "split")
+	if err := plan.Cluster(ctx, service); err != nil {
+		return err
+	}
+	total := len(plan.Groups)
+	err = plan.Describe(ctx, daemon.NewGenerator(cfg), cfg.Hook.CommitStyle, func(i int, g split.Group) {
+		fmt.Fprintf(os.Stderr, "[%d/%d] Writing a message for: %s\n", i+1, total, g.Summary)
+	})
+	if err != nil {
+		return err
+	}
+	gen.Record("")
+
+	if total == 1 || c.Bool("dry-run") {
+		for i, g := range plan.Groups {
+			printSplitGroup(plan, i, g)
+		}
+		if total == 1 {
+			fmt.Println("The staged changes belong together; commit them as one.")
+		} else {
+			fmt.Println("Dry run; nothing was committed.")
+		}
+		return nil
+	}
+
+	return applySplit(c.Context, ops, plan)
+}
+
+func printSplitGroup(plan *split.Plan, i int, g split.Group) {
+	fmt.Printf("Commit %d of %d: %s\n", i+1, len(plan.Groups), strings.Join(plan.Paths(g), ", "))
+	for _, line := range strings.Split(g.Message, "\n") {
+		fmt.Println(strings.TrimRight("  > "+line, " "))
+	}
+	fmt.Println()
+}
+
+var errSplitQuit = errors.New("quit")
+
+func applySplit(ctx context.Context, ops *git.GitOperations, plan *split.Plan) (err error) {
+	session, err := split.Start(ops, plan)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if finishErr := session.Finish(); finishErr != nil && err == nil {
+			err = finishErr
+		}
+	}()
+
+	committed := 0
+	for i, g := range plan.Groups {
+		message, err := confirmSplitGroup(plan, i, g)
+		if errors.Is(err, errSplitQuit) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if message == "" {
+			continue
+		}
+
+		hash, err := session.Commit(ctx, g, message)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Committed %s %s\n", git.Commit{Hash: hash}.ShortHash(), git.Commit{Message: message}.Subject())
+		committed++
+	}
+
+	fmt.Printf("Created %d of %d commits.", committed, len(plan.Groups))
+	if committed < len(plan.Groups) {
+		fmt.Print(" The remaining changes are still staged.")
+	}
+	fmt.Println()
+	return nil
+}
+
+// confirmSplitGroup asks what to do with a group and returns the message to
+// commit it with, or an empty message to skip it
+func confirmSplitGroup(plan *split.Plan, i int, g split.Group) (string, error) {
+	input := userinput.NewSecureInputHandler()
+	input.SetTimeout(5 * time.Minute)
+	for {
+		printSplitGroup(plan, i, g)
+		answer, err := input.PromptWithValidation(context.Background(), "[c]ommit, [e]dit message, [s]kip, [q]uit? ", func(s string) error {
+			switch strings.ToLower(s) {
+			case "c", "e", "s", "q":
+				return nil
+			}
+			return fmt.Errorf("expected c, e, s or q")
+		})
+		if err != nil {
+			return "", err
+		}
+
+		switch strings.ToLower(answer) {
+		case "c":
+			return g.Message, nil
+		case "s":
+			return "", nil
+		case "q":
+			return "", errSplitQuit
+		}
+
+		edited, err := editMessage(g.Message)
+		if err != nil {
+			return "", err
+		}
+		if edited == "" {
+			fmt.Println("Empty message; keeping the proposed one.")
+			continue
+		}
+		g.Message = edited
+	}
+}
+
+// editMessage opens message in $EDITOR and returns the result without
+// comment lines
+func editMessage(message string) (string, error) {
+	tmp, err := os.CreateTemp("", "muse-message-*.txt")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.WriteString(message + "\n"); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("failed to write temporary file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("failed to write temporary file: %w", err)
+	}
+
+	if err := runEditor(tmp.Name()); err != nil {
+		return "", err
+	}
+	edited, err := os.ReadFile(tmp.Name())
+	if err != nil {
+		return "", fmt.Errorf("failed to read edited message: %w", err)
+	}
+	return templates.StripComments(string(edited)), nil
+}
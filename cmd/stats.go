@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/klauern/muse/config"
+	"github.com/klauern/muse/internal/ledger"
+	"github.com/urfave/cli/v2"
+)
+
+func NewStatsCmd(cfg *config.Config) *cli.Command {
+	return &cli.Command{
+		Name:  "stats",
+		Usage: "Summarize tokens, estimated costs and latency from the usage ledger",
+		Description: "Every generation is recorded in usage.jsonl under $XDG_STATE_HOME/muse\n" +
+			"(~/.local/state/muse by default) unless usage.enabled is false.\n" +
+			"Costs are estimates from usage.prices and muse's built-in list prices.",
+		Flags: []cli.Flag{
+			&cli.StringSliceFlag{
+				Name:  "by",
+				Usage: "Group by day, repo or model; repeat for several tables (default: all three)",
+			},
+			&cli.IntFlag{
+				Name:  "days",
+				Value: 30,
+				Usage: "Only include the last `N` days, or every entry when 0",
+			},
+			outputFlag(),
+		},
+		Action: func(c *cli.Context) error {
+			return runStats(c, cfg)
+		},
+	}
+}
+
+// statsReport is the json output of muse stats
+type statsReport struct {
+	Ledger string                  `json:"ledger"`
+	Since  *time.Time              `json:"since,omitempty"`
+	Total  ledger.Row              `json:"total"`
+	By     map[string][]ledger.Row `json:"by"`
+}
+
+func runStats(c *cli.Context, cfg *config.Config) error {
+	output, err := outputFormat(c)
+	if err != nil {
+		return err
+	}
+	groupings := c.StringSlice("by")
+	if len(groupings) == 0 {
+		groupings = ledger.Groupings
+	}
+	for _, by := range groupings {
+		if !slices.Contains(ledger.Groupings, by) {
+			return fmt.Errorf("unknown grouping %q; use %s", by, strings.Join(ledger.Groupings, ", "))
+		}
+	}
+	if c.Int("days") < 0 {
+		return fmt.Errorf("--days must be 0 or more, got %d", c.Int("days"))
+	}
+
+	l, err := ledger.FromConfig(cfg.Usage)
+	if err != nil {
+		return err
+	}
+	var since time.Time
+	if days := c.Int("days"); days > 0 {
+		// Whole days, counting today as the first
+		now := time.Now()
+		since = time.Date(now.Year(), now.Month(), now.Day()-days+1, 0, 0, 0, 0, time.Local)
+	}
+	entries, err := l.Entries(since)
+	if err != nil {
+		return err
+	}
+
+	report := statsReport{Ledger: l.Path, Total: ledger.Total(entries), By: map[string][]ledger.Row{}}
+	if !since.IsZero() {
+		report.Since = &since
+	}
+	for _, by := range groupings {
+		if report.By[by], err = ledger.Summarize(entries, by); err != nil {
+			return err
+		}
+	}
+
+	if output == "json" {
+		return writeJSON(report)
+	}
+	if len(entries) == 0 {
+		if !cfg.Usage.Enabled {
+			fmt.Println("Usage recording is off; set usage.enabled to true to start a ledger.")
+		} else {
+			fmt.Printf("No generations recorded in %s yet.\n", l.Path)
+		}
+		return nil
+	}
+	for i, by := range groupings {
+		if i > 0 {
+			fmt.Println()
+		}
+		if err := ledger.WriteTable(os.Stdout, by, report.By[by], report.Total); err != nil {
+			return err
+		}
+	}
+	if hookOutcomesMissing(entries) {
+		fmt.Println("\nOutcomes of messages written by the commit hook are recorded by the post-commit hook; re-run 'muse install' to add it.")
+	}
+	return nil
+}
+
+// hookOutcomesMissing reports whether the commit hook generated messages but
+// none of them has an outcome, as happens without the post-commit hook
+func hookOutcomesMissing(entries []ledger.Entry) bool {
+	found := false
+	for _, e := range entries {
+		if e.Command != "prepare-commit-msg" {
+			continue
+		}
+		if e.Outcome != "" {
+			return false
+		}
+		found = true
+	}
+	return found
+}
@@ -1,13 +1,15 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
-	"os"
-	"path/filepath"
 
 	"github.com/klauern/muse/config"
 	"github.com/klauern/muse/hooks"
+	"github.com/klauern/muse/internal/configloader"
+	"github.com/klauern/muse/internal/daemon"
+	"github.com/klauern/muse/internal/git"
 	"github.com/urfave/cli/v2"
 )
 
@@ -15,29 +17,164 @@ func NewStatusCmd(config *config.Config) *cli.Command {
 	return &cli.Command{
 		Name:  "status",
 		Usage: "Check the status of the prepare-commit-msg hook",
+		Flags: []cli.Flag{outputFlag()},
 		Action: func(c *cli.Context) error {
-			return checkStatus(config)
+			return checkStatus(c, config)
 		},
 	}
 }
 
-func checkStatus(config *config.Config) error {
-	gitDir, err := hooks.FindGitDir()
+// statusReport is the state shown by muse status
+type statusReport struct {
+	Local   localHookStatus  `json:"local"`
+	Global  globalHookStatus `json:"global"`
+	Profile profileStatus    `json:"profile"`
+	Hook    hookSettings     `json:"hook"`
+	Daemon  daemonStatus     `json:"daemon"`
+}
+
+type localHookStatus struct {
+	Repository bool   `json:"repository"`
+	Installed  bool   `json:"installed"`
+	Path       string `json:"path,omitempty"`
+}
+
+type globalHookStatus struct {
+	Installed bool   `json:"installed"`
+	ConfigKey string `json:"config_key,omitempty"`
+	Path      string `json:"path,omitempty"`
+	// Warning is set when the git config no longer points at the hook
+	Warning string `json:"warning,omitempty"`
+}
+
+type profileStatus struct {
+	Name      string `json:"name,omitempty"`
+	Selection string `json:"selection,omitempty"`
+}
+
+type hookSettings struct {
+	DryRun bool   `json:"dry_run"`
+	Type   string `json:"type"`
+}
+
+type daemonStatus struct {
+	Running bool   `json:"running"`
+	PID     int    `json:"pid,omitempty"`
+	Version string `json:"version,omitempty"`
+	Socket  string `json:"socket"`
+}
+
+func checkStatus(c *cli.Context, config *config.Config) error {
+	output, err := outputFormat(c)
+	if err != nil {
+		return err
+	}
+	report, err := collectStatus(config)
+	if err != nil {
+		return err
+	}
+
+	slog.Debug("Status check completed")
+	if output == "json" {
+		return writeJSON(report)
+	}
+	writeStatus(report)
+	return nil
+}
+
+func collectStatus(config *config.Config) (*statusReport, error) {
+	report := &statusReport{
+		Hook: hookSettings{DryRun: config.Hook.DryRun, Type: config.Hook.Type},
+	}
+
+	hookPath, err := hooks.LocalHookPath()
 	if err != nil {
-		slog.Error("Failed to find .git directory", "error", err)
-		return fmt.Errorf("failed to find .git directory: %w", err)
+		slog.Debug("Not inside a git repository", "error", err)
+	} else {
+		installed, err := hooks.HasMuseBlock(hookPath)
+		if err != nil {
+			return nil, err
+		}
+		report.Local = localHookStatus{Repository: true, Installed: installed}
+		if installed {
+			report.Local.Path = hookPath
+		}
+	}
+
+	state, err := hooks.LoadGlobalState()
+	if err != nil {
+		return nil, err
+	}
+	if state != nil {
+		report.Global = globalHookStatus{Installed: true, ConfigKey: state.ConfigKey, Path: state.HookPath}
+		if current, _, err := git.GetGlobalConfig(state.ConfigKey); err == nil && current != state.ConfigValue {
+			report.Global.Warning = fmt.Sprintf("%s is now %q, so the global hook is not active", state.ConfigKey, current)
+		}
 	}
 
-	hookPath := filepath.Join(gitDir, "hooks", "prepare-commit-msg")
+	if config.Profile != "" {
+		report.Profile = profileStatus{Name: config.Profile, Selection: profileSelection()}
+	}
 
-	if _, err := os.Stat(hookPath); os.IsNotExist(err) {
-		fmt.Println("prepare-commit-msg hook is not installed")
+	report.Daemon.Socket = daemon.DefaultSocketPath()
+	if status, err := daemon.NewClient(report.Daemon.Socket).Status(context.Background()); err == nil {
+		report.Daemon.Running = true
+		report.Daemon.PID = status.PID
+		report.Daemon.Version = status.Version
 	} else {
-		fmt.Println("prepare-commit-msg hook is installed")
+		slog.Debug("Daemon not reachable", "error", err)
 	}
+	return report, nil
+}
 
-	fmt.Printf("Hook configuration: DryRun=%t Type=%s\n", config.Hook.DryRun, config.Hook.Type)
+func writeStatus(report *statusReport) {
+	switch {
+	case !report.Local.Repository:
+		fmt.Println("Local: not inside a git repository")
+	case report.Local.Installed:
+		fmt.Printf("Local: prepare-commit-msg hook is installed (%s)\n", report.Local.Path)
+	default:
+		fmt.Println("Local: prepare-commit-msg hook is not installed")
+	}
 
-	slog.Debug("Status check completed")
-	return nil
+	if !report.Global.Installed {
+		fmt.Println("Global: prepare-commit-msg hook is not installed")
+	} else {
+		fmt.Printf("Global: prepare-commit-msg hook is installed via %s (%s)\n", report.Global.ConfigKey, report.Global.Path)
+		if report.Global.Warning != "" {
+			fmt.Printf("Warning: %s\n", report.Global.Warning)
+		}
+	}
+
+	if report.Profile.Name == "" {
+		fmt.Println("Profile: none")
+	} else {
+		fmt.Printf("Profile: %s (%s)\n", report.Profile.Name, report.Profile.Selection)
+	}
+
+	fmt.Printf("Hook configuration: DryRun=%t Type=%s\n", report.Hook.DryRun, report.Hook.Type)
+
+	if report.Daemon.Running {
+		fmt.Printf("Daemon: running (pid %d, version %s, %s)\n", report.Daemon.PID, report.Daemon.Version, report.Daemon.Socket)
+	} else {
+		fmt.Println("Daemon: not running")
+	}
+}
+
+// profileSelection describes how the active profile was chosen
+func profileSelection() string {
+	_, origins, err := configloader.GetConfigLoader().LoadWithOrigins()
+	if err != nil {
+		return "unknown"
+	}
+	switch origin := origins["profile"]; origin.Layer {
+	case config.LayerProfile:
+		return "matched this repository"
+	case config.LayerFlags:
+		return "set by --profile"
+	case config.LayerEnv:
+		return "set by MUSE_PROFILE"
+	default:
+		return "set in " + origin.String()
+	}
 }
@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"fmt"
+
 	"github.com/klauern/muse/config"
 	"github.com/klauern/muse/hooks"
 	"github.com/urfave/cli/v2"
@@ -9,9 +11,50 @@ import (
 func NewUninstallCmd(config *config.Config) *cli.Command {
 	return &cli.Command{
 		Name:  "uninstall",
-		Usage: "Uninstall the prepare-commit-msg hook",
+		Usage: "Remove muse from the repository's hooks",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "restore",
+				Usage: "Restore the hooks that existed before muse was installed",
+			},
+			&cli.BoolFlag{
+				Name:  "global",
+				Usage: "Remove the global hook and restore the previous git config",
+			},
+			&cli.BoolFlag{
+				Name:  "pre-commit",
+				Usage: "Remove only the muse review pre-commit hook",
+			},
+			&cli.BoolFlag{
+				Name:  "post-commit",
+				Usage: "Remove only the post-commit hook that records edits to generated messages",
+			},
+		},
 		Action: func(c *cli.Context) error {
 			installer := hooks.NewInstaller(config)
+			if c.Bool("pre-commit") || c.Bool("post-commit") {
+				if c.Bool("global") || c.Bool("restore") {
+					return fmt.Errorf("--pre-commit and --post-commit cannot be combined with --global or --restore")
+				}
+				if c.Bool("pre-commit") {
+					if err := installer.UninstallPreCommit(); err != nil {
+						return err
+					}
+				}
+				if c.Bool("post-commit") {
+					return installer.UninstallPostCommit()
+				}
+				return nil
+			}
+			if c.Bool("global") {
+				if c.Bool("restore") {
+					return fmt.Errorf("--restore cannot be combined with --global")
+				}
+				return installer.UninstallGlobal()
+			}
+			if c.Bool("restore") {
+				return installer.Restore()
+			}
 			return installer.Uninstall()
 		},
 	}
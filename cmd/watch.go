@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/klauern/muse/config"
+	"github.com/klauern/muse/internal/feedback"
+	"github.com/klauern/muse/internal/git"
+	"github.com/klauern/muse/internal/ledger"
+	"github.com/klauern/muse/internal/watch"
+	"github.com/klauern/muse/llm"
+	"github.com/urfave/cli/v2"
+)
+
+func NewWatchCmd(cfg *config.Config) *cli.Command {
+	return &cli.Command{
+		Name:  "watch",
+		Usage: "Generate messages in the background as changes are staged, so the hook returns instantly",
+		Flags: []cli.Flag{
+			&cli.DurationFlag{
+				Name:  "debounce",
+				Usage: "How long the index must stay unchanged before generating",
+				Value: watch.DefaultDebounce,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			return runWatch(c, cfg)
+		},
+	}
+}
+
+func runWatch(c *cli.Context, cfg *config.Config) error {
+	if !cfg.Cache.Enabled {
+		return fmt.Errorf("muse watch stores messages in the response cache; set cache.enabled to true")
+	}
+
+	gitOps, err := git.NewGitOperations("")
+	if err != nil {
+		return fmt.Errorf("failed to initialize git operations: %w", err)
+	}
+	gitDir, err := gitOps.GetGitDir()
+	if err != nil {
+		return err
+	}
+
+	generator, err := llm.NewCommitMessageGenerator(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create commit message generator: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(c.Context, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	watcher := &watch.IndexWatcher{
+		GitDir:   gitDir,
+		Debounce: c.Duration("debounce"),
+		OnChange: func(ctx context.Context) {
+			pregenerate(ctx, gitOps, generator, cfg)
+		},
+	}
+
+	fmt.Printf("Watching %s for staged changes (press Ctrl+C to stop)\n", gitDir)
+	return watcher.Run(ctx)
+}
+
+// pregenerate generates a message for the staged changes so that the hook
+// finds it in the cache
+func pregenerate(ctx context.Context, gitOps *git.GitOperations, generator *llm.CommitMessageGenerator, cfg *config.Config) {
+	diff, err := gitOps.GetStagedDiff()
+	if err != nil {
+		slog.Warn("Failed to get staged diff", "error", err)
+		return
+	}
+	if strings.TrimSpace(diff) == "" {
+		slog.Debug("Nothing staged; skipping pre-generation")
+		return
+	}
+
+	genCtx, gen := ledger.Start(feedback.WithExamples(ctx, cfg, "", cfg.Hook.CommitStyle, diff), cfg, "watch")
+	if _, err := generator.Generate(genCtx, diff, cfg.Hook.CommitStyle); err != nil {
+		if ctx.Err() == nil {
+			fmt.Fprintf(os.Stderr, "Failed to pre-generate commit message: %v\n", err)
+		}
+		return
+	}
+	gen.Record("")
+	fmt.Println("Commit message ready for the staged changes")
+}
@@ -3,18 +3,10 @@ package config
 import (
 	_ "embed"
 	"fmt"
-	"log/slog"
 	"os"
 	"path/filepath"
-	"strings"
 
-	"github.com/klauern/muse/internal/security"
 	"github.com/klauern/muse/templates"
-	"github.com/knadh/koanf"
-	"github.com/knadh/koanf/parsers/yaml"
-	"github.com/knadh/koanf/providers/env"
-	"github.com/knadh/koanf/providers/file"
-	"github.com/knadh/koanf/providers/rawbytes"
 )
 
 //go:embed example_config.yaml
@@ -23,126 +15,95 @@ var ExampleConfig []byte
 type Config struct {
 	Hook Hook      `koanf:"hook"`
 	LLM  LLMConfig `koanf:"llm"`
+	// Cache controls the on-disk cache of generated messages
+	Cache CacheConfig `koanf:"cache"`
+	// Review controls muse review and the pre-commit hook
+	Review ReviewConfig `koanf:"review"`
+	// Usage controls the ledger of generations that muse stats reads
+	Usage UsageConfig `koanf:"usage"`
+	// Feedback controls how edits to generated messages are captured and
+	// reused as examples
+	Feedback FeedbackConfig `koanf:"feedback"`
+	// Profile names the active profile; empty selects one by its match rules
+	Profile  string             `koanf:"profile" jsonschema_description:"Profile to apply; when empty, the first profile whose match rules select the repository is used"`
+	Profiles map[string]Profile `koanf:"profiles" jsonschema_description:"Named sets of hook and llm settings"`
 }
 
 type LLMConfig struct {
-	Provider string         `koanf:"provider"`
-	Config   map[string]any `koanf:"config"`
+	Provider string         `koanf:"provider" jsonschema_description:"LLM provider used to generate commit messages"`
+	Config   map[string]any `koanf:"config" jsonschema_description:"Provider-specific settings such as model, api_key and api_base"`
 }
 
-type Hook struct {
-	Type        string                `koanf:"type"`
-	CommitStyle templates.CommitStyle `koanf:"commit_style"`
-	Preview     bool                  `koanf:"preview"`
-	DryRun      bool                  `koanf:"dry_run"`
+type CacheConfig struct {
+	Enabled   bool   `koanf:"enabled" jsonschema_description:"Reuse the message generated for an identical diff, style, template and model"`
+	TTL       string `koanf:"ttl" jsonschema:"pattern=^([0-9]+(\\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$" jsonschema_description:"How long a cached message stays valid, e.g. 168h"`
+	MaxSizeMB int    `koanf:"max_size_mb" jsonschema_description:"Size limit of the cache directory in megabytes; the oldest entries are removed first"`
 }
 
-// LoadConfig loads the configuration from YAML and environment variables
-// Note: This implementation includes race condition fixes via proper synchronization
-func LoadConfig() (*Config, error) {
-	// TODO: Future improvement - migrate to fully thread-safe implementation
-	// For now, we maintain the original API but document the race condition issue
-	slog.Debug("Loading config")
-	k := koanf.New(".")
-
-	// Define the list of config file paths to check
-	configPaths := []string{
-		"./muse.yaml", // Local directory
-		os.Getenv("XDG_CONFIG_HOME") + "/muse/muse.yaml", // XDG base directory
-		os.Getenv("HOME") + "/.config/muse/muse.yaml",    // Default XDG base directory
-	}
+type ReviewConfig struct {
+	FailOn string `koanf:"fail_on" jsonschema:"enum=error,enum=warning,enum=info,enum=never" jsonschema_description:"Lowest issue severity that makes muse review fail and blocks the pre-commit hook; never only reports issues"`
+}
 
-	// Load the first existing config file
-	var found bool
-	for _, path := range configPaths {
-		if _, err := os.Stat(path); err == nil {
-			if err := k.Load(file.Provider(path), yaml.Parser()); err != nil {
-				return nil, fmt.Errorf("error loading config from %s: %v", path, err)
-			}
-			found = true
-			break
-		}
-	}
+type UsageConfig struct {
+	Enabled bool         `koanf:"enabled" jsonschema_description:"Record each generation with its tokens, estimated cost and latency in a ledger under the XDG state directory"`
+	Prices  []ModelPrice `koanf:"prices" jsonschema_description:"Prices used to estimate costs; they take precedence over the built-in prices"`
+}
 
-	if !found {
-		// Use example config
-		if err := k.Load(rawbytes.Provider(ExampleConfig), yaml.Parser()); err != nil {
-			return nil, fmt.Errorf("error loading example config: %v", err)
-		}
-	}
+type FeedbackConfig struct {
+	Enabled     bool   `koanf:"enabled" jsonschema_description:"Record how generated messages were edited before committing, via the post-commit hook, and show recent edits to the model as examples"`
+	MaxExamples int    `koanf:"max_examples" jsonschema_description:"Most edited messages from the repository to include in a prompt; 0 records edits without using them"`
+	HalfLife    string `koanf:"half_life" jsonschema:"pattern=^([0-9]+(\\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$" jsonschema_description:"Age at which an edit counts half as much when choosing examples, e.g. 336h"`
+}
 
-	// Load environment variables, with "MUSE_" prefix (ignores case)
-	if err := k.Load(env.Provider("MUSE_", ".", func(s string) string {
-		return strings.ReplaceAll(strings.ToLower(s), "_", ".")
-	}), nil); err != nil {
-		slog.Error("error loading environment variables; continuing", "error", err)
-	}
+// ModelPrice is the price of a model in USD per million tokens
+type ModelPrice struct {
+	Model  string  `koanf:"model" json:"model" jsonschema:"required" jsonschema_description:"Model name, or a prefix such as gpt-4o that also matches dated versions"`
+	Input  float64 `koanf:"input" json:"input" jsonschema_description:"USD per million prompt tokens"`
+	Output float64 `koanf:"output" json:"output" jsonschema_description:"USD per million completion tokens"`
+}
 
-	// Unmarshal into the struct
-	var config Config
-	if err := k.Unmarshal("", &config); err != nil {
-		slog.Error("error unmarshaling config; continuing", "error", err)
-		return nil, fmt.Errorf("error unmarshaling config: %v", err)
-	}
+type Hook struct {
+	Type        string                `koanf:"type" jsonschema_description:"Git hook that muse installs"`
+	CommitStyle templates.CommitStyle `koanf:"commit_style" jsonschema_description:"Style of the generated commit messages"`
+	Preview     bool                  `koanf:"preview" jsonschema_description:"Show the generated message and ask for confirmation before applying it"`
+	DryRun      bool                  `koanf:"dry_run" jsonschema_description:"Show the generated message without applying it"`
+}
 
-	// Handle API keys with environment fallback - RACE CONDITION MITIGATION
-	// Create a copy to avoid concurrent modification issues
-	if config.LLM.Config != nil {
-		configCopy := make(map[string]any)
-		for k, v := range config.LLM.Config {
-			configCopy[k] = v
+// Dir returns the muse directory under the user's XDG config home
+func Dir() (string, error) {
+	configDir := os.Getenv("XDG_CONFIG_HOME")
+	if configDir == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get user home directory: %w", err)
 		}
+		configDir = filepath.Join(homeDir, ".config")
+	}
+	return filepath.Join(configDir, "muse"), nil
+}
 
-		for key := range configCopy {
-			envKey := strings.ToUpper(fmt.Sprintf("%s_API_KEY", key))
-			envValue := os.Getenv(envKey)
-			if envValue != "" {
-				// Validate the credential before using it
-				if err := security.ValidateCredential(envValue); err != nil {
-					slog.Warn("Environment variable credential validation warning",
-						"provider", key,
-						"env_var", envKey,
-						"issue", err.Error(),
-						"masked_value", security.MaskCredential(envValue))
-				}
-				// Use environment variable but don't log the actual value
-				configCopy[key] = envValue
-				slog.Debug("Using environment variable for API key", "provider", key, "env_var", envKey)
-			} else if value, exists := config.LLM.Config[key]; exists {
-				if strValue, ok := value.(string); ok && strValue != "" {
-					// Validate config file credential
-					if err := security.ValidateCredential(strValue); err != nil {
-						slog.Warn("Configuration file credential validation warning",
-							"provider", key,
-							"issue", err.Error(),
-							"masked_value", security.MaskCredential(strValue))
-					}
-				} else {
-					// No credential configured
-					slog.Warn("API key not configured", "provider", key, "suggestion", fmt.Sprintf("Set %s environment variable or configure in muse.yaml", envKey))
-				}
-			}
+// StateDir returns the muse directory under the user's XDG state home, where
+// muse keeps records such as the usage ledger
+func StateDir() (string, error) {
+	stateDir := os.Getenv("XDG_STATE_HOME")
+	if stateDir == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get user home directory: %w", err)
 		}
-
-		// Atomically replace the config map
-		config.LLM.Config = configCopy
+		stateDir = filepath.Join(homeDir, ".local", "state")
 	}
-
-	return &config, nil
+	return filepath.Join(stateDir, "muse"), nil
 }
 
 // CreateConfig generates a template configuration file.
 func CreateConfig() error {
-	configDir := os.Getenv("XDG_CONFIG_HOME")
-	if configDir == "" {
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return fmt.Errorf("failed to get user home directory: %w", err)
-		}
-		configDir = filepath.Join(homeDir, ".config")
+	dir, err := Dir()
+	if err != nil {
+		return err
 	}
 
-	configPath := filepath.Join(configDir, "muse", "muse.yaml")
+	configPath := filepath.Join(dir, "muse.yaml")
 
 	if err := os.MkdirAll(filepath.Dir(configPath), 0o755); err != nil {
 		return fmt.Errorf("failed to create config directory: %w", err)
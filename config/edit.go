@@ -0,0 +1,111 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/knadh/koanf"
+	"github.com/knadh/koanf/parsers/yaml"
+	"github.com/knadh/koanf/providers/rawbytes"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// GetValue returns the value of a flattened key such as "hook.preview" in a
+// YAML config document
+func GetValue(data []byte, key string) (any, bool, error) {
+	k := koanf.New(".")
+	if err := k.Load(rawbytes.Provider(data), yaml.Parser()); err != nil {
+		return nil, false, fmt.Errorf("failed to parse config: %w", err)
+	}
+	if !k.Exists(key) {
+		return nil, false, nil
+	}
+	return k.Get(key), true, nil
+}
+
+// SetValue sets a flattened key in a YAML config document and returns the
+// updated document. value is parsed as YAML, so "true" becomes a boolean.
+// Comments and the order of existing keys are preserved, and missing parent
+// mappings are created.
+func SetValue(data []byte, key, value string) ([]byte, error) {
+	if key == "" {
+		return nil, fmt.Errorf("config key must not be empty")
+	}
+
+	var doc yamlv3.Node
+	if err := yamlv3.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		doc = yamlv3.Node{
+			Kind:    yamlv3.DocumentNode,
+			Content: []*yamlv3.Node{{Kind: yamlv3.MappingNode, Tag: "!!map"}},
+		}
+	}
+
+	newValue, err := parseValueNode(value)
+	if err != nil {
+		return nil, err
+	}
+
+	node := doc.Content[0]
+	parts := strings.Split(key, ".")
+	for i, part := range parts {
+		if node.Kind != yamlv3.MappingNode {
+			return nil, fmt.Errorf("cannot set %s: %s is not a mapping", key, strings.Join(parts[:i], "."))
+		}
+
+		child := mappingValue(node, part)
+		if i == len(parts)-1 {
+			if child == nil {
+				node.Content = append(node.Content, &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: part}, newValue)
+			} else {
+				newValue.HeadComment = child.HeadComment
+				newValue.LineComment = child.LineComment
+				newValue.FootComment = child.FootComment
+				*child = *newValue
+			}
+			break
+		}
+
+		if child == nil {
+			child = &yamlv3.Node{Kind: yamlv3.MappingNode, Tag: "!!map"}
+			node.Content = append(node.Content, &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: part}, child)
+		}
+		node = child
+	}
+
+	var buf bytes.Buffer
+	enc := yamlv3.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to encode config: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode config: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// parseValueNode parses a command-line value into a YAML node
+func parseValueNode(value string) (*yamlv3.Node, error) {
+	var doc yamlv3.Node
+	if err := yamlv3.Unmarshal([]byte(value), &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse value %q: %w", value, err)
+	}
+	if len(doc.Content) == 0 {
+		return &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: value}, nil
+	}
+	return doc.Content[0], nil
+}
+
+// mappingValue returns the value node for key in a mapping node, or nil
+func mappingValue(node *yamlv3.Node, key string) *yamlv3.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
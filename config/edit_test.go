@@ -0,0 +1,83 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSetValue(t *testing.T) {
+	original := `# Muse configuration
+hook:
+  # Style of commit messages
+  commit_style: "conventional" # trailing note
+  preview: true
+`
+
+	tests := []struct {
+		name      string
+		data      string
+		key       string
+		value     string
+		wantValue any
+		contains  []string
+	}{
+		{
+			name:      "replace keeps comments",
+			data:      original,
+			key:       "hook.commit_style",
+			value:     "gitmoji",
+			wantValue: "gitmoji",
+			contains:  []string{"# Muse configuration", "# Style of commit messages", "commit_style: gitmoji # trailing note", "preview: true"},
+		},
+		{
+			name:      "boolean value",
+			data:      original,
+			key:       "hook.preview",
+			value:     "false",
+			wantValue: false,
+		},
+		{
+			name:      "creates parents",
+			data:      original,
+			key:       "llm.config.model",
+			value:     "gpt-4o-mini",
+			wantValue: "gpt-4o-mini",
+			contains:  []string{"commit_style: \"conventional\"", "llm:\n  config:\n    model: gpt-4o-mini"},
+		},
+		{
+			name:      "empty document",
+			data:      "",
+			key:       "hook.dry_run",
+			value:     "true",
+			wantValue: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated, err := SetValue([]byte(tt.data), tt.key, tt.value)
+			if err != nil {
+				t.Fatalf("SetValue() error = %v", err)
+			}
+
+			got, ok, err := GetValue(updated, tt.key)
+			if err != nil || !ok {
+				t.Fatalf("GetValue() = %v, %v, %v", got, ok, err)
+			}
+			if got != tt.wantValue {
+				t.Errorf("%s = %#v, want %#v", tt.key, got, tt.wantValue)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(string(updated), want) {
+					t.Errorf("updated config does not contain %q:\n%s", want, updated)
+				}
+			}
+		})
+	}
+}
+
+func TestSetValue_NonMappingParent(t *testing.T) {
+	if _, err := SetValue([]byte("hook: enabled\n"), "hook.preview", "true"); err == nil {
+		t.Error("SetValue() should fail when a parent key is a scalar")
+	}
+}
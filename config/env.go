@@ -0,0 +1,190 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/invopop/jsonschema"
+)
+
+// Environment variables are the config key in upper case with EnvSeparator
+// between nesting levels, so MUSE_HOOK__COMMIT_STYLE sets hook.commit_style.
+// A single underscore stays part of the key name.
+const (
+	EnvPrefix    = "MUSE_"
+	EnvSeparator = "__"
+)
+
+// Placeholders for map keys in documented variable names
+const (
+	envNamePlaceholder = "<NAME>"
+	envKeyPlaceholder  = "<KEY>"
+)
+
+// EnvVar documents one supported environment variable
+type EnvVar struct {
+	Name        string `json:"name"`
+	Key         string `json:"key"`
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+}
+
+// EnvName returns the environment variable that sets a flattened key
+func EnvName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", EnvSeparator))
+}
+
+// EnvKey returns the flattened config key set by an environment variable.
+// It reports false for variables without the MUSE_ prefix and for names
+// that do not correspond to a config field.
+func EnvKey(name string) (string, bool) {
+	if !strings.HasPrefix(name, EnvPrefix) {
+		return "", false
+	}
+
+	parts := strings.Split(strings.ToLower(strings.TrimPrefix(name, EnvPrefix)), EnvSeparator)
+	for _, part := range parts {
+		if part == "" {
+			return "", false
+		}
+	}
+	// Only leaf values can be set; a whole section cannot
+	t, ok := fieldType(reflect.TypeOf(Config{}), parts)
+	if !ok || t.Kind() == reflect.Struct || t.Kind() == reflect.Map {
+		return "", false
+	}
+	return strings.Join(parts, "."), true
+}
+
+// EnvValue converts the raw value of the variable for key. List fields are
+// split on commas; everything else is left for koanf to convert.
+func EnvValue(key, value string) any {
+	t, ok := fieldType(reflect.TypeOf(Config{}), strings.Split(key, "."))
+	if ok && t.Kind() == reflect.Slice {
+		items := strings.Split(value, ",")
+		for i := range items {
+			items[i] = strings.TrimSpace(items[i])
+		}
+		return items
+	}
+	return value
+}
+
+// SuggestEnvName returns the supported variable a misspelled one most likely
+// meant, such as MUSE_HOOK__COMMIT_STYLE for MUSE_HOOK_COMMIT_STYLE
+func SuggestEnvName(name string) string {
+	flattened := strings.ReplaceAll(name, EnvSeparator, "_")
+	for _, v := range EnvVars(nil) {
+		if strings.ReplaceAll(v.Name, EnvSeparator, "_") == flattened {
+			return v.Name
+		}
+	}
+	return ""
+}
+
+// EnvVars lists every supported environment variable. The Config fields are
+// derived from their koanf tags; providers contributes the settings each
+// provider accepts under llm.config.
+func EnvVars(providers map[string]*jsonschema.Schema) []EnvVar {
+	var vars []EnvVar
+	collectEnvVars(reflect.TypeOf(Config{}), nil, &vars)
+
+	// Document the settings known providers accept next to the generic
+	// llm.config entry
+	seen := map[string]bool{}
+	for _, name := range providerNames(providers) {
+		schema := providers[name]
+		if schema == nil || schema.Properties == nil {
+			continue
+		}
+		for pair := schema.Properties.Oldest(); pair != nil; pair = pair.Next() {
+			key := "llm.config." + pair.Key
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			vars = append(vars, EnvVar{
+				Name:        EnvName(key),
+				Key:         key,
+				Type:        schemaTypeName(pair.Value),
+				Description: pair.Value.Description,
+			})
+		}
+	}
+
+	sort.SliceStable(vars, func(i, j int) bool { return vars[i].Key < vars[j].Key })
+	return vars
+}
+
+func collectEnvVars(t reflect.Type, path []string, vars *[]EnvVar) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := field.Tag.Get("koanf")
+		if name == "" || name == "-" {
+			continue
+		}
+		fieldPath := append(append([]string{}, path...), name)
+		description := field.Tag.Get("jsonschema_description")
+
+		switch ft := field.Type; {
+		case ft.Kind() == reflect.Struct:
+			collectEnvVars(ft, fieldPath, vars)
+		case ft.Kind() == reflect.Map && ft.Elem().Kind() == reflect.Struct:
+			collectEnvVars(ft.Elem(), append(fieldPath, envNamePlaceholder), vars)
+		case ft.Kind() == reflect.Map:
+			key := strings.Join(append(fieldPath, envKeyPlaceholder), ".")
+			*vars = append(*vars, EnvVar{Name: EnvName(key), Key: key, Type: "string", Description: description})
+		default:
+			key := strings.Join(fieldPath, ".")
+			*vars = append(*vars, EnvVar{Name: EnvName(key), Key: key, Type: kindName(ft), Description: description})
+		}
+	}
+}
+
+// fieldType follows koanf tags from t along path and returns the type found
+// there. Map fields accept any key; free-form values accept any sub-path.
+func fieldType(t reflect.Type, path []string) (reflect.Type, bool) {
+	if len(path) == 0 {
+		return t, true
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if t.Field(i).Tag.Get("koanf") == path[0] {
+				return fieldType(t.Field(i).Type, path[1:])
+			}
+		}
+		return nil, false
+	case reflect.Map:
+		return fieldType(t.Elem(), path[1:])
+	case reflect.Interface:
+		return t, true
+	}
+	return nil, false
+}
+
+func kindName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Slice:
+		return "list"
+	case reflect.Int, reflect.Int64, reflect.Float64:
+		return "number"
+	}
+	return "string"
+}
+
+func schemaTypeName(schema *jsonschema.Schema) string {
+	switch schema.Type {
+	case "boolean":
+		return "boolean"
+	case "array":
+		return "list"
+	case "integer", "number":
+		return "number"
+	}
+	return "string"
+}
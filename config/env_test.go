@@ -0,0 +1,98 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/invopop/jsonschema"
+)
+
+func TestEnvKey(t *testing.T) {
+	tests := []struct {
+		name   string
+		want   string
+		wantOK bool
+	}{
+		{"MUSE_HOOK__COMMIT_STYLE", "hook.commit_style", true},
+		{"MUSE_HOOK__DRY_RUN", "hook.dry_run", true},
+		{"MUSE_LLM__PROVIDER", "llm.provider", true},
+		{"MUSE_LLM__CONFIG__MODEL", "llm.config.model", true},
+		{"MUSE_LLM__CONFIG__API_BASE", "llm.config.api_base", true},
+		{"MUSE_PROFILE", "profile", true},
+		{"MUSE_PROFILES__WORK__HOOK__COMMIT_STYLE", "profiles.work.hook.commit_style", true},
+		{"MUSE_HOOK_COMMIT_STYLE", "", false},
+		{"MUSE_HOOK", "", false},
+		{"MUSE_LLM__CONFIG", "", false},
+		{"MUSE_HOOK____PREVIEW", "", false},
+		{"OPENAI_API_KEY", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := EnvKey(tt.name)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("EnvKey(%q) = %q, %v; want %q, %v", tt.name, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestEnvValue(t *testing.T) {
+	got := EnvValue("profiles.work.match.remotes", "github.com/acme/**, gitlab.com/acme/**")
+	want := []string{"github.com/acme/**", "gitlab.com/acme/**"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("EnvValue() = %#v, want %#v", got, want)
+	}
+	if got := EnvValue("hook.preview", "true"); got != "true" {
+		t.Errorf("EnvValue() = %#v, want the raw string", got)
+	}
+}
+
+func TestSuggestEnvName(t *testing.T) {
+	if got := SuggestEnvName("MUSE_HOOK_COMMIT_STYLE"); got != "MUSE_HOOK__COMMIT_STYLE" {
+		t.Errorf("SuggestEnvName() = %q, want MUSE_HOOK__COMMIT_STYLE", got)
+	}
+	if got := SuggestEnvName("MUSE_SOMETHING_ELSE"); got != "" {
+		t.Errorf("SuggestEnvName() = %q, want no suggestion", got)
+	}
+}
+
+func TestEnvVars_CoverEveryField(t *testing.T) {
+	providers := map[string]*jsonschema.Schema{"openai": {Properties: jsonschema.NewProperties()}}
+	providers["openai"].Properties.Set("model", &jsonschema.Schema{Type: "string", Description: "Model name"})
+
+	vars := EnvVars(providers)
+	byName := map[string]EnvVar{}
+	for _, v := range vars {
+		byName[v.Name] = v
+		// Every documented variable must map back to its key
+		if v.Name != EnvName(v.Key) {
+			t.Errorf("%s does not match its key %s", v.Name, v.Key)
+		}
+	}
+
+	for name, wantType := range map[string]string{
+		"MUSE_HOOK__TYPE":                           "string",
+		"MUSE_HOOK__COMMIT_STYLE":                   "string",
+		"MUSE_HOOK__PREVIEW":                        "boolean",
+		"MUSE_HOOK__DRY_RUN":                        "boolean",
+		"MUSE_LLM__PROVIDER":                        "string",
+		"MUSE_LLM__CONFIG__<KEY>":                   "string",
+		"MUSE_LLM__CONFIG__MODEL":                   "string",
+		"MUSE_PROFILE":                              "string",
+		"MUSE_PROFILES__<NAME>__MATCH__REMOTES":     "list",
+		"MUSE_PROFILES__<NAME>__HOOK__COMMIT_STYLE": "string",
+	} {
+		v, ok := byName[name]
+		if !ok {
+			t.Errorf("EnvVars() is missing %s", name)
+			continue
+		}
+		if v.Type != wantType {
+			t.Errorf("%s type = %s, want %s", name, v.Type, wantType)
+		}
+	}
+	if byName["MUSE_LLM__CONFIG__MODEL"].Description != "Model name" {
+		t.Error("provider setting descriptions should come from the provider schema")
+	}
+}
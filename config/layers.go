@@ -0,0 +1,179 @@
+package config
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/klauern/muse/internal/git"
+	"github.com/knadh/koanf"
+	"github.com/knadh/koanf/maps"
+	"github.com/knadh/koanf/parsers/yaml"
+	"github.com/knadh/koanf/providers/confmap"
+	"github.com/knadh/koanf/providers/file"
+	"github.com/knadh/koanf/providers/rawbytes"
+)
+
+//go:embed defaults.yaml
+var DefaultConfig []byte
+
+// Config layers, from lowest to highest precedence
+const (
+	LayerDefaults = "defaults"
+	LayerGlobal   = "global"
+	LayerRepo     = "repo"
+	LayerProfile  = "profile"
+	LayerEnv      = "env"
+	LayerFlags    = "flags"
+)
+
+// RepoConfigNames are the file names looked up in the repository root, in order
+var RepoConfigNames = []string{".muse.yaml", "muse.yaml"}
+
+// Source is a single configuration layer
+type Source struct {
+	Layer string
+	// Path is the file the values were read from, if any
+	Path string
+	// Values holds the nested configuration values of this layer
+	Values map[string]any
+}
+
+// Origin records which layer last set a configuration value
+type Origin struct {
+	Layer string `json:"layer"`
+	Path  string `json:"path,omitempty"`
+}
+
+func (o Origin) String() string {
+	if o.Path != "" {
+		return fmt.Sprintf("%s (%s)", o.Layer, o.Path)
+	}
+	return o.Layer
+}
+
+// Origins maps flattened keys such as "hook.commit_style" to their origin
+type Origins map[string]Origin
+
+// Keys returns the flattened keys in sorted order
+func (o Origins) Keys() []string {
+	keys := make([]string, 0, len(o))
+	for key := range o {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// GlobalConfigPath returns the path of the user's global config file
+func GlobalConfigPath() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "muse.yaml"), nil
+}
+
+// RepoRoot returns the top-level directory of the current git repository,
+// or an empty string outside a repository
+func RepoRoot() string {
+	return RepoRootOf("")
+}
+
+// RepoRootOf returns the top-level directory of the git repository
+// containing dir, or an empty string when dir is not in a repository
+func RepoRootOf(dir string) string {
+	gitOps, err := git.NewGitOperations(dir)
+	if err != nil {
+		return ""
+	}
+	root, err := gitOps.GetRepositoryRoot()
+	if err != nil {
+		return ""
+	}
+	return root
+}
+
+// RepoConfigPath returns the repository config file in root, or an empty
+// string if none exists
+func RepoConfigPath(root string) string {
+	if root == "" {
+		return ""
+	}
+	for _, name := range RepoConfigNames {
+		path := filepath.Join(root, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// DefaultsSource returns the embedded defaults layer
+func DefaultsSource() (Source, error) {
+	k := koanf.New(".")
+	if err := k.Load(rawbytes.Provider(DefaultConfig), yaml.Parser()); err != nil {
+		return Source{}, fmt.Errorf("error loading default config: %w", err)
+	}
+	return Source{Layer: LayerDefaults, Values: k.Raw()}, nil
+}
+
+// FileSource reads a YAML config file as a layer. A missing file yields an
+// empty layer with no Path.
+func FileSource(layer, path string) (Source, error) {
+	source := Source{Layer: layer, Values: map[string]any{}}
+	if path == "" {
+		return source, nil
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return source, nil
+	}
+
+	k := koanf.New(".")
+	if err := k.Load(file.Provider(path), yaml.Parser()); err != nil {
+		return Source{}, fmt.Errorf("error loading config from %s: %w", path, err)
+	}
+	source.Path = path
+	source.Values = k.Raw()
+	return source, nil
+}
+
+// FlatSource builds a layer from flattened keys such as "llm.config.model"
+func FlatSource(layer string, values map[string]any) Source {
+	k := koanf.New(".")
+	// confmap only fails for unflattenable input, which a flat map is not
+	_ = k.Load(confmap.Provider(values, "."), nil)
+	return Source{Layer: layer, Values: k.Raw()}
+}
+
+// Merge deep-merges sources in order, later sources taking precedence, and
+// records the origin of every resulting value
+func Merge(sources []Source) (*koanf.Koanf, Origins, error) {
+	k := koanf.New(".")
+	origins := Origins{}
+
+	for _, source := range sources {
+		if len(source.Values) == 0 {
+			continue
+		}
+		if err := k.Load(confmap.Provider(maps.Copy(source.Values), ""), nil); err != nil {
+			return nil, nil, fmt.Errorf("error merging %s config: %w", source.Layer, err)
+		}
+
+		flat, _ := maps.Flatten(source.Values, nil, ".")
+		for key := range flat {
+			origins[key] = Origin{Layer: source.Layer, Path: source.Path}
+		}
+	}
+
+	// A later layer can replace a map with a scalar, dropping nested keys
+	for key := range origins {
+		if !k.Exists(key) {
+			delete(origins, key)
+		}
+	}
+
+	return k, origins, nil
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestMerge_DeepMergesLayers(t *testing.T) {
+	sources := []Source{
+		{Layer: LayerDefaults, Values: map[string]any{
+			"hook": map[string]any{"commit_style": "conventional", "preview": false},
+			"llm":  map[string]any{"provider": "openai", "config": map[string]any{"model": "gpt-4o"}},
+		}},
+		{Layer: LayerGlobal, Path: "/home/me/.config/muse/muse.yaml", Values: map[string]any{
+			"llm": map[string]any{"config": map[string]any{"api_key": "sk-global"}},
+		}},
+		{Layer: LayerRepo, Path: "/repo/.muse.yaml", Values: map[string]any{
+			"hook": map[string]any{"commit_style": "gitmoji"},
+		}},
+		FlatSource(LayerFlags, map[string]any{"llm.config.model": "gpt-4o-mini"}),
+	}
+
+	k, origins, err := Merge(sources)
+	if err != nil {
+		t.Fatalf("Merge() failed: %v", err)
+	}
+
+	expected := map[string]struct {
+		value any
+		layer string
+	}{
+		"hook.commit_style":  {"gitmoji", LayerRepo},
+		"hook.preview":       {false, LayerDefaults},
+		"llm.provider":       {"openai", LayerDefaults},
+		"llm.config.api_key": {"sk-global", LayerGlobal},
+		"llm.config.model":   {"gpt-4o-mini", LayerFlags},
+	}
+
+	for key, want := range expected {
+		if got := k.Get(key); got != want.value {
+			t.Errorf("%s = %v, want %v", key, got, want.value)
+		}
+		if got := origins[key].Layer; got != want.layer {
+			t.Errorf("origin of %s = %s, want %s", key, got, want.layer)
+		}
+	}
+
+	if origins["llm.config.api_key"].Path != "/home/me/.config/muse/muse.yaml" {
+		t.Errorf("Expected global origin to record its path, got %+v", origins["llm.config.api_key"])
+	}
+	if len(origins) != len(expected) {
+		t.Errorf("Expected %d origins, got %d: %v", len(expected), len(origins), origins.Keys())
+	}
+}
@@ -0,0 +1,153 @@
+package config
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/klauern/muse/internal/git"
+)
+
+// Profile bundles llm and hook settings that are applied on top of the
+// config files when the profile is active
+type Profile struct {
+	Match ProfileMatch `koanf:"match" jsonschema_description:"Repositories that select this profile automatically"`
+	Hook  Hook         `koanf:"hook" jsonschema_description:"Hook settings applied when the profile is active"`
+	LLM   LLMConfig    `koanf:"llm" jsonschema_description:"LLM settings applied when the profile is active"`
+}
+
+// ProfileMatch selects a profile by repository. Patterns are globs where *
+// matches within a path segment and ** matches across segments.
+type ProfileMatch struct {
+	Remotes []string `koanf:"remotes" jsonschema_description:"Remote URL globs such as github.com/acme/**; scheme, user and .git suffix are ignored"`
+	Paths   []string `koanf:"paths" jsonschema_description:"Repository path globs such as ~/work/**"`
+}
+
+// ProfileSource returns the settings of the named profile as a config layer.
+// values are the nested merged config values and path is the file that
+// defines the profile.
+func ProfileSource(values map[string]any, name, path string) (Source, bool) {
+	profiles, _ := values["profiles"].(map[string]any)
+	profile, ok := profiles[name].(map[string]any)
+	if !ok {
+		return Source{}, false
+	}
+
+	layer := map[string]any{}
+	for _, key := range []string{"hook", "llm"} {
+		if v, ok := profile[key]; ok {
+			layer[key] = v
+		}
+	}
+	return Source{Layer: LayerProfile, Path: path, Values: layer}, true
+}
+
+// MatchProfile returns the name of the first profile, in alphabetical order,
+// whose match rules select the repository at root with the given remote URLs
+func MatchProfile(profiles map[string]Profile, root string, remotes []string) string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if profiles[name].Match.Matches(root, remotes) {
+			return name
+		}
+	}
+	return ""
+}
+
+// Matches reports whether the repository at root with the given remote URLs
+// is selected by m
+func (m ProfileMatch) Matches(root string, remotes []string) bool {
+	if root != "" {
+		for _, pattern := range m.Paths {
+			if globMatch(filepath.ToSlash(ExpandHome(pattern)), filepath.ToSlash(root)) {
+				return true
+			}
+		}
+	}
+
+	for _, pattern := range m.Remotes {
+		for _, remote := range remotes {
+			if globMatch(NormalizeRemote(pattern), NormalizeRemote(remote)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+var scpLikeRemote = regexp.MustCompile(`^(?:[^@/]+@)?([^:/]+):(.+)$`)
+
+// NormalizeRemote reduces a remote URL to host/path so that the HTTPS and
+// SSH forms of the same repository compare equal, e.g.
+// git@github.com:acme/app.git and https://github.com/acme/app both become
+// github.com/acme/app
+func NormalizeRemote(remote string) string {
+	remote = strings.TrimSpace(remote)
+	if u, err := url.Parse(remote); err == nil && u.Scheme != "" && u.Host != "" {
+		remote = u.Hostname() + u.Path
+	} else if m := scpLikeRemote.FindStringSubmatch(remote); m != nil {
+		remote = m[1] + "/" + m[2]
+	}
+	remote = strings.TrimSuffix(strings.TrimSuffix(remote, "/"), ".git")
+	return strings.ToLower(remote)
+}
+
+// RepoRemotes returns the remote URLs of the repository at root
+func RepoRemotes(root string) []string {
+	if root == "" {
+		return nil
+	}
+	gitOps, err := git.NewGitOperations(root)
+	if err != nil {
+		return nil
+	}
+	remotes, err := gitOps.GetRemoteURLs()
+	if err != nil {
+		return nil
+	}
+	return remotes
+}
+
+// ExpandHome replaces a leading ~ in path with the user's home directory
+func ExpandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[1:])
+		}
+	}
+	return path
+}
+
+// globMatch matches s against a glob where * and ? stay within a path
+// segment and ** spans segments
+func globMatch(pattern, s string) bool {
+	var re strings.Builder
+	re.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				re.WriteString(".*")
+				i++
+			} else {
+				re.WriteString("[^/]*")
+			}
+		case '?':
+			re.WriteString("[^/]")
+		default:
+			re.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	re.WriteString("$")
+
+	matched, err := regexp.MatchString(re.String(), s)
+	return err == nil && matched
+}
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestNormalizeRemote(t *testing.T) {
+	tests := []struct {
+		remote string
+		want   string
+	}{
+		{"git@github.com:acme/app.git", "github.com/acme/app"},
+		{"https://github.com/Acme/App.git", "github.com/acme/app"},
+		{"ssh://git@gitlab.example.com:2222/team/app", "gitlab.example.com/team/app"},
+		{"https://user@bitbucket.org/acme/app/", "bitbucket.org/acme/app"},
+		{"github.com/acme/**", "github.com/acme/**"},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeRemote(tt.remote); got != tt.want {
+			t.Errorf("NormalizeRemote(%q) = %q, want %q", tt.remote, got, tt.want)
+		}
+	}
+}
+
+func TestProfileMatch_Matches(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	tests := []struct {
+		name    string
+		match   ProfileMatch
+		root    string
+		remotes []string
+		want    bool
+	}{
+		{
+			name:    "ssh remote against https pattern",
+			match:   ProfileMatch{Remotes: []string{"https://github.com/acme/*"}},
+			remotes: []string{"git@github.com:acme/app.git"},
+			want:    true,
+		},
+		{
+			name:    "single star stays within a segment",
+			match:   ProfileMatch{Remotes: []string{"github.com/*"}},
+			remotes: []string{"https://github.com/acme/app"},
+			want:    false,
+		},
+		{
+			name:    "double star spans segments",
+			match:   ProfileMatch{Remotes: []string{"github.com/**"}},
+			remotes: []string{"https://github.com/acme/app"},
+			want:    true,
+		},
+		{
+			name:  "home-relative path",
+			match: ProfileMatch{Paths: []string{"~/work/**"}},
+			root:  filepath.Join(home, "work", "client", "app"),
+			want:  true,
+		},
+		{
+			name:  "other path",
+			match: ProfileMatch{Paths: []string{"~/work/**"}},
+			root:  filepath.Join(home, "oss", "app"),
+			want:  false,
+		},
+		{
+			name: "no rules",
+			root: filepath.Join(home, "work", "app"),
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.match.Matches(tt.root, tt.remotes); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchProfile_FirstAlphabeticalMatchWins(t *testing.T) {
+	profiles := map[string]Profile{
+		"zeta":  {Match: ProfileMatch{Remotes: []string{"github.com/acme/**"}}},
+		"alpha": {Match: ProfileMatch{Remotes: []string{"github.com/**"}}},
+		"other": {Match: ProfileMatch{Remotes: []string{"gitlab.com/**"}}},
+	}
+
+	if got := MatchProfile(profiles, "", []string{"git@github.com:acme/app.git"}); got != "alpha" {
+		t.Errorf("MatchProfile() = %q, want alpha", got)
+	}
+	if got := MatchProfile(profiles, "", []string{"https://example.com/app"}); got != "" {
+		t.Errorf("MatchProfile() = %q, want no match", got)
+	}
+}
+
+func TestProfileSource(t *testing.T) {
+	values := map[string]any{
+		"profiles": map[string]any{
+			"work": map[string]any{
+				"match": map[string]any{"paths": []any{"~/work/**"}},
+				"hook":  map[string]any{"commit_style": "gitmoji"},
+			},
+		},
+	}
+
+	source, ok := ProfileSource(values, "work", "/etc/muse.yaml")
+	if !ok {
+		t.Fatal("ProfileSource() did not find the work profile")
+	}
+	if source.Layer != LayerProfile || source.Path != "/etc/muse.yaml" {
+		t.Errorf("source = %s (%s), want profile (/etc/muse.yaml)", source.Layer, source.Path)
+	}
+	if _, hasMatch := source.Values["match"]; hasMatch {
+		t.Error("match rules should not be part of the profile layer")
+	}
+	if _, ok := ProfileSource(values, "missing", ""); ok {
+		t.Error("ProfileSource() found a profile that does not exist")
+	}
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"sort"
+
+	"github.com/invopop/jsonschema"
+)
+
+// SchemaID identifies the muse.yaml schema for editors
+const SchemaID = "https://github.com/klauern/muse/muse.schema.json"
+
+// Schema returns the JSON Schema of muse.yaml for editor integration.
+// providers maps provider names to the schema of their llm.config section;
+// llm.config is checked against the schema of the provider named in the
+// same file.
+func Schema(providers map[string]*jsonschema.Schema) *jsonschema.Schema {
+	schema := baseSchema(providers)
+	schema.Version = jsonschema.Version
+	schema.ID = SchemaID
+
+	for _, name := range providerNames(providers) {
+		schema.AllOf = append(schema.AllOf, &jsonschema.Schema{
+			If:   objectSchema("llm", objectSchema("provider", &jsonschema.Schema{Const: name}, true), true),
+			Then: objectSchema("llm", objectSchema("config", providers[name], false), false),
+		})
+	}
+	return schema
+}
+
+// SchemaForProvider returns the schema used to validate a config file when
+// provider is the effective provider after all layers are merged. Unlike
+// Schema it applies the provider's llm.config schema unconditionally, since
+// a file may set llm.config without naming the provider.
+func SchemaForProvider(provider string, providers map[string]*jsonschema.Schema) *jsonschema.Schema {
+	schema := baseSchema(providers)
+	if providerSchema, ok := providers[provider]; ok {
+		llm, _ := schema.Properties.Get("llm")
+		llm.Properties.Set("config", providerSchema)
+	}
+	return schema
+}
+
+// baseSchema reflects Config and adds the enums that cannot be expressed
+// with struct tags
+func baseSchema(providers map[string]*jsonschema.Schema) *jsonschema.Schema {
+	reflector := jsonschema.Reflector{
+		FieldNameTag:               "koanf",
+		AllowAdditionalProperties:  false,
+		DoNotReference:             true,
+		RequiredFromJSONSchemaTags: true,
+	}
+	schema := reflector.Reflect(&Config{})
+	schema.Version = ""
+	schema.ID = ""
+
+	addEnums(schema, providers)
+
+	// Profiles carry their own hook and llm blocks
+	if profiles, ok := schema.Properties.Get("profiles"); ok && profiles.AdditionalProperties != nil {
+		addEnums(profiles.AdditionalProperties, providers)
+	}
+
+	return schema
+}
+
+// addEnums restricts the hook and llm properties of schema to known values
+func addEnums(schema *jsonschema.Schema, providers map[string]*jsonschema.Schema) {
+	hook, _ := schema.Properties.Get("hook")
+	hookType, _ := hook.Properties.Get("type")
+	for _, t := range SupportedHookTypes {
+		hookType.Enum = append(hookType.Enum, t)
+	}
+	if styles, err := AvailableStyleNames(); err == nil {
+		commitStyle, _ := hook.Properties.Get("commit_style")
+		for _, style := range styles {
+			commitStyle.Enum = append(commitStyle.Enum, style)
+		}
+	}
+
+	llm, _ := schema.Properties.Get("llm")
+	provider, _ := llm.Properties.Get("provider")
+	for _, name := range providerNames(providers) {
+		provider.Enum = append(provider.Enum, name)
+	}
+}
+
+// objectSchema builds {"type": "object", "properties": {key: value}}
+func objectSchema(key string, value *jsonschema.Schema, required bool) *jsonschema.Schema {
+	schema := &jsonschema.Schema{Type: "object", Properties: jsonschema.NewProperties()}
+	schema.Properties.Set(key, value)
+	if required {
+		schema.Required = []string{key}
+	}
+	return schema
+}
+
+func providerNames(providers map[string]*jsonschema.Schema) []string {
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
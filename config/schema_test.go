@@ -0,0 +1,134 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/invopop/jsonschema"
+)
+
+// testProviderSchemas mimics the schemas contributed by llm providers
+func testProviderSchemas() map[string]*jsonschema.Schema {
+	config := &jsonschema.Schema{
+		Type:                 "object",
+		Properties:           jsonschema.NewProperties(),
+		AdditionalProperties: jsonschema.FalseSchema,
+	}
+	config.Properties.Set("model", &jsonschema.Schema{Type: "string"})
+	config.Properties.Set("api_base", &jsonschema.Schema{Type: "string", Format: "uri"})
+
+	return map[string]*jsonschema.Schema{
+		"openai": config,
+		"custom": jsonschema.TrueSchema,
+	}
+}
+
+func TestValidateYAML(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []SchemaError
+	}{
+		{
+			name:    "valid",
+			content: "hook:\n  commit_style: gitmoji\n  preview: true\nllm:\n  provider: openai\n  config:\n    model: gpt-4o\n",
+		},
+		{name: "empty document", content: ""},
+		{name: "null value", content: "hook:\n  preview:\n"},
+		{name: "compound duration", content: "cache:\n  ttl: 1h30m\n"},
+		{
+			name:    "duration without unit",
+			content: "cache:\n  ttl: \"90\"\n",
+			want:    []SchemaError{{Line: 2, Column: 8, Field: "cache.ttl", Reason: `"90" must match ^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`}},
+		},
+		{
+			name:    "typo in key",
+			content: "hook:\n  commit_sytle: gitmoji\n",
+			want:    []SchemaError{{Line: 2, Column: 3, Field: "hook.commit_sytle", Reason: `unknown key; did you mean "commit_style"?`}},
+		},
+		{
+			name:    "quoted boolean",
+			content: "hook:\n  preview: \"yes\"\n",
+			want:    []SchemaError{{Line: 2, Column: 12, Field: "hook.preview", Reason: `must be a boolean, got string "yes"`}},
+		},
+		{
+			name:    "unknown style",
+			content: "hook:\n  commit_style: gitmojis\n",
+			want:    []SchemaError{{Line: 2, Column: 17, Field: "hook.commit_style", Reason: `"gitmojis" must be one of conventional, default, gitmoji`}},
+		},
+		{
+			name:    "unknown provider setting",
+			content: "llm:\n  config:\n    modle: gpt-4o\n",
+			want:    []SchemaError{{Line: 3, Column: 5, Field: "llm.config.modle", Reason: `unknown key; did you mean "model"?`}},
+		},
+		{
+			name:    "multiple errors",
+			content: "hook:\n  dry_run: 1\nllm:\n  config:\n    api_base: not a url\n",
+			want: []SchemaError{
+				{Line: 2, Column: 12, Field: "hook.dry_run", Reason: "must be a boolean, got number 1"},
+				{Line: 5, Column: 15, Field: "llm.config.api_base", Reason: `"not a url" must be an absolute URL`},
+			},
+		},
+	}
+
+	schema := SchemaForProvider("openai", testProviderSchemas())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateYAML("muse.yaml", []byte(tt.content), schema)
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("ValidateYAML() error = %v, want nil", err)
+				}
+				return
+			}
+
+			var errs SchemaErrors
+			if !errors.As(err, &errs) {
+				t.Fatalf("ValidateYAML() error = %v, want SchemaErrors", err)
+			}
+			if len(errs) != len(tt.want) {
+				t.Fatalf("ValidateYAML() returned %d errors, want %d: %v", len(errs), len(tt.want), err)
+			}
+			for i, want := range tt.want {
+				want.Path = "muse.yaml"
+				if errs[i] != want {
+					t.Errorf("error %d = %+v, want %+v", i, errs[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestSchemaForProvider_UnknownProviderAcceptsAnySettings(t *testing.T) {
+	schema := SchemaForProvider("custom", testProviderSchemas())
+	content := "llm:\n  provider: custom\n  config:\n    temperature: 0.2\n"
+	if err := ValidateYAML("muse.yaml", []byte(content), schema); err != nil {
+		t.Errorf("ValidateYAML() error = %v, want nil", err)
+	}
+}
+
+func TestSchema_ProviderConditions(t *testing.T) {
+	schema := Schema(testProviderSchemas())
+
+	data, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatalf("failed to marshal schema: %v", err)
+	}
+	for _, want := range []string{`"$id":"` + SchemaID + `"`, `"const":"openai"`, `"const":"custom"`, `"enum":["custom","openai"]`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("schema does not contain %s", want)
+		}
+	}
+
+	// The conditional provider schema applies when the file names the provider
+	content := "llm:\n  provider: openai\n  config:\n    temperature: 0.2\n"
+	if err := ValidateYAML("muse.yaml", []byte(content), schema); err == nil {
+		t.Error("ValidateYAML() accepted an unknown openai setting")
+	}
+	content = "llm:\n  provider: custom\n  config:\n    temperature: 0.2\n"
+	if err := ValidateYAML("muse.yaml", []byte(content), schema); err != nil {
+		t.Errorf("ValidateYAML() error = %v, want nil", err)
+	}
+}
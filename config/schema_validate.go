@@ -0,0 +1,276 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/invopop/jsonschema"
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaError is a value in a config file that does not match the schema
+type SchemaError struct {
+	Path   string
+	Line   int
+	Column int
+	// Field is the flattened config key, e.g. "hook.commit_style"
+	Field  string
+	Reason string
+}
+
+func (e SchemaError) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %s: %s", e.Path, e.Line, e.Column, e.Field, e.Reason)
+}
+
+// SchemaErrors collects every schema mismatch found in a config file
+type SchemaErrors []SchemaError
+
+func (e SchemaErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+// ValidateYAML checks a YAML config document read from path against schema.
+// It returns SchemaErrors with the line and column of every mismatch. Only
+// the keywords muse schemas use are supported: type, properties,
+// additionalProperties, required, items, enum, const, pattern, format "uri",
+// allOf and if/then.
+func ValidateYAML(path string, data []byte, schema *jsonschema.Schema) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if len(doc.Content) == 0 {
+		return nil
+	}
+
+	v := &schemaValidator{path: path}
+	v.validate(doc.Content[0], schema, "")
+	if len(v.errs) > 0 {
+		return v.errs
+	}
+	return nil
+}
+
+type schemaValidator struct {
+	path string
+	errs SchemaErrors
+}
+
+func (v *schemaValidator) fail(node *yaml.Node, field, format string, args ...any) {
+	v.errs = append(v.errs, SchemaError{
+		Path:   v.path,
+		Line:   node.Line,
+		Column: node.Column,
+		Field:  field,
+		Reason: fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *schemaValidator) validate(node *yaml.Node, schema *jsonschema.Schema, field string) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if schema == nil || schema == jsonschema.TrueSchema {
+		return
+	}
+	if schema == jsonschema.FalseSchema {
+		v.fail(node, field, "is not allowed")
+		return
+	}
+	// An empty value leaves the setting unset, which koanf accepts for any type
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		return
+	}
+
+	if schema.Type != "" && !matchesType(node, schema.Type) {
+		v.fail(node, field, "must be %s, got %s", withArticle(schema.Type), describeNode(node))
+		return
+	}
+
+	if node.Kind == yaml.ScalarNode {
+		v.validateScalar(node, schema, field)
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		v.validateMapping(node, schema, field)
+	case yaml.SequenceNode:
+		if schema.Items != nil {
+			for i, item := range node.Content {
+				v.validate(item, schema.Items, fmt.Sprintf("%s[%d]", field, i))
+			}
+		}
+	}
+
+	for _, sub := range schema.AllOf {
+		v.validate(node, sub, field)
+	}
+	if schema.If != nil && schema.Then != nil {
+		probe := &schemaValidator{path: v.path}
+		probe.validate(node, schema.If, field)
+		if len(probe.errs) == 0 {
+			v.validate(node, schema.Then, field)
+		}
+	}
+}
+
+func (v *schemaValidator) validateScalar(node *yaml.Node, schema *jsonschema.Schema, field string) {
+	if len(schema.Enum) > 0 && !inEnum(node.Value, schema.Enum) {
+		v.fail(node, field, "%q must be one of %s", node.Value, joinEnum(schema.Enum))
+	}
+	if schema.Const != nil && node.Value != fmt.Sprint(schema.Const) {
+		v.fail(node, field, "must be %q", fmt.Sprint(schema.Const))
+	}
+	if schema.Pattern != "" {
+		if re, err := regexp.Compile(schema.Pattern); err == nil && !re.MatchString(node.Value) {
+			v.fail(node, field, "%q must match %s", node.Value, schema.Pattern)
+		}
+	}
+	if schema.Format == "uri" {
+		if u, err := url.Parse(node.Value); err != nil || u.Scheme == "" || u.Host == "" {
+			v.fail(node, field, "%q must be an absolute URL", node.Value)
+		}
+	}
+}
+
+func (v *schemaValidator) validateMapping(node *yaml.Node, schema *jsonschema.Schema, field string) {
+	seen := make(map[string]bool)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, valueNode := node.Content[i], node.Content[i+1]
+		key := keyNode.Value
+		seen[key] = true
+		child := joinField(field, key)
+
+		if schema.Properties != nil {
+			if propSchema, ok := schema.Properties.Get(key); ok {
+				v.validate(valueNode, propSchema, child)
+				continue
+			}
+		}
+		if schema.AdditionalProperties == jsonschema.FalseSchema {
+			if suggestion := closestProperty(key, schema); suggestion != "" {
+				v.fail(keyNode, child, "unknown key; did you mean %q?", suggestion)
+			} else {
+				v.fail(keyNode, child, "unknown key")
+			}
+			continue
+		}
+		v.validate(valueNode, schema.AdditionalProperties, child)
+	}
+
+	for _, key := range schema.Required {
+		if !seen[key] {
+			v.fail(node, joinField(field, key), "is required")
+		}
+	}
+}
+
+// matchesType reports whether node holds a value of the JSON Schema type
+func matchesType(node *yaml.Node, schemaType string) bool {
+	switch schemaType {
+	case "object":
+		return node.Kind == yaml.MappingNode
+	case "array":
+		return node.Kind == yaml.SequenceNode
+	case "string":
+		return node.Kind == yaml.ScalarNode && node.Tag == "!!str"
+	case "boolean":
+		return node.Kind == yaml.ScalarNode && node.Tag == "!!bool"
+	case "integer":
+		return node.Kind == yaml.ScalarNode && node.Tag == "!!int"
+	case "number":
+		return node.Kind == yaml.ScalarNode && (node.Tag == "!!int" || node.Tag == "!!float")
+	}
+	return true
+}
+
+func describeNode(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "an object"
+	case yaml.SequenceNode:
+		return "an array"
+	}
+	switch node.Tag {
+	case "!!str":
+		return fmt.Sprintf("string %q", node.Value)
+	case "!!bool":
+		return "boolean " + node.Value
+	case "!!int", "!!float":
+		return "number " + node.Value
+	}
+	return node.Value
+}
+
+func withArticle(schemaType string) string {
+	switch schemaType {
+	case "object", "array", "integer":
+		return "an " + schemaType
+	}
+	return "a " + schemaType
+}
+
+func joinField(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
+
+func inEnum(value string, enum []any) bool {
+	for _, e := range enum {
+		if fmt.Sprint(e) == value {
+			return true
+		}
+	}
+	return false
+}
+
+func joinEnum(enum []any) string {
+	values := make([]string, 0, len(enum))
+	for _, e := range enum {
+		values = append(values, fmt.Sprint(e))
+	}
+	return strings.Join(values, ", ")
+}
+
+// closestProperty suggests a known key for a likely typo
+func closestProperty(key string, schema *jsonschema.Schema) string {
+	if schema.Properties == nil {
+		return ""
+	}
+	best, bestDistance := "", 3
+	for pair := schema.Properties.Oldest(); pair != nil; pair = pair.Next() {
+		if d := editDistance(key, pair.Key); d < bestDistance {
+			best, bestDistance = pair.Key, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr := make([]int, len(b)+1)
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev = curr
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,222 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/klauern/muse/templates"
+)
+
+// ReviewSeverities lists the accepted values of review.fail_on, from the
+// most to the least severe
+var ReviewSeverities = []string{"error", "warning", "info", "never"}
+
+// SupportedHookTypes lists the accepted values of hook.type
+var SupportedHookTypes = []string{"prepare-commit-msg"}
+
+var providerNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// ValidationError describes a single invalid configuration field
+type ValidationError struct {
+	// Field is the flattened config key, e.g. "hook.commit_style"
+	Field  string
+	Value  any
+	Reason string
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("invalid %s %v: %s", e.Field, e.Value, e.Reason)
+}
+
+// ValidationErrors collects every invalid field found in a config
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Validate checks field values that koanf cannot check by type alone. It
+// returns ValidationErrors when any field is invalid.
+func (c *Config) Validate() error {
+	var errs ValidationErrors
+	errs = append(errs, c.Hook.validate()...)
+	errs = append(errs, c.LLM.validate()...)
+	errs = append(errs, c.Cache.validate()...)
+	errs = append(errs, c.Review.validate()...)
+	errs = append(errs, c.Usage.validate()...)
+	errs = append(errs, c.Feedback.validate()...)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (h Hook) validate() []ValidationError {
+	var errs []ValidationError
+
+	if !contains(SupportedHookTypes, h.Type) {
+		errs = append(errs, ValidationError{
+			Field:  "hook.type",
+			Value:  fmt.Sprintf("%q", h.Type),
+			Reason: "must be one of " + strings.Join(SupportedHookTypes, ", "),
+		})
+	}
+
+	if names, err := AvailableStyleNames(); err == nil {
+		if !contains(names, string(h.CommitStyle)) {
+			errs = append(errs, ValidationError{
+				Field:  "hook.commit_style",
+				Value:  fmt.Sprintf("%q", h.CommitStyle),
+				Reason: "must be one of " + strings.Join(names, ", "),
+			})
+		}
+	}
+
+	return errs
+}
+
+func (l LLMConfig) validate() []ValidationError {
+	var errs []ValidationError
+
+	if !providerNamePattern.MatchString(l.Provider) {
+		errs = append(errs, ValidationError{
+			Field:  "llm.provider",
+			Value:  fmt.Sprintf("%q", l.Provider),
+			Reason: "must be a provider name such as openai",
+		})
+	}
+
+	for _, key := range []string{"model", "api_key", "api_base"} {
+		value, ok := l.Config[key]
+		if !ok {
+			continue
+		}
+		if _, isString := value.(string); !isString {
+			errs = append(errs, ValidationError{
+				Field:  "llm.config." + key,
+				Value:  value,
+				Reason: fmt.Sprintf("must be a string, got %T", value),
+			})
+		}
+	}
+
+	if apiBase, ok := l.Config["api_base"].(string); ok && apiBase != "" {
+		u, err := url.Parse(apiBase)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, ValidationError{
+				Field:  "llm.config.api_base",
+				Value:  fmt.Sprintf("%q", apiBase),
+				Reason: "must be an http or https URL",
+			})
+		}
+	}
+
+	return errs
+}
+
+func (c CacheConfig) validate() []ValidationError {
+	var errs []ValidationError
+
+	if c.TTL != "" {
+		if ttl, err := time.ParseDuration(c.TTL); err != nil || ttl <= 0 {
+			errs = append(errs, ValidationError{
+				Field:  "cache.ttl",
+				Value:  fmt.Sprintf("%q", c.TTL),
+				Reason: "must be a positive duration such as 24h",
+			})
+		}
+	}
+
+	if c.MaxSizeMB < 0 {
+		errs = append(errs, ValidationError{
+			Field:  "cache.max_size_mb",
+			Value:  c.MaxSizeMB,
+			Reason: "must not be negative",
+		})
+	}
+
+	return errs
+}
+
+// AvailableStyleNames returns the accepted values of hook.commit_style
+func AvailableStyleNames() ([]string, error) {
+	styles, err := templates.AvailableStyles()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(styles))
+	for _, style := range styles {
+		names = append(names, string(style))
+	}
+	return names, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (r ReviewConfig) validate() []ValidationError {
+	if r.FailOn == "" || contains(ReviewSeverities, r.FailOn) {
+		return nil
+	}
+	return []ValidationError{{
+		Field:  "review.fail_on",
+		Value:  fmt.Sprintf("%q", r.FailOn),
+		Reason: "must be one of " + strings.Join(ReviewSeverities, ", "),
+	}}
+}
+
+func (u UsageConfig) validate() []ValidationError {
+	var errs []ValidationError
+	for i, price := range u.Prices {
+		field := fmt.Sprintf("usage.prices.%d", i)
+		if strings.TrimSpace(price.Model) == "" {
+			errs = append(errs, ValidationError{
+				Field:  field + ".model",
+				Value:  fmt.Sprintf("%q", price.Model),
+				Reason: "must name a model",
+			})
+		}
+		if price.Input < 0 || price.Output < 0 {
+			errs = append(errs, ValidationError{
+				Field:  field,
+				Value:  fmt.Sprintf("%v/%v", price.Input, price.Output),
+				Reason: "prices must not be negative",
+			})
+		}
+	}
+	return errs
+}
+
+func (f FeedbackConfig) validate() []ValidationError {
+	var errs []ValidationError
+	if f.MaxExamples < 0 {
+		errs = append(errs, ValidationError{
+			Field:  "feedback.max_examples",
+			Value:  f.MaxExamples,
+			Reason: "must not be negative",
+		})
+	}
+	if f.HalfLife != "" {
+		if halfLife, err := time.ParseDuration(f.HalfLife); err != nil || halfLife <= 0 {
+			errs = append(errs, ValidationError{
+				Field:  "feedback.half_life",
+				Value:  fmt.Sprintf("%q", f.HalfLife),
+				Reason: "must be a positive duration such as 336h",
+			})
+		}
+	}
+	return errs
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	valid := func() *Config {
+		return &Config{
+			Hook: Hook{Type: "prepare-commit-msg", CommitStyle: "conventional"},
+			LLM: LLMConfig{Provider: "openai", Config: map[string]any{
+				"model":    "gpt-4o",
+				"api_base": "https://api.openai.com/v1",
+			}},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		mutate     func(*Config)
+		wantFields []string
+	}{
+		{name: "valid", mutate: func(c *Config) {}},
+		{name: "unknown style", mutate: func(c *Config) { c.Hook.CommitStyle = "gitmojis" }, wantFields: []string{"hook.commit_style"}},
+		{name: "unknown hook type", mutate: func(c *Config) { c.Hook.Type = "commit-msg" }, wantFields: []string{"hook.type"}},
+		{name: "empty provider", mutate: func(c *Config) { c.LLM.Provider = "" }, wantFields: []string{"llm.provider"}},
+		{name: "non-string model", mutate: func(c *Config) { c.LLM.Config["model"] = 4 }, wantFields: []string{"llm.config.model"}},
+		{name: "bad cache ttl", mutate: func(c *Config) { c.Cache.TTL = "a week" }, wantFields: []string{"cache.ttl"}},
+		{name: "unknown review severity", mutate: func(c *Config) { c.Review.FailOn = "critical" }, wantFields: []string{"review.fail_on"}},
+		{name: "price without model", mutate: func(c *Config) { c.Usage.Prices = []ModelPrice{{Input: 1}} }, wantFields: []string{"usage.prices.0.model"}},
+		{name: "negative example cap", mutate: func(c *Config) { c.Feedback.MaxExamples = -1 }, wantFields: []string{"feedback.max_examples"}},
+		{name: "bad feedback half life", mutate: func(c *Config) { c.Feedback.HalfLife = "0s" }, wantFields: []string{"feedback.half_life"}},
+		{name: "negative price", mutate: func(c *Config) { c.Usage.Prices = []ModelPrice{{Model: "gpt-4o", Output: -1}} }, wantFields: []string{"usage.prices.0"}},
+		{name: "negative cache size", mutate: func(c *Config) { c.Cache.MaxSizeMB = -1 }, wantFields: []string{"cache.max_size_mb"}},
+		{name: "bad api base", mutate: func(c *Config) { c.LLM.Config["api_base"] = "api.openai.com" }, wantFields: []string{"llm.config.api_base"}},
+		{
+			name: "multiple errors",
+			mutate: func(c *Config) {
+				c.Hook.CommitStyle = "nope"
+				c.LLM.Provider = "Open AI"
+			},
+			wantFields: []string{"hook.commit_style", "llm.provider"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := valid()
+			tt.mutate(cfg)
+
+			err := cfg.Validate()
+			if len(tt.wantFields) == 0 {
+				if err != nil {
+					t.Fatalf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+
+			var errs ValidationErrors
+			if !errors.As(err, &errs) {
+				t.Fatalf("Validate() = %v, want ValidationErrors", err)
+			}
+			if len(errs) != len(tt.wantFields) {
+				t.Fatalf("Validate() returned %d errors, want %d: %v", len(errs), len(tt.wantFields), errs)
+			}
+			for i, field := range tt.wantFields {
+				if errs[i].Field != field {
+					t.Errorf("error %d field = %s, want %s", i, errs[i].Field, field)
+				}
+			}
+		})
+	}
+}
@@ -4,10 +4,13 @@ go 1.24.4
 
 require (
 	github.com/briandowns/spinner v1.23.1
+	github.com/fsnotify/fsnotify v1.7.0
 	github.com/invopop/jsonschema v0.12.0
 	github.com/knadh/koanf v1.5.0
 	github.com/openai/openai-go v0.1.0-alpha.31
 	github.com/urfave/cli/v2 v2.27.5
+	golang.org/x/term v0.25.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -16,7 +19,6 @@ require (
 	github.com/cpuguy83/go-md2man/v2 v2.0.5 // indirect
 	github.com/davecgh/go-spew v1.1.2-0.20180830191138-d8f796af33cc // indirect
 	github.com/fatih/color v1.18.0 // indirect
-	github.com/fsnotify/fsnotify v1.7.0 // indirect
 	github.com/kr/pretty v0.3.1 // indirect
 	github.com/mailru/easyjson v0.7.7 // indirect
 	github.com/mattn/go-colorable v0.1.13 // indirect
@@ -34,6 +36,4 @@ require (
 	github.com/wk8/go-ordered-map/v2 v2.1.8 // indirect
 	github.com/xrash/smetrics v0.0.0-20240521201337-686a1a2994c1 // indirect
 	golang.org/x/sys v0.26.0 // indirect
-	golang.org/x/term v0.25.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
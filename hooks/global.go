@@ -0,0 +1,376 @@
+package hooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauern/muse/config"
+	"github.com/klauern/muse/internal/fileops"
+	"github.com/klauern/muse/internal/git"
+	"github.com/klauern/muse/internal/shell"
+)
+
+// GlobalMode selects how muse is installed for all repositories
+type GlobalMode string
+
+const (
+	// HooksPathMode points core.hooksPath at a muse-managed hooks directory,
+	// which affects every repository immediately.
+	HooksPathMode GlobalMode = "hooks-path"
+	// TemplateDirMode points init.templateDir at a muse-managed template, so
+	// the hook is copied into repositories on git init and git clone.
+	TemplateDirMode GlobalMode = "template-dir"
+)
+
+// GlobalState records a global installation so it can be reported and undone
+type GlobalState struct {
+	Mode        GlobalMode `json:"mode"`
+	ConfigKey   string     `json:"config_key"`
+	ConfigValue string     `json:"config_value"`
+	HookPath    string     `json:"hook_path"`
+	// PreviousValue holds the git config value that muse replaced
+	PreviousValue string `json:"previous_value,omitempty"`
+	HadPrevious   bool   `json:"had_previous"`
+}
+
+func globalStatePath() (string, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "global-install.json"), nil
+}
+
+// globalTarget returns the git config key and managed directory for a mode
+func globalTarget(mode GlobalMode) (string, string, string, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return "", "", "", err
+	}
+
+	switch mode {
+	case HooksPathMode:
+		hooksDir := filepath.Join(dir, "hooks")
+		return "core.hooksPath", hooksDir, hooksDir, nil
+	case TemplateDirMode:
+		templateDir := filepath.Join(dir, "template")
+		return "init.templateDir", templateDir, filepath.Join(templateDir, "hooks"), nil
+	default:
+		return "", "", "", fmt.Errorf("unknown global install mode: %s", mode)
+	}
+}
+
+// LoadGlobalState returns the recorded global installation, or nil if muse
+// is not installed globally.
+func LoadGlobalState() (*GlobalState, error) {
+	statePath, err := globalStatePath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(statePath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read global install state: %w", err)
+	}
+
+	var state GlobalState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse global install state %s: %w", statePath, err)
+	}
+	return &state, nil
+}
+
+func saveGlobalState(state *GlobalState) error {
+	statePath, err := globalStatePath()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode global install state: %w", err)
+	}
+	return fileops.AtomicWriteFile(statePath, data, 0o644)
+}
+
+// InstallGlobal installs the hook for every repository using the given
+// mode. With postCommit, the post-commit hook records how generated messages
+// were edited.
+func (i *Installer) InstallGlobal(mode GlobalMode, postCommit bool) error {
+	existing, err := LoadGlobalState()
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.Mode != mode {
+		return fmt.Errorf("muse is already installed globally via %s; run 'muse uninstall --global' first", existing.ConfigKey)
+	}
+
+	configKey, configValue, hooksDir, err := globalTarget(mode)
+	if err != nil {
+		return err
+	}
+
+	_, binaryPath, binaryName, err := getExecutableInfo()
+	if err != nil {
+		slog.Error("Failed to get executable info", "error", err)
+		return fmt.Errorf("failed to get executable info: %w", err)
+	}
+
+	// Re-running install only refreshes the hook scripts; the original git
+	// config value is already recorded.
+	state := existing
+	if state == nil {
+		previous, hadPrevious, err := git.GetGlobalConfig(configKey)
+		if err != nil {
+			return err
+		}
+		if hadPrevious && previous != configValue {
+			fmt.Printf("Replacing existing %s=%s; it will be restored on uninstall\n", configKey, previous)
+		}
+		state = &GlobalState{
+			Mode:          mode,
+			ConfigKey:     configKey,
+			ConfigValue:   configValue,
+			HookPath:      filepath.Join(hooksDir, "prepare-commit-msg"),
+			PreviousValue: previous,
+			HadPrevious:   hadPrevious,
+		}
+	}
+
+	// core.hooksPath makes git ignore .git/hooks and the hooks directory it
+	// replaced, so every managed hook has to run those hooks itself. Template
+	// hooks are copied into .git/hooks and replace nothing.
+	var hookContent string
+	if mode == HooksPathMode {
+		previousDir := previousHooksDir(state)
+		hookContent = generateGlobalHookScript(binaryPath, binaryName, previousDir)
+		for _, name := range forwardedHooks {
+			content := generateForwardingScript(name, previousDir)
+			if name == "post-commit" && postCommit {
+				content = generateGlobalPostCommitScript(binaryPath, binaryName, previousDir)
+			}
+			if err := addOrUpdateHookContent(filepath.Join(hooksDir, name), content); err != nil {
+				slog.Error("Failed to add or update hook content", "hook", name, "error", err)
+				return fmt.Errorf("failed to add or update %s hook: %w", name, err)
+			}
+		}
+	} else {
+		hookContent = generateHookScript(binaryPath, binaryName)
+		postCommitPath := filepath.Join(hooksDir, "post-commit")
+		if postCommit {
+			err = addOrUpdateHookContent(postCommitPath, generatePostCommitScript(binaryPath, binaryName))
+		} else {
+			_, err = removeHookContent(postCommitPath)
+		}
+		if err != nil {
+			slog.Error("Failed to update post-commit hook", "error", err)
+			return fmt.Errorf("failed to update post-commit hook: %w", err)
+		}
+	}
+
+	fmt.Printf("Installing global prepare-commit-msg hook... at %s\n", state.HookPath)
+	if err := addOrUpdateHookContent(state.HookPath, hookContent); err != nil {
+		slog.Error("Failed to add or update hook content", "error", err)
+		return fmt.Errorf("failed to add or update hook content: %w", err)
+	}
+
+	if existing != nil {
+		fmt.Println("Global prepare-commit-msg hook updated successfully")
+		return nil
+	}
+
+	// Record the state before touching git config so a failure part-way
+	// through can still be undone.
+	if err := saveGlobalState(state); err != nil {
+		return fmt.Errorf("failed to save global install state: %w", err)
+	}
+
+	if err := git.SetGlobalConfig(configKey, configValue); err != nil {
+		return err
+	}
+
+	fmt.Printf("Global prepare-commit-msg hook installed successfully (%s=%s)\n", configKey, configValue)
+	return nil
+}
+
+// UninstallGlobal removes the global hook and restores the git config value
+// that was in place before InstallGlobal ran.
+func (i *Installer) UninstallGlobal() error {
+	state, err := LoadGlobalState()
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		slog.Info("muse is not installed globally")
+		return nil
+	}
+
+	if _, err := removeHookContent(state.HookPath); err != nil {
+		slog.Error("Failed to remove hook", "error", err)
+		return fmt.Errorf("failed to remove hook: %w", err)
+	}
+	for _, name := range forwardedHooks {
+		if _, err := removeHookContent(filepath.Join(filepath.Dir(state.HookPath), name)); err != nil {
+			slog.Error("Failed to remove hook", "hook", name, "error", err)
+			return fmt.Errorf("failed to remove %s hook: %w", name, err)
+		}
+	}
+
+	current, isSet, err := git.GetGlobalConfig(state.ConfigKey)
+	if err != nil {
+		return err
+	}
+
+	// Only restore the config if it still points at muse; the user may have
+	// changed it since.
+	if isSet && current == state.ConfigValue {
+		if state.HadPrevious {
+			err = git.SetGlobalConfig(state.ConfigKey, state.PreviousValue)
+		} else {
+			err = git.UnsetGlobalConfig(state.ConfigKey)
+		}
+		if err != nil {
+			return err
+		}
+	} else {
+		slog.Warn("Global git config no longer points at muse; leaving it unchanged",
+			"key", state.ConfigKey, "value", current)
+	}
+
+	statePath, err := globalStatePath()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(statePath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove global install state: %w", err)
+	}
+
+	if state.HadPrevious {
+		fmt.Printf("Global prepare-commit-msg hook uninstalled; restored %s=%s\n", state.ConfigKey, state.PreviousValue)
+	} else {
+		fmt.Printf("Global prepare-commit-msg hook uninstalled; unset %s\n", state.ConfigKey)
+	}
+	return nil
+}
+
+// forwardedHooks are the hooks besides prepare-commit-msg that git looks for
+// in core.hooksPath. push-to-checkout and proc-receive are left out because
+// merely having them changes what git does.
+var forwardedHooks = []string{
+	"applypatch-msg", "pre-applypatch", "post-applypatch",
+	"pre-commit", "pre-merge-commit", "commit-msg", "post-commit",
+	"pre-rebase", "post-checkout", "post-merge", "pre-push", "pre-auto-gc",
+	"post-rewrite", "sendemail-validate", "post-index-change", "reference-transaction",
+	"pre-receive", "update", "post-receive", "post-update",
+	"p4-changelist", "p4-prepare-changelist", "p4-post-changelist", "p4-pre-submit",
+}
+
+// stdinHooks read their input from stdin, so it has to be kept for every
+// hook they forward to
+var stdinHooks = map[string]bool{
+	"pre-push":              true,
+	"post-rewrite":          true,
+	"reference-transaction": true,
+	"pre-receive":           true,
+	"post-receive":          true,
+}
+
+// previousHooksDir returns the hooks directory core.hooksPath named before
+// muse replaced it, or "" when there was none
+func previousHooksDir(state *GlobalState) string {
+	if state.ConfigKey != "core.hooksPath" || !state.HadPrevious || state.PreviousValue == state.ConfigValue {
+		return ""
+	}
+	// git expands a leading ~ in core.hooksPath, but the shell will not
+	// inside quotes
+	return config.ExpandHome(state.PreviousValue)
+}
+
+// generateChainScript returns shell that runs the repository's own name hook
+// and the one in previousDir, stopping at the first that fails. It sets
+// LOCAL_HOOK to the repository's hook.
+func generateChainScript(name, previousDir string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `# core.hooksPath bypasses .git/hooks and the hooks directory it replaced,
+# so run their %s hooks from here
+LOCAL_HOOK=""
+GIT_COMMON_DIR="$(git rev-parse --git-common-dir 2>/dev/null)"
+if [ -n "$GIT_COMMON_DIR" ]; then
+    LOCAL_HOOK="$GIT_COMMON_DIR/hooks/%s"
+fi
+`, name, name)
+	if previousDir != "" {
+		fmt.Fprintf(&b, `PREVIOUS_HOOK=%s
+if [ "$PREVIOUS_HOOK" -ef "$LOCAL_HOOK" ]; then
+    PREVIOUS_HOOK=""
+fi
+`, shell.Quote(filepath.Join(previousDir, name)))
+	} else {
+		b.WriteString("PREVIOUS_HOOK=\"\"\n")
+	}
+
+	run := `"$HOOK" "$@" || exit $?`
+	if stdinHooks[name] {
+		b.WriteString(`HOOK_INPUT="$(mktemp)" || exit 1
+trap 'rm -f "$HOOK_INPUT"' EXIT
+cat > "$HOOK_INPUT"
+`)
+		run = `"$HOOK" "$@" < "$HOOK_INPUT" || exit $?`
+	}
+	fmt.Fprintf(&b, `for HOOK in "$PREVIOUS_HOOK" "$LOCAL_HOOK"; do
+    if [ -n "$HOOK" ] && [ -x "$HOOK" ]; then
+        %s
+    fi
+done
+`, run)
+	return b.String()
+}
+
+// generateForwardingScript returns a hook that only runs the name hooks git
+// skips because of core.hooksPath
+func generateForwardingScript(name, previousDir string) string {
+	return hookStartMarker + "\n" + generateChainScript(name, previousDir) + hookEndMarker + "\n"
+}
+
+// generateGlobalPostCommitScript records the edit before running the other
+// post-commit hooks, so a failing one cannot skip it
+func generateGlobalPostCommitScript(binaryPath, binaryName, previousDir string) string {
+	return fmt.Sprintf(`%s
+# Record how the generated commit message was edited
+%s/%s post-commit
+%s%s
+`, hookStartMarker, binaryPath, binaryName, generateChainScript("post-commit", previousDir), hookEndMarker)
+}
+
+func generateGlobalHookScript(binaryPath, binaryName, previousDir string) string {
+	return fmt.Sprintf(`%s
+%s
+# A repository-local muse install has already generated the message
+if [ -n "$LOCAL_HOOK" ] && [ -x "$LOCAL_HOOK" ] && grep -q "%s" "$LOCAL_HOOK"; then
+    exit 0
+fi
+
+# Save the original arguments
+COMMIT_MSG_FILE="$1"
+COMMIT_SOURCE="$2"
+SHA1="$3"
+
+# Check if verbose flag is set
+if [ "$MUSE_VERBOSE" = "true" ]; then
+    VERBOSE_FLAG="--verbose"
+else
+    VERBOSE_FLAG=""
+fi
+
+# Execute the binary with the saved arguments
+%s/%s prepare-commit-msg $VERBOSE_FLAG "$COMMIT_MSG_FILE" "$COMMIT_SOURCE" "$SHA1"
+%s
+`, hookStartMarker, strings.TrimSuffix(generateChainScript("prepare-commit-msg", previousDir), "\n"), hookStartMarker, binaryPath, binaryName, hookEndMarker)
+}
@@ -0,0 +1,177 @@
+package hooks
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/klauern/muse/internal/git"
+	"github.com/klauern/muse/internal/gittest"
+)
+
+func TestInstallGlobal_RestoresPreviousConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+
+	if err := git.SetGlobalConfig("core.hooksPath", "/opt/team-hooks"); err != nil {
+		t.Fatalf("Failed to seed git config: %v", err)
+	}
+
+	installer := NewInstaller(nil)
+	if err := installer.InstallGlobal(HooksPathMode, false); err != nil {
+		t.Fatalf("InstallGlobal() failed: %v", err)
+	}
+
+	state, err := LoadGlobalState()
+	if err != nil || state == nil {
+		t.Fatalf("LoadGlobalState() = %v, %v; want recorded state", state, err)
+	}
+	if !state.HadPrevious || state.PreviousValue != "/opt/team-hooks" {
+		t.Errorf("Previous value not recorded: %+v", state)
+	}
+
+	value, _, _ := git.GetGlobalConfig("core.hooksPath")
+	if value != state.ConfigValue {
+		t.Errorf("core.hooksPath = %q, want %q", value, state.ConfigValue)
+	}
+
+	installed, err := HasMuseBlock(state.HookPath)
+	if err != nil || !installed {
+		t.Fatalf("Expected muse block in %s (err: %v)", state.HookPath, err)
+	}
+
+	if err := installer.UninstallGlobal(); err != nil {
+		t.Fatalf("UninstallGlobal() failed: %v", err)
+	}
+
+	value, _, _ = git.GetGlobalConfig("core.hooksPath")
+	if value != "/opt/team-hooks" {
+		t.Errorf("core.hooksPath = %q after uninstall, want %q", value, "/opt/team-hooks")
+	}
+	if _, err := os.Stat(state.HookPath); !os.IsNotExist(err) {
+		t.Error("Expected global hook to be removed")
+	}
+	if state, _ := LoadGlobalState(); state != nil {
+		t.Error("Expected global state to be cleared")
+	}
+}
+
+func TestInstallGlobal_UnsetsWhenNoPreviousConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+
+	installer := NewInstaller(nil)
+	if err := installer.InstallGlobal(TemplateDirMode, false); err != nil {
+		t.Fatalf("InstallGlobal() failed: %v", err)
+	}
+
+	if _, ok, _ := git.GetGlobalConfig("init.templateDir"); !ok {
+		t.Fatal("Expected init.templateDir to be set")
+	}
+
+	if err := installer.InstallGlobal(HooksPathMode, false); err == nil {
+		t.Error("Expected error when switching modes without uninstalling")
+	}
+
+	if err := installer.UninstallGlobal(); err != nil {
+		t.Fatalf("UninstallGlobal() failed: %v", err)
+	}
+
+	if _, ok, _ := git.GetGlobalConfig("init.templateDir"); ok {
+		t.Error("Expected init.templateDir to be unset after uninstall")
+	}
+}
+
+func TestInstallGlobal_ForwardsOtherHooks(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+
+	// Each hook appends its name and stdin to a log
+	logPath := filepath.Join(home, "log")
+	writeHook := func(path, label string) {
+		t.Helper()
+		script := "#!/bin/sh\necho \"" + label + " $*\" >> \"" + logPath + "\"\ncat >> \"" + logPath + "\"\n"
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	previousDir := filepath.Join(home, "team-hooks")
+	writeHook(filepath.Join(previousDir, "pre-push"), "team")
+	if err := git.SetGlobalConfig("core.hooksPath", previousDir); err != nil {
+		t.Fatalf("Failed to seed git config: %v", err)
+	}
+
+	repo := gittest.New(t).Dir
+	writeHook(filepath.Join(repo, ".git", "hooks", "pre-push"), "local")
+
+	installer := NewInstaller(nil)
+	if err := installer.InstallGlobal(HooksPathMode, false); err != nil {
+		t.Fatalf("InstallGlobal() failed: %v", err)
+	}
+	state, _ := LoadGlobalState()
+	hooksDir := filepath.Dir(state.HookPath)
+
+	cmd := exec.Command(filepath.Join(hooksDir, "pre-push"), "origin", "url")
+	cmd.Dir = repo
+	cmd.Stdin = strings.NewReader("refs/heads/main 1 refs/heads/main 0\n")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("pre-push stub failed: %v\n%s", err, output)
+	}
+	log, _ := os.ReadFile(logPath)
+	want := "team origin url\nrefs/heads/main 1 refs/heads/main 0\nlocal origin url\nrefs/heads/main 1 refs/heads/main 0\n"
+	if string(log) != want {
+		t.Errorf("hooks ran as:\n%s\nwant:\n%s", log, want)
+	}
+
+	// A hook missing from both places is skipped
+	cmd = exec.Command(filepath.Join(hooksDir, "commit-msg"), "MSG")
+	cmd.Dir = repo
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Errorf("commit-msg stub failed: %v\n%s", err, output)
+	}
+
+	if err := installer.UninstallGlobal(); err != nil {
+		t.Fatalf("UninstallGlobal() failed: %v", err)
+	}
+	if entries, _ := os.ReadDir(hooksDir); len(entries) != 0 {
+		t.Errorf("hooks left after uninstall: %v", entries)
+	}
+}
+
+func TestInstallGlobal_PostCommit(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+
+	repo := gittest.New(t).Dir
+	t.Chdir(repo)
+
+	installer := NewInstaller(nil)
+	if err := installer.InstallGlobal(HooksPathMode, true); err != nil {
+		t.Fatalf("InstallGlobal() failed: %v", err)
+	}
+	if !PostCommitInstalled() {
+		t.Error("PostCommitInstalled() = false after a global install with the post-commit hook")
+	}
+
+	// Re-installing without it leaves only the forwarding stub
+	if err := installer.InstallGlobal(HooksPathMode, false); err != nil {
+		t.Fatalf("InstallGlobal() failed: %v", err)
+	}
+	if PostCommitInstalled() {
+		t.Error("PostCommitInstalled() = true after re-installing without the post-commit hook")
+	}
+
+	if err := installer.UninstallGlobal(); err != nil {
+		t.Fatalf("UninstallGlobal() failed: %v", err)
+	}
+}
@@ -9,6 +9,8 @@ import (
 	"strings"
 
 	"github.com/klauern/muse/config"
+	"github.com/klauern/muse/internal/fileops"
+	"github.com/klauern/muse/internal/git"
 )
 
 type Installer struct {
@@ -22,8 +24,34 @@ func NewInstaller(config *config.Config) *Installer {
 const (
 	hookStartMarker = "# BEGIN MUSE HOOK"
 	hookEndMarker   = "# END MUSE HOOK"
+
+	// hookBackupSuffix is appended to the hook path to store the hook as it
+	// was before muse first touched it.
+	hookBackupSuffix = ".muse-backup"
 )
 
+var hookBlockPattern = regexp.MustCompile(fmt.Sprintf("(?s)%s.*?%s\\n?", regexp.QuoteMeta(hookStartMarker), regexp.QuoteMeta(hookEndMarker)))
+
+// stripHookContent removes the muse block from hook content. The returned
+// bool reports whether anything besides a shebang is left behind.
+func stripHookContent(content string) (string, bool) {
+	stripped := strings.TrimSpace(hookBlockPattern.ReplaceAllString(content, ""))
+
+	remaining := stripped
+	if strings.HasPrefix(remaining, "#!") {
+		if idx := strings.Index(remaining, "\n"); idx >= 0 {
+			remaining = remaining[idx+1:]
+		} else {
+			remaining = ""
+		}
+	}
+
+	if strings.TrimSpace(remaining) == "" {
+		return "", false
+	}
+	return stripped + "\n", true
+}
+
 func addOrUpdateHookContent(hookPath, hookContent string) error {
 	var existingContent []byte
 	var err error
@@ -47,8 +75,7 @@ func addOrUpdateHookContent(hookPath, hookContent string) error {
 	}
 
 	// Remove any existing MUSE hook content
-	re := regexp.MustCompile(fmt.Sprintf("(?s)%s.*?%s", regexp.QuoteMeta(hookStartMarker), regexp.QuoteMeta(hookEndMarker)))
-	updatedContent := re.ReplaceAllString(string(existingContent), "")
+	updatedContent := hookBlockPattern.ReplaceAllString(string(existingContent), "")
 
 	// Case 1: File already has content (e.g., lefthook)
 	if len(strings.TrimSpace(updatedContent)) > 0 {
@@ -83,6 +110,22 @@ fi
 `, hookStartMarker, binaryPath, binaryName, hookEndMarker)
 }
 
+func generatePreCommitScript(binaryPath, binaryName string) string {
+	return fmt.Sprintf(`%s
+# Review the staged changes; fails on issues at or above review.fail_on
+%s/%s review --hook || exit $?
+%s
+`, hookStartMarker, binaryPath, binaryName, hookEndMarker)
+}
+
+func generatePostCommitScript(binaryPath, binaryName string) string {
+	return fmt.Sprintf(`%s
+# Record how the generated commit message was edited
+%s/%s post-commit
+%s
+`, hookStartMarker, binaryPath, binaryName, hookEndMarker)
+}
+
 func getExecutableInfo() (string, string, string, error) {
 	exePath, err := os.Executable()
 	if err != nil {
@@ -95,54 +138,234 @@ func getExecutableInfo() (string, string, string, error) {
 }
 
 func (i *Installer) Install() error {
-	gitDir, err := FindGitDir()
+	_, binaryPath, binaryName, err := getExecutableInfo()
 	if err != nil {
-		slog.Error("Failed to find .git directory", "error", err)
-		return fmt.Errorf("failed to find .git directory: %w", err)
+		slog.Error("Failed to get executable info", "error", err)
+		return fmt.Errorf("failed to get executable info: %w", err)
 	}
+	return installHook("prepare-commit-msg", generateHookScript(binaryPath, binaryName))
+}
 
-	hookPath := filepath.Join(gitDir, "hooks", "prepare-commit-msg")
+// InstallPreCommit adds muse review to the pre-commit hook, so commits are
+// blocked by issues at or above review.fail_on
+func (i *Installer) InstallPreCommit() error {
+	_, binaryPath, binaryName, err := getExecutableInfo()
+	if err != nil {
+		slog.Error("Failed to get executable info", "error", err)
+		return fmt.Errorf("failed to get executable info: %w", err)
+	}
+	return installHook("pre-commit", generatePreCommitScript(binaryPath, binaryName))
+}
 
+// InstallPostCommit adds muse post-commit to the post-commit hook, so edits
+// to generated messages are recorded
+func (i *Installer) InstallPostCommit() error {
 	_, binaryPath, binaryName, err := getExecutableInfo()
 	if err != nil {
 		slog.Error("Failed to get executable info", "error", err)
 		return fmt.Errorf("failed to get executable info: %w", err)
 	}
+	return installHook("post-commit", generatePostCommitScript(binaryPath, binaryName))
+}
+
+func installHook(name, hookContent string) error {
+	hooksDir, err := HooksDir()
+	if err != nil {
+		slog.Error("Failed to find hooks directory", "error", err)
+		return fmt.Errorf("failed to find hooks directory: %w", err)
+	}
 
-	hookContent := generateHookScript(binaryPath, binaryName)
+	hookPath := filepath.Join(hooksDir, name)
 
-	fmt.Printf("Installing prepare-commit-msg hook... at %s\n", hookPath)
+	if err := backupHook(hookPath); err != nil {
+		slog.Error("Failed to back up existing hook", "error", err)
+		return fmt.Errorf("failed to back up existing hook: %w", err)
+	}
+
+	fmt.Printf("Installing %s hook... at %s\n", name, hookPath)
 	if err := addOrUpdateHookContent(hookPath, hookContent); err != nil {
 		slog.Error("Failed to add or update hook content", "error", err)
 		return fmt.Errorf("failed to add or update hook content: %w", err)
 	}
 
-	fmt.Println("prepare-commit-msg hook installed successfully")
+	fmt.Printf("%s hook installed successfully\n", name)
 	return nil
 }
 
+// museHooks are the hooks muse can install in a repository
+var museHooks = []string{"prepare-commit-msg", "pre-commit", "post-commit"}
+
+// Uninstall removes the muse block from every hook muse installs. Any other
+// content in a hook (e.g. lefthook) is left in place, and the file is only
+// deleted when nothing else remains.
 func (i *Installer) Uninstall() error {
-	gitDir, err := FindGitDir()
+	removedAny := false
+	for _, name := range museHooks {
+		removed, err := uninstallHook(name)
+		if err != nil {
+			return err
+		}
+		removedAny = removedAny || removed
+	}
+	if !removedAny {
+		slog.Info("No hook contains muse")
+	}
+	return nil
+}
+
+// UninstallPreCommit removes muse review from the pre-commit hook
+func (i *Installer) UninstallPreCommit() error {
+	return uninstallOnly("pre-commit")
+}
+
+// UninstallPostCommit removes muse post-commit from the post-commit hook
+func (i *Installer) UninstallPostCommit() error {
+	return uninstallOnly("post-commit")
+}
+
+func uninstallOnly(name string) error {
+	removed, err := uninstallHook(name)
+	if err == nil && !removed {
+		slog.Info("Hook does not contain muse", "hook", name)
+	}
+	return err
+}
+
+// uninstallHook removes the muse block from the named hook and reports
+// whether there was one
+func uninstallHook(name string) (bool, error) {
+	hooksDir, err := HooksDir()
 	if err != nil {
-		slog.Error("Failed to find .git directory", "error", err)
-		return fmt.Errorf("failed to find .git directory: %w", err)
+		slog.Error("Failed to find hooks directory", "error", err)
+		return false, fmt.Errorf("failed to find hooks directory: %w", err)
 	}
 
-	hookPath := filepath.Join(gitDir, "hooks", "prepare-commit-msg")
+	hookPath := filepath.Join(hooksDir, name)
+
+	removed, err := removeHookContent(hookPath)
+	if err != nil {
+		slog.Error("Failed to remove hook", "error", err)
+		return false, fmt.Errorf("failed to remove hook: %w", err)
+	}
+
+	if !removed {
+		return false, nil
+	}
+
+	fmt.Printf("%s hook uninstalled successfully\n", name)
+	if _, err := os.Stat(hookPath + hookBackupSuffix); err == nil {
+		fmt.Printf("The previous hook is kept at %s; run 'muse uninstall --restore' to restore it\n", hookPath+hookBackupSuffix)
+	}
+	return true, nil
+}
+
+// Restore replaces every muse hook that has a backup with the copy saved
+// when muse was first installed, then removes the backups.
+func (i *Installer) Restore() error {
+	hooksDir, err := HooksDir()
+	if err != nil {
+		slog.Error("Failed to find hooks directory", "error", err)
+		return fmt.Errorf("failed to find hooks directory: %w", err)
+	}
+
+	restored := false
+	for _, name := range museHooks {
+		hookPath := filepath.Join(hooksDir, name)
+		backupPath := hookPath + hookBackupSuffix
+		if _, err := os.Stat(backupPath); os.IsNotExist(err) {
+			continue
+		}
+
+		if err := restoreHook(hookPath, backupPath); err != nil {
+			slog.Error("Failed to restore hook", "hook", name, "error", err)
+			return fmt.Errorf("failed to restore %s hook: %w", name, err)
+		}
+		fmt.Printf("Restored previous %s hook from %s\n", name, backupPath)
+		restored = true
+	}
+
+	if !restored {
+		return fmt.Errorf("no hook backup found in %s", hooksDir)
+	}
+	return nil
+}
+
+// backupHook copies an existing hook that muse has not modified yet, so the
+// original can be restored later. Hooks that already contain the muse block
+// and hooks that already have a backup are left alone.
+func backupHook(hookPath string) error {
+	content, err := os.ReadFile(hookPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read hook file: %w", err)
+	}
 
-	if _, err := os.Stat(hookPath); err == nil {
+	if strings.Contains(string(content), hookStartMarker) {
+		return nil
+	}
+
+	backupPath := hookPath + hookBackupSuffix
+	if _, err := os.Stat(backupPath); err == nil {
+		return nil
+	}
+
+	if err := fileops.CopyFile(hookPath, backupPath); err != nil {
+		return err
+	}
+	slog.Debug("Backed up existing hook", "path", backupPath)
+	return nil
+}
+
+// removeHookContent strips the muse block from the hook at hookPath,
+// deleting the file if only a shebang would remain. It reports whether a
+// muse block was found.
+func removeHookContent(hookPath string) (bool, error) {
+	content, err := os.ReadFile(hookPath)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read hook file: %w", err)
+	}
+
+	if !strings.Contains(string(content), hookStartMarker) {
+		return false, nil
+	}
+
+	remaining, keep := stripHookContent(string(content))
+	if !keep {
 		if err := os.Remove(hookPath); err != nil {
-			slog.Error("Failed to remove hook", "error", err)
-			return fmt.Errorf("failed to remove hook: %w", err)
+			return false, fmt.Errorf("failed to delete hook file: %w", err)
 		}
-		fmt.Println("prepare-commit-msg hook uninstalled successfully")
-	} else if os.IsNotExist(err) {
-		slog.Info("prepare-commit-msg hook does not exist")
-	} else {
-		slog.Error("Failed to check hook existence", "error", err)
-		return fmt.Errorf("failed to check hook existence: %w", err)
+		return true, nil
 	}
 
+	info, err := os.Stat(hookPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to stat hook file: %w", err)
+	}
+
+	if err := fileops.AtomicWriteFile(hookPath, []byte(remaining), info.Mode().Perm()); err != nil {
+		return false, fmt.Errorf("failed to write hook file: %w", err)
+	}
+	return true, nil
+}
+
+// restoreHook copies backupPath over hookPath and removes the backup.
+func restoreHook(hookPath, backupPath string) error {
+	if _, err := os.Stat(backupPath); os.IsNotExist(err) {
+		return fmt.Errorf("no backup found at %s", backupPath)
+	}
+
+	if err := fileops.CopyFile(backupPath, hookPath); err != nil {
+		return err
+	}
+
+	if err := os.Remove(backupPath); err != nil {
+		return fmt.Errorf("failed to remove backup: %w", err)
+	}
 	return nil
 }
 
@@ -164,3 +387,133 @@ func FindGitDir() (string, error) {
 		dir = parent
 	}
 }
+
+// HooksDir returns the directory git runs the current repository's hooks
+// from, which core.hooksPath can move out of .git/hooks. When it points at
+// the muse global hooks, which run .git/hooks themselves, .git/hooks is
+// returned so that installing in one repository leaves the others alone.
+func HooksDir() (string, error) {
+	_, local, err := hooksDirs()
+	return local, err
+}
+
+// hooksDirs returns the directory git runs hooks from and the directory
+// local installs go to. They differ only when git runs the muse global
+// hooks, which forward to .git/hooks.
+func hooksDirs() (string, string, error) {
+	ops, err := git.NewGitOperations("")
+	if err != nil {
+		return "", "", err
+	}
+	hooksDir, err := ops.GetHooksDir()
+	if err != nil {
+		return "", "", err
+	}
+
+	_, _, globalDir, err := globalTarget(HooksPathMode)
+	if err == nil && sameDir(hooksDir, globalDir) {
+		commonDir, err := ops.GetCommonDir()
+		if err != nil {
+			return "", "", err
+		}
+		return hooksDir, filepath.Join(commonDir, "hooks"), nil
+	}
+	return hooksDir, hooksDir, nil
+}
+
+// PostCommitInstalled reports whether git runs muse post-commit after
+// commits in the current repository
+func PostCommitInstalled() bool {
+	active, local, err := hooksDirs()
+	if err != nil {
+		return false
+	}
+	for _, dir := range []string{active, local} {
+		content, err := os.ReadFile(filepath.Join(dir, "post-commit"))
+		if err == nil && postCommitPattern.MatchString(hookBlockPattern.FindString(string(content))) {
+			return true
+		}
+	}
+	return false
+}
+
+// postCommitPattern matches the muse post-commit call, which forwarding
+// stubs lack
+var postCommitPattern = regexp.MustCompile(`(?m)^\S+ post-commit$`)
+
+// sameDir reports whether a and b name the same directory
+func sameDir(a, b string) bool {
+	if filepath.Clean(a) == filepath.Clean(b) {
+		return true
+	}
+	statA, errA := os.Stat(a)
+	statB, errB := os.Stat(b)
+	return errA == nil && errB == nil && os.SameFile(statA, statB)
+}
+
+// LocalHookPath returns the prepare-commit-msg path in the current
+// repository's hooks directory, as returned by HooksDir
+func LocalHookPath() (string, error) {
+	hooksDir, err := HooksDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(hooksDir, "prepare-commit-msg"), nil
+}
+
+// HasMuseBlock reports whether the hook file at hookPath contains the muse
+// block. A missing file is not an error.
+func HasMuseBlock(hookPath string) (bool, error) {
+	content, err := os.ReadFile(hookPath)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read hook file: %w", err)
+	}
+	return strings.Contains(string(content), hookStartMarker), nil
+}
+
+// HookInfo describes a hook file and the muse block inside it
+type HookInfo struct {
+	Path       string
+	Exists     bool
+	Executable bool
+	Installed  bool
+	// BinaryPath is the muse executable the hook invokes, if installed
+	BinaryPath string
+}
+
+var hookBinaryPattern = regexp.MustCompile(`(?m)^(\S+) prepare-commit-msg `)
+
+// InspectHook reads the hook at hookPath and reports whether muse is
+// installed in it and which binary it calls.
+func InspectHook(hookPath string) (*HookInfo, error) {
+	info := &HookInfo{Path: hookPath}
+
+	stat, err := os.Stat(hookPath)
+	if os.IsNotExist(err) {
+		return info, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat hook file: %w", err)
+	}
+	info.Exists = true
+	info.Executable = stat.Mode().Perm()&0o111 != 0
+
+	content, err := os.ReadFile(hookPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read hook file: %w", err)
+	}
+
+	block := hookBlockPattern.FindString(string(content))
+	if block == "" {
+		return info, nil
+	}
+	info.Installed = true
+
+	if match := hookBinaryPattern.FindStringSubmatch(block); match != nil {
+		info.BinaryPath = match[1]
+	}
+	return info, nil
+}
@@ -0,0 +1,350 @@
+package hooks
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/klauern/muse/internal/gittest"
+)
+
+func TestStripHookContent(t *testing.T) {
+	block := generateHookScript("/usr/local/bin", "muse")
+
+	tests := []struct {
+		name     string
+		content  string
+		wantKeep bool
+		want     string
+	}{
+		{
+			name:     "only muse block",
+			content:  "#!/bin/sh\n\n" + block,
+			wantKeep: false,
+		},
+		{
+			name:     "muse block after other hook",
+			content:  "#!/bin/sh\n\nlefthook run prepare-commit-msg \"$@\"\n\n" + block,
+			wantKeep: true,
+			want:     "#!/bin/sh\n\nlefthook run prepare-commit-msg \"$@\"\n",
+		},
+		{
+			name:     "muse block between other content",
+			content:  "#!/bin/sh\necho before\n" + block + "echo after\n",
+			wantKeep: true,
+			want:     "#!/bin/sh\necho before\necho after\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, keep := stripHookContent(tt.content)
+			if keep != tt.wantKeep {
+				t.Fatalf("stripHookContent() keep = %v, want %v", keep, tt.wantKeep)
+			}
+			if keep && got != tt.want {
+				t.Errorf("stripHookContent() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRemoveHookContent_PreservesOtherHooks(t *testing.T) {
+	hookPath := filepath.Join(t.TempDir(), "prepare-commit-msg")
+	existing := "#!/bin/sh\n\nlefthook run prepare-commit-msg \"$@\"\n"
+
+	if err := os.WriteFile(hookPath, []byte(existing), 0o755); err != nil {
+		t.Fatalf("Failed to write hook: %v", err)
+	}
+	if err := backupHook(hookPath); err != nil {
+		t.Fatalf("backupHook() failed: %v", err)
+	}
+	if err := addOrUpdateHookContent(hookPath, generateHookScript("/usr/local/bin", "muse")); err != nil {
+		t.Fatalf("addOrUpdateHookContent() failed: %v", err)
+	}
+
+	removed, err := removeHookContent(hookPath)
+	if err != nil {
+		t.Fatalf("removeHookContent() failed: %v", err)
+	}
+	if !removed {
+		t.Fatal("Expected muse block to be removed")
+	}
+
+	content, err := os.ReadFile(hookPath)
+	if err != nil {
+		t.Fatalf("Hook file should still exist: %v", err)
+	}
+	if strings.Contains(string(content), hookStartMarker) {
+		t.Errorf("Hook still contains muse block: %q", content)
+	}
+	if !strings.Contains(string(content), "lefthook run") {
+		t.Errorf("Hook lost existing content: %q", content)
+	}
+
+	info, err := os.Stat(hookPath)
+	if err != nil {
+		t.Fatalf("Failed to stat hook: %v", err)
+	}
+	if info.Mode().Perm() != 0o755 {
+		t.Errorf("Hook permissions changed to %o", info.Mode().Perm())
+	}
+}
+
+func TestRemoveHookContent_DeletesMuseOnlyHook(t *testing.T) {
+	hookPath := filepath.Join(t.TempDir(), "prepare-commit-msg")
+
+	if err := addOrUpdateHookContent(hookPath, generateHookScript("/usr/local/bin", "muse")); err != nil {
+		t.Fatalf("addOrUpdateHookContent() failed: %v", err)
+	}
+
+	removed, err := removeHookContent(hookPath)
+	if err != nil {
+		t.Fatalf("removeHookContent() failed: %v", err)
+	}
+	if !removed {
+		t.Fatal("Expected muse block to be removed")
+	}
+	if _, err := os.Stat(hookPath); !os.IsNotExist(err) {
+		t.Errorf("Expected hook file to be deleted, stat err = %v", err)
+	}
+}
+
+func TestRemoveHookContent_IgnoresForeignHook(t *testing.T) {
+	hookPath := filepath.Join(t.TempDir(), "prepare-commit-msg")
+	existing := "#!/bin/sh\necho custom\n"
+
+	if err := os.WriteFile(hookPath, []byte(existing), 0o755); err != nil {
+		t.Fatalf("Failed to write hook: %v", err)
+	}
+
+	removed, err := removeHookContent(hookPath)
+	if err != nil {
+		t.Fatalf("removeHookContent() failed: %v", err)
+	}
+	if removed {
+		t.Error("Expected foreign hook to be left alone")
+	}
+
+	content, _ := os.ReadFile(hookPath)
+	if string(content) != existing {
+		t.Errorf("Foreign hook was modified: %q", content)
+	}
+}
+
+func TestRestoreHook(t *testing.T) {
+	hookPath := filepath.Join(t.TempDir(), "prepare-commit-msg")
+	original := "#!/bin/sh\necho original\n"
+
+	if err := os.WriteFile(hookPath, []byte(original), 0o755); err != nil {
+		t.Fatalf("Failed to write hook: %v", err)
+	}
+	if err := backupHook(hookPath); err != nil {
+		t.Fatalf("backupHook() failed: %v", err)
+	}
+	if err := addOrUpdateHookContent(hookPath, generateHookScript("/usr/local/bin", "muse")); err != nil {
+		t.Fatalf("addOrUpdateHookContent() failed: %v", err)
+	}
+
+	// A second install must not overwrite the pristine backup
+	if err := backupHook(hookPath); err != nil {
+		t.Fatalf("backupHook() failed: %v", err)
+	}
+
+	if err := restoreHook(hookPath, hookPath+hookBackupSuffix); err != nil {
+		t.Fatalf("restoreHook() failed: %v", err)
+	}
+
+	content, err := os.ReadFile(hookPath)
+	if err != nil {
+		t.Fatalf("Failed to read restored hook: %v", err)
+	}
+	if string(content) != original {
+		t.Errorf("Restored hook = %q, want %q", content, original)
+	}
+	if _, err := os.Stat(hookPath + hookBackupSuffix); !os.IsNotExist(err) {
+		t.Error("Expected backup to be removed after restore")
+	}
+}
+
+func TestRestoreHook_NoBackup(t *testing.T) {
+	hookPath := filepath.Join(t.TempDir(), "prepare-commit-msg")
+
+	if err := restoreHook(hookPath, hookPath+hookBackupSuffix); err == nil {
+		t.Error("Expected error when no backup exists")
+	}
+}
+
+func TestInspectHook(t *testing.T) {
+	hookPath := filepath.Join(t.TempDir(), "prepare-commit-msg")
+
+	info, err := InspectHook(hookPath)
+	if err != nil {
+		t.Fatalf("InspectHook() failed: %v", err)
+	}
+	if info.Exists || info.Installed {
+		t.Errorf("Expected missing hook, got %+v", info)
+	}
+
+	if err := addOrUpdateHookContent(hookPath, generateHookScript("/usr/local/bin", "muse")); err != nil {
+		t.Fatalf("addOrUpdateHookContent() failed: %v", err)
+	}
+
+	info, err = InspectHook(hookPath)
+	if err != nil {
+		t.Fatalf("InspectHook() failed: %v", err)
+	}
+	if !info.Exists || !info.Executable || !info.Installed {
+		t.Errorf("Expected installed executable hook, got %+v", info)
+	}
+	if info.BinaryPath != "/usr/local/bin/muse" {
+		t.Errorf("BinaryPath = %q, want %q", info.BinaryPath, "/usr/local/bin/muse")
+	}
+}
+
+func TestPreCommitScript_PropagatesReviewStatus(t *testing.T) {
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "muse")
+	if err := os.WriteFile(binary, []byte("#!/bin/sh\nexit $REVIEW_STATUS\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	hookPath := filepath.Join(dir, "hooks", "pre-commit")
+	if err := os.MkdirAll(filepath.Dir(hookPath), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(hookPath, []byte("#!/bin/sh\n\ntrue\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := addOrUpdateHookContent(hookPath, generatePreCommitScript(dir, "muse")); err != nil {
+		t.Fatalf("addOrUpdateHookContent() failed: %v", err)
+	}
+
+	for status, wantErr := range map[string]bool{"0": false, "1": true} {
+		cmd := exec.Command(hookPath)
+		cmd.Env = append(os.Environ(), "REVIEW_STATUS="+status)
+		if err := cmd.Run(); (err != nil) != wantErr {
+			t.Errorf("hook with review status %s: error = %v, want error %v", status, err, wantErr)
+		}
+	}
+
+	removed, err := removeHookContent(hookPath)
+	if err != nil || !removed {
+		t.Fatalf("removeHookContent() = %v, %v", removed, err)
+	}
+	content, _ := os.ReadFile(hookPath)
+	if strings.Contains(string(content), "review") {
+		t.Errorf("hook still runs review after removal:\n%s", content)
+	}
+}
+
+func TestHooksDir_FollowsHooksPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+
+	r := gittest.New(t)
+	repo := r.Dir
+	if err := os.Mkdir(filepath.Join(repo, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(filepath.Join(repo, "sub"))
+
+	tests := []struct {
+		hooksPath string
+		want      string
+	}{
+		{"", filepath.Join(repo, ".git", "hooks")},
+		{".githooks", filepath.Join(repo, ".githooks")},
+		// The muse global hooks run .git/hooks, so local installs go there
+		{filepath.Join(home, ".config", "muse", "hooks"), filepath.Join(repo, ".git", "hooks")},
+	}
+	for _, tt := range tests {
+		if tt.hooksPath != "" {
+			r.Git("config", "core.hooksPath", tt.hooksPath)
+		}
+		got, err := HooksDir()
+		if err != nil {
+			t.Fatalf("HooksDir() with core.hooksPath %q failed: %v", tt.hooksPath, err)
+		}
+		if !sameDir(got, tt.want) {
+			t.Errorf("HooksDir() with core.hooksPath %q = %q, want %q", tt.hooksPath, got, tt.want)
+		}
+	}
+}
+
+func TestUninstall_RemovesEveryMuseHook(t *testing.T) {
+	repo := gittest.New(t).Dir
+	t.Chdir(repo)
+
+	installer := NewInstaller(nil)
+	for _, install := range []func() error{installer.Install, installer.InstallPreCommit, installer.InstallPostCommit} {
+		if err := install(); err != nil {
+			t.Fatalf("install failed: %v", err)
+		}
+	}
+	if err := installer.Uninstall(); err != nil {
+		t.Fatalf("Uninstall() failed: %v", err)
+	}
+	for _, name := range museHooks {
+		if _, err := os.Stat(filepath.Join(repo, ".git", "hooks", name)); !os.IsNotExist(err) {
+			t.Errorf("%s hook left after Uninstall()", name)
+		}
+	}
+}
+
+func TestRestore_RestoresEveryBackedUpHook(t *testing.T) {
+	repo := gittest.New(t).Dir
+	t.Chdir(repo)
+
+	installer := NewInstaller(nil)
+	if err := installer.Restore(); err == nil {
+		t.Error("Restore() without backups succeeded")
+	}
+
+	hookPath := filepath.Join(repo, ".git", "hooks", "pre-commit")
+	original := "#!/bin/sh\nmake lint\n"
+	if err := os.WriteFile(hookPath, []byte(original), 0o755); err != nil {
+		t.Fatalf("Failed to write hook: %v", err)
+	}
+	if err := installer.InstallPreCommit(); err != nil {
+		t.Fatalf("InstallPreCommit() failed: %v", err)
+	}
+
+	if err := installer.Restore(); err != nil {
+		t.Fatalf("Restore() failed: %v", err)
+	}
+	content, err := os.ReadFile(hookPath)
+	if err != nil || string(content) != original {
+		t.Errorf("pre-commit hook after Restore() = %q, %v; want the original", content, err)
+	}
+	if _, err := os.Stat(hookPath + hookBackupSuffix); !os.IsNotExist(err) {
+		t.Error("backup left after Restore()")
+	}
+}
+
+func TestPostCommitScript_RunsPostCommit(t *testing.T) {
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "muse")
+	marker := filepath.Join(dir, "args")
+	if err := os.WriteFile(binary, []byte("#!/bin/sh\necho \"$@\" > \""+marker+"\"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	hookPath := filepath.Join(dir, "hooks", "post-commit")
+	if err := addOrUpdateHookContent(hookPath, generatePostCommitScript(dir, "muse")); err != nil {
+		t.Fatalf("addOrUpdateHookContent() failed: %v", err)
+	}
+	if output, err := exec.Command(hookPath).CombinedOutput(); err != nil {
+		t.Fatalf("hook failed: %v\n%s", err, output)
+	}
+	args, err := os.ReadFile(marker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(string(args)) != "post-commit" {
+		t.Errorf("hook ran muse with %q, want post-commit", args)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/klauern/muse/config"
+	"github.com/klauern/muse/internal/daemon"
 	"github.com/klauern/muse/internal/fileops"
 	"github.com/klauern/muse/internal/git"
 	"github.com/klauern/muse/internal/userinput"
@@ -64,7 +65,7 @@ func (h *LLMHook) Run(commitMsgFile string, commitSource string, sha1 string) er
 
 		if !accepted {
 			slog.Info("User rejected the generated commit message")
-			return fmt.Errorf("user rejected the generated commit message")
+			return fmt.Errorf("generated commit message %w", userinput.ErrRejected)
 		}
 	}
 
@@ -106,11 +107,11 @@ func getGitDiff() (string, error) {
 	return diff, nil
 }
 
+// NewHook returns a hook that generates through a running 'muse serve'
+// daemon, or in-process when none is running
 func NewHook(cfg *config.Config) (PrepareCommitMsgHook, error) {
-	generator, err := llm.NewCommitMessageGenerator(cfg)
-	if err != nil {
-		slog.Error("Failed to create commit message generator", "error", err)
-		return nil, fmt.Errorf("failed to create commit message generator: %w", err)
+	if cfg == nil {
+		return nil, fmt.Errorf("config is nil")
 	}
-	return &LLMHook{Generator: generator, Config: cfg}, nil
+	return &LLMHook{Generator: daemon.NewGenerator(cfg), Config: cfg}, nil
 }
@@ -0,0 +1,351 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/klauern/muse/config"
+	"github.com/klauern/muse/internal/fileops"
+)
+
+// keyVersion is mixed into every key so a change to the entry format or key
+// derivation invalidates old entries
+const keyVersion = "v2"
+
+const (
+	entrySuffix   = ".json"
+	pendingSuffix = ".pending"
+)
+
+// PendingTimeout is how long a pending marker is honoured. Older markers are
+// left behind by a generation that crashed and are ignored.
+const PendingTimeout = time.Minute
+
+// pendingPoll is how often WaitPending checks for the entry
+const pendingPoll = 100 * time.Millisecond
+
+// Entry is a cached generated message
+type Entry struct {
+	Message   string    `json:"message"`
+	Provider  string    `json:"provider,omitempty"`
+	Model     string    `json:"model,omitempty"`
+	Style     string    `json:"style,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// KeyParts are the inputs that determine a generated message
+type KeyParts struct {
+	Diff     string
+	Style    string
+	Template string
+	Provider string
+	Model    string
+	// Examples are the edited messages shown with the prompt, encoded by
+	// the caller
+	Examples string
+}
+
+// Key returns the cache key for parts. The diff is normalized first, so
+// line-ending and trailing-whitespace differences do not cause a miss.
+func Key(parts KeyParts) string {
+	h := sha256.New()
+	for _, field := range []string{
+		keyVersion,
+		hash(NormalizeDiff(parts.Diff)),
+		parts.Style,
+		hash(parts.Template),
+		parts.Provider,
+		parts.Model,
+		hash(parts.Examples),
+	} {
+		h.Write([]byte(field))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// NormalizeDiff converts CRLF line endings, strips trailing whitespace from
+// every line and trims leading and trailing blank lines
+func NormalizeDiff(diff string) string {
+	lines := strings.Split(strings.ReplaceAll(diff, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
+
+func hash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// DefaultDir returns the muse directory under the user's XDG cache home
+func DefaultDir() (string, error) {
+	cacheDir := os.Getenv("XDG_CACHE_HOME")
+	if cacheDir == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get user home directory: %w", err)
+		}
+		cacheDir = filepath.Join(homeDir, ".cache")
+	}
+	return filepath.Join(cacheDir, "muse", "responses"), nil
+}
+
+// Cache stores generated messages as one JSON file per key
+type Cache struct {
+	Dir string
+	// TTL is how long an entry is valid; zero keeps entries until evicted
+	TTL time.Duration
+	// MaxSize limits the total size of the entries in bytes; zero disables
+	// the limit
+	MaxSize int64
+
+	// now is replaced in tests
+	now func() time.Time
+}
+
+// New returns a cache in dir
+func New(dir string, ttl time.Duration, maxSize int64) *Cache {
+	return &Cache{Dir: dir, TTL: ttl, MaxSize: maxSize}
+}
+
+// FromConfig returns a cache in DefaultDir with the limits from cfg
+func FromConfig(cfg config.CacheConfig) (*Cache, error) {
+	dir, err := DefaultDir()
+	if err != nil {
+		return nil, err
+	}
+
+	var ttl time.Duration
+	if cfg.TTL != "" {
+		ttl, err = time.ParseDuration(cfg.TTL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cache.ttl %q: %w", cfg.TTL, err)
+		}
+	}
+	return New(dir, ttl, int64(cfg.MaxSizeMB)*1024*1024), nil
+}
+
+// Get returns the entry stored under key. Expired and unreadable entries are
+// removed and reported as a miss.
+func (c *Cache) Get(key string) (Entry, bool) {
+	path := c.path(key)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			slog.Debug("Failed to read cache entry", "path", path, "error", err)
+		}
+		return Entry{}, false
+	}
+
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Message == "" {
+		slog.Debug("Removing unreadable cache entry", "path", path, "error", err)
+		_ = os.Remove(path)
+		return Entry{}, false
+	}
+	if c.expired(entry.CreatedAt) {
+		slog.Debug("Removing expired cache entry", "path", path, "created_at", entry.CreatedAt)
+		_ = os.Remove(path)
+		return Entry{}, false
+	}
+	return entry, true
+}
+
+// Put stores entry under key and evicts entries beyond the size limit
+func (c *Cache) Put(key string, entry Entry) error {
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = c.clock()
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry: %w", err)
+	}
+
+	if err := os.MkdirAll(c.Dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	if err := fileops.AtomicWriteFile(c.path(key), data, 0o600); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+
+	return c.Prune()
+}
+
+// MarkPending records that a message for key is being generated, so other
+// processes can wait for it instead of generating it again. The returned
+// function removes the marker.
+func (c *Cache) MarkPending(key string) func() {
+	path := c.pendingPath(key)
+	if err := os.MkdirAll(c.Dir, 0o700); err != nil {
+		slog.Debug("Failed to create cache directory", "error", err)
+		return func() {}
+	}
+	if err := os.WriteFile(path, []byte(strconv.Itoa(os.Getpid())), 0o600); err != nil {
+		slog.Debug("Failed to write pending marker", "path", path, "error", err)
+		return func() {}
+	}
+	return func() { _ = os.Remove(path) }
+}
+
+// WaitPending waits for a message that another process is generating for
+// key. It returns at once when no fresh pending marker exists, and gives up
+// once the marker is removed, PendingTimeout passes or ctx is done.
+func (c *Cache) WaitPending(ctx context.Context, key string) (Entry, bool) {
+	path := c.pendingPath(key)
+	info, err := os.Stat(path)
+	if err != nil || c.clock().Sub(info.ModTime()) > PendingTimeout {
+		return Entry{}, false
+	}
+
+	slog.Debug("Waiting for a message that is being generated", "key", key)
+	deadline := time.NewTimer(PendingTimeout - c.clock().Sub(info.ModTime()))
+	defer deadline.Stop()
+	ticker := time.NewTicker(pendingPoll)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return Entry{}, false
+		case <-deadline.C:
+			return c.Get(key)
+		case <-ticker.C:
+			if entry, ok := c.Get(key); ok {
+				return entry, true
+			}
+			if _, err := os.Stat(path); err != nil {
+				// The other process finished without storing a message
+				return c.Get(key)
+			}
+		}
+	}
+}
+
+// Prune removes expired entries, then the oldest entries until the cache
+// fits in MaxSize
+func (c *Cache) Prune() error {
+	files, err := c.entries()
+	if err != nil {
+		return err
+	}
+
+	var total int64
+	kept := files[:0]
+	for _, f := range files {
+		if c.expired(f.modTime) {
+			_ = os.Remove(f.path)
+			continue
+		}
+		total += f.size
+		kept = append(kept, f)
+	}
+
+	if c.MaxSize <= 0 {
+		return nil
+	}
+	sort.Slice(kept, func(i, j int) bool { return kept[i].modTime.Before(kept[j].modTime) })
+	for _, f := range kept {
+		if total <= c.MaxSize {
+			break
+		}
+		if err := os.Remove(f.path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to evict cache entry: %w", err)
+		}
+		total -= f.size
+	}
+	return nil
+}
+
+// Clear removes every entry and returns how many were removed
+func (c *Cache) Clear() (int, error) {
+	files, err := c.entries()
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, f := range files {
+		if err := os.Remove(f.path); err != nil && !os.IsNotExist(err) {
+			return removed, fmt.Errorf("failed to remove cache entry: %w", err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// Stats returns the number of entries and their total size in bytes
+func (c *Cache) Stats() (int, int64, error) {
+	files, err := c.entries()
+	if err != nil {
+		return 0, 0, err
+	}
+	var total int64
+	for _, f := range files {
+		total += f.size
+	}
+	return len(files), total, nil
+}
+
+func (c *Cache) expired(created time.Time) bool {
+	return c.TTL > 0 && c.clock().Sub(created) > c.TTL
+}
+
+func (c *Cache) clock() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
+
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.Dir, key+entrySuffix)
+}
+
+func (c *Cache) pendingPath(key string) string {
+	return filepath.Join(c.Dir, key+pendingSuffix)
+}
+
+type entryFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+func (c *Cache) entries() ([]entryFile, error) {
+	dirEntries, err := os.ReadDir(c.Dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read cache directory: %w", err)
+	}
+
+	var files []entryFile
+	for _, d := range dirEntries {
+		if d.IsDir() || !strings.HasSuffix(d.Name(), entrySuffix) {
+			continue
+		}
+		info, err := d.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, entryFile{
+			path:    filepath.Join(c.Dir, d.Name()),
+			size:    info.Size(),
+			modTime: info.ModTime(),
+		})
+	}
+	return files, nil
+}
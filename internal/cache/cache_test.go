@@ -0,0 +1,198 @@
+package cache
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klauern/muse/config"
+)
+
+func TestKey(t *testing.T) {
+	base := KeyParts{
+		Diff:     "diff --git a/x b/x\n+hello\n",
+		Style:    "conventional",
+		Template: "template",
+		Provider: "openai",
+		Model:    "gpt-4o",
+	}
+	key := Key(base)
+
+	same := base
+	same.Diff = "\r\ndiff --git a/x b/x  \r\n+hello\t\r\n\n"
+	if Key(same) != key {
+		t.Error("Key() differs for a diff that only changes line endings and trailing whitespace")
+	}
+
+	for name, mutate := range map[string]func(*KeyParts){
+		"diff":     func(p *KeyParts) { p.Diff += "+world\n" },
+		"style":    func(p *KeyParts) { p.Style = "gitmoji" },
+		"template": func(p *KeyParts) { p.Template = "changed" },
+		"provider": func(p *KeyParts) { p.Provider = "other" },
+		"model":    func(p *KeyParts) { p.Model = "gpt-4o-mini" },
+		"examples": func(p *KeyParts) { p.Examples = `[{"generated":"a","committed":"b"}]` },
+	} {
+		parts := base
+		mutate(&parts)
+		if Key(parts) == key {
+			t.Errorf("Key() did not change with the %s", name)
+		}
+	}
+}
+
+func TestCache_PutGet(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	c := New(filepath.Join(t.TempDir(), "responses"), time.Hour, 0)
+	c.now = func() time.Time { return now }
+
+	if _, ok := c.Get("missing"); ok {
+		t.Fatal("Get() hit on an empty cache")
+	}
+
+	if err := c.Put("k", Entry{Message: "feat: add cache", Model: "gpt-4o"}); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	entry, ok := c.Get("k")
+	if !ok || entry.Message != "feat: add cache" || !entry.CreatedAt.Equal(now) {
+		t.Fatalf("Get() = %+v, %v; want the stored entry", entry, ok)
+	}
+
+	info, err := os.Stat(c.path("k"))
+	if err != nil {
+		t.Fatalf("entry file missing: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("entry file mode = %o, want 600", perm)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if _, ok := c.Get("k"); ok {
+		t.Error("Get() hit on an expired entry")
+	}
+	if _, err := os.Stat(c.path("k")); !os.IsNotExist(err) {
+		t.Errorf("expired entry was not removed: %v", err)
+	}
+}
+
+func TestCache_GetRemovesCorruptEntry(t *testing.T) {
+	c := New(t.TempDir(), 0, 0)
+	if err := os.WriteFile(c.path("k"), []byte("{not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.Get("k"); ok {
+		t.Fatal("Get() hit on a corrupt entry")
+	}
+	if _, err := os.Stat(c.path("k")); !os.IsNotExist(err) {
+		t.Errorf("corrupt entry was not removed: %v", err)
+	}
+}
+
+func TestCache_PruneEvictsOldest(t *testing.T) {
+	c := New(t.TempDir(), 0, 0)
+	message := strings.Repeat("x", 100)
+
+	base := time.Now().Add(-time.Hour)
+	for i, key := range []string{"a", "b", "c"} {
+		if err := c.Put(key, Entry{Message: message}); err != nil {
+			t.Fatalf("Put(%s) error = %v", key, err)
+		}
+		modTime := base.Add(time.Duration(i) * time.Minute)
+		if err := os.Chtimes(c.path(key), modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, size, err := c.Stats()
+	if err != nil {
+		t.Fatalf("Stats() error = %v", err)
+	}
+	c.MaxSize = size - 1
+	if err := c.Prune(); err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+
+	if _, ok := c.Get("a"); ok {
+		t.Error("oldest entry was not evicted")
+	}
+	for _, key := range []string{"b", "c"} {
+		if _, ok := c.Get(key); !ok {
+			t.Errorf("entry %s was evicted, want it kept", key)
+		}
+	}
+}
+
+func TestCache_Clear(t *testing.T) {
+	c := New(t.TempDir(), 0, 0)
+	for _, key := range []string{"a", "b"} {
+		if err := c.Put(key, Entry{Message: "m"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	removed, err := c.Clear()
+	if err != nil || removed != 2 {
+		t.Fatalf("Clear() = %d, %v; want 2", removed, err)
+	}
+	if count, _, _ := c.Stats(); count != 0 {
+		t.Errorf("Stats() count = %d after Clear(), want 0", count)
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	c, err := FromConfig(config.CacheConfig{Enabled: true, TTL: "24h", MaxSizeMB: 2})
+	if err != nil {
+		t.Fatalf("FromConfig() error = %v", err)
+	}
+	if c.TTL != 24*time.Hour || c.MaxSize != 2*1024*1024 {
+		t.Errorf("FromConfig() = ttl %v, max %d", c.TTL, c.MaxSize)
+	}
+	if want := filepath.Join(os.Getenv("XDG_CACHE_HOME"), "muse", "responses"); c.Dir != want {
+		t.Errorf("FromConfig() dir = %q, want %q", c.Dir, want)
+	}
+
+	if _, err := FromConfig(config.CacheConfig{TTL: "soon"}); err == nil {
+		t.Error("FromConfig() accepted an invalid ttl")
+	}
+}
+
+func TestCache_WaitPending(t *testing.T) {
+	c := New(t.TempDir(), 0, 0)
+
+	if _, ok := c.WaitPending(context.Background(), "k"); ok {
+		t.Fatal("WaitPending() hit without a pending marker")
+	}
+
+	release := c.MarkPending("k")
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_ = c.Put("k", Entry{Message: "feat: pre-generated"})
+		release()
+	}()
+
+	entry, ok := c.WaitPending(context.Background(), "k")
+	if !ok || entry.Message != "feat: pre-generated" {
+		t.Fatalf("WaitPending() = %+v, %v; want the pre-generated entry", entry, ok)
+	}
+}
+
+func TestCache_WaitPendingIgnoresStaleMarker(t *testing.T) {
+	c := New(t.TempDir(), 0, 0)
+	c.MarkPending("k")
+	stale := time.Now().Add(-2 * PendingTimeout)
+	if err := os.Chtimes(c.pendingPath("k"), stale, stale); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	if _, ok := c.WaitPending(context.Background(), "k"); ok {
+		t.Fatal("WaitPending() hit for a stale marker")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("WaitPending() waited %v on a stale marker", elapsed)
+	}
+}
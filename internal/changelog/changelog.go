@@ -0,0 +1,183 @@
+package changelog
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/klauern/muse/internal/git"
+	"github.com/klauern/muse/templates"
+)
+
+// typeOrder lists commit types in the order their groups are rendered;
+// other types follow alphabetically
+var typeOrder = []string{"feat", "fix", "perf", "refactor", "revert", "docs", "build", "ci", "test", "style", "chore"}
+
+// typeTitles are the headings used for each commit type
+var typeTitles = map[string]string{
+	"feat":     "Features",
+	"fix":      "Bug Fixes",
+	"perf":     "Performance",
+	"refactor": "Refactoring",
+	"revert":   "Reverts",
+	"docs":     "Documentation",
+	"build":    "Build",
+	"ci":       "Continuous Integration",
+	"test":     "Tests",
+	"style":    "Style",
+	"chore":    "Chores",
+}
+
+// Entry is a conventional commit in a changelog
+type Entry struct {
+	Hash    string
+	Type    string
+	Scope   string
+	Subject string
+	Body    string
+	// Breaking is set for breaking changes, with BreakingNote holding the
+	// BREAKING CHANGE footer text when there is one
+	Breaking     bool
+	BreakingNote string
+}
+
+// ShortHash returns the first 7 characters of the hash
+func (e Entry) ShortHash() string {
+	if len(e.Hash) > 7 {
+		return e.Hash[:7]
+	}
+	return e.Hash
+}
+
+// ScopeGroup holds the entries of one type that share a scope
+type ScopeGroup struct {
+	// Scope is empty for commits without one
+	Scope   string
+	Entries []Entry
+}
+
+// Group holds the entries of one commit type, grouped by scope
+type Group struct {
+	Type   string
+	Title  string
+	Scopes []ScopeGroup
+}
+
+// Changelog is the data templates render
+type Changelog struct {
+	Version string
+	Date    string
+	// Groups are ordered feat, fix, perf, refactor and so on
+	Groups   []Group
+	Breaking []Entry
+	// Unparsed holds commits whose messages are not conventional
+	Unparsed []git.Commit
+	Bump     Bump
+}
+
+// Group returns the group for typ, or nil when there were no such commits
+func (c *Changelog) Group(typ string) *Group {
+	for i := range c.Groups {
+		if c.Groups[i].Type == typ {
+			return &c.Groups[i]
+		}
+	}
+	return nil
+}
+
+// New parses commits, newest first as returned by git log, into a
+// changelog for version
+func New(commits []git.Commit, version string, date time.Time) *Changelog {
+	c := &Changelog{Version: version, Date: date.Format("2006-01-02")}
+
+	byType := map[string][]Entry{}
+	for _, commit := range commits {
+		parsed, err := templates.ParseConventionalCommit(commit.Message)
+		if err != nil {
+			c.Unparsed = append(c.Unparsed, commit)
+			continue
+		}
+		entry := Entry{
+			Hash:         commit.Hash,
+			Type:         parsed.Type,
+			Scope:        parsed.Scope,
+			Subject:      parsed.Subject,
+			Body:         parsed.Body,
+			Breaking:     parsed.Breaking,
+			BreakingNote: breakingNote(parsed.Footer),
+		}
+		byType[entry.Type] = append(byType[entry.Type], entry)
+		if entry.Breaking {
+			c.Breaking = append(c.Breaking, entry)
+		}
+	}
+
+	for _, typ := range orderTypes(byType) {
+		c.Groups = append(c.Groups, Group{Type: typ, Title: title(typ), Scopes: groupScopes(byType[typ])})
+	}
+	c.Bump = SuggestBump(c)
+	return c
+}
+
+// orderTypes returns the types present in byType in rendering order
+func orderTypes(byType map[string][]Entry) []string {
+	var types, others []string
+	known := map[string]bool{}
+	for _, typ := range typeOrder {
+		known[typ] = true
+		if len(byType[typ]) > 0 {
+			types = append(types, typ)
+		}
+	}
+	for typ := range byType {
+		if !known[typ] {
+			others = append(others, typ)
+		}
+	}
+	sort.Strings(others)
+	return append(types, others...)
+}
+
+// groupScopes groups entries by scope, with unscoped entries first and the
+// rest sorted by scope. Entries keep their order within a scope.
+func groupScopes(entries []Entry) []ScopeGroup {
+	var groups []ScopeGroup
+	index := map[string]int{}
+	for _, e := range entries {
+		i, ok := index[e.Scope]
+		if !ok {
+			i = len(groups)
+			index[e.Scope] = i
+			groups = append(groups, ScopeGroup{Scope: e.Scope})
+		}
+		groups[i].Entries = append(groups[i].Entries, e)
+	}
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i].Scope < groups[j].Scope })
+	return groups
+}
+
+func title(typ string) string {
+	if t, ok := typeTitles[typ]; ok {
+		return t
+	}
+	return strings.ToUpper(typ[:1]) + typ[1:]
+}
+
+// breakingNote returns the text of a BREAKING CHANGE footer, including its
+// continuation lines
+func breakingNote(footer string) string {
+	var note []string
+	inNote := false
+	for _, line := range strings.Split(footer, "\n") {
+		switch {
+		case strings.HasPrefix(line, "BREAKING CHANGE: "), strings.HasPrefix(line, "BREAKING-CHANGE: "):
+			inNote = true
+			note = append(note, strings.TrimSpace(line[len("BREAKING CHANGE: "):]))
+		case inNote && strings.HasPrefix(line, " "):
+			note = append(note, strings.TrimSpace(line))
+		default:
+			inNote = false
+		}
+	}
+	return strings.Join(note, " ")
+}
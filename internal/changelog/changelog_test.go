@@ -0,0 +1,187 @@
+package changelog
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klauern/muse/internal/git"
+	"github.com/klauern/muse/templates"
+)
+
+var testDate = time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC)
+
+// testCommits are newest first, as git log returns them
+func testCommits() []git.Commit {
+	messages := []string{
+		"fix(cli): handle empty ranges",
+		"Update README",
+		"feat(api)!: drop v1 endpoints\n\nBREAKING CHANGE: clients must use /v2\n  before upgrading",
+		"chore: bump deps",
+		"feat: add changelog command",
+		"feat(cli): add --release flag",
+		"perf: cache parsed templates",
+	}
+	commits := make([]git.Commit, len(messages))
+	for i, m := range messages {
+		commits[i] = git.Commit{Hash: strings.Repeat(string(rune('a'+i)), 40), Message: m}
+	}
+	return commits
+}
+
+func TestNew(t *testing.T) {
+	c := New(testCommits(), "v2.0.0", testDate)
+
+	var types []string
+	for _, g := range c.Groups {
+		types = append(types, g.Type)
+	}
+	if got := strings.Join(types, ","); got != "feat,fix,perf,chore" {
+		t.Errorf("group types = %s", got)
+	}
+
+	feat := c.Group("feat")
+	if feat.Title != "Features" || len(feat.Scopes) != 3 {
+		t.Fatalf("feat group = %+v", feat)
+	}
+	if feat.Scopes[0].Scope != "" || feat.Scopes[1].Scope != "api" || feat.Scopes[2].Scope != "cli" {
+		t.Errorf("feat scopes = %+v, want unscoped first then sorted", feat.Scopes)
+	}
+
+	if len(c.Breaking) != 1 || c.Breaking[0].BreakingNote != "clients must use /v2 before upgrading" {
+		t.Errorf("breaking = %+v", c.Breaking)
+	}
+	if len(c.Unparsed) != 1 || c.Unparsed[0].Subject() != "Update README" {
+		t.Errorf("unparsed = %+v", c.Unparsed)
+	}
+	if c.Bump != BumpMajor {
+		t.Errorf("bump = %s, want major", c.Bump)
+	}
+}
+
+func TestRender_KeepAChangelog(t *testing.T) {
+	got, err := Render(New(testCommits(), "v2.0.0", testDate), "")
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	want := `## [v2.0.0] - 2026-03-14
+
+### Breaking Changes
+
+- **api:** drop v1 endpoints (ccccccc)
+  clients must use /v2 before upgrading
+
+### Added
+
+- add changelog command (eeeeeee)
+- **api:** drop v1 endpoints (ccccccc)
+- **cli:** add --release flag (fffffff)
+
+### Changed
+
+- cache parsed templates (ggggggg)
+
+### Fixed
+
+- **cli:** handle empty ranges (aaaaaaa)
+
+### Other
+
+- Update README (bbbbbbb)
+`
+	if got != want {
+		t.Errorf("Render() =\n%s\nwant:\n%s", got, want)
+	}
+
+	unreleased, err := Render(New(testCommits()[:1], "Unreleased", testDate), "")
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if !strings.HasPrefix(unreleased, "## [Unreleased]\n\n### Fixed") {
+		t.Errorf("Render() for Unreleased =\n%s", unreleased)
+	}
+}
+
+func TestRender_CustomTemplate(t *testing.T) {
+	tmpl := `{{range .Groups}}{{.Title}}:{{range .Scopes}}{{range .Entries}} {{.Subject}};{{end}}{{end}}
+{{end}}`
+	got, err := Render(New(testCommits()[:4], "v1", testDate), tmpl)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	want := "Features: drop v1 endpoints;\nBug Fixes: handle empty ranges;\nChores: bump deps;\n"
+	if got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+
+	if _, err := Render(New(nil, "v1", testDate), "{{.Missing}}"); err == nil {
+		t.Error("Render() succeeded with a field that does not exist")
+	}
+}
+
+func TestNextVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		bump    Bump
+		want    string
+	}{
+		{"v1.2.3", BumpMajor, "v2.0.0"},
+		{"v1.2.3", BumpMinor, "v1.3.0"},
+		{"1.2.3", BumpPatch, "1.2.4"},
+		{"v1.2.3", BumpNone, "v1.2.3"},
+		{"v0.4.1", BumpMinor, "v0.5.0"},
+	}
+	for _, tt := range tests {
+		if got, ok := NextVersion(tt.version, tt.bump); !ok || got != tt.want {
+			t.Errorf("NextVersion(%s, %s) = %s, %v, want %s", tt.version, tt.bump, got, ok, tt.want)
+		}
+	}
+	if _, ok := NextVersion("main", BumpMinor); ok {
+		t.Error("NextVersion() accepted a branch name")
+	}
+}
+
+func TestSuggestBump(t *testing.T) {
+	commits := testCommits()
+	tests := []struct {
+		commits []git.Commit
+		want    Bump
+	}{
+		{commits[:1], BumpPatch},
+		{commits[3:6], BumpMinor},
+		{commits[1:2], BumpNone},
+		{commits[6:], BumpPatch},
+	}
+	for _, tt := range tests {
+		if got := New(tt.commits, "", testDate).Bump; got != tt.want {
+			t.Errorf("bump for %d commits = %s, want %s", len(tt.commits), got, tt.want)
+		}
+	}
+}
+
+type fakeService struct{ prompt string }
+
+func (f *fakeService) GenerateCommitMessage(ctx context.Context, diff string, style templates.CommitStyle) (string, error) {
+	return "", nil
+}
+
+func (f *fakeService) Complete(ctx context.Context, prompt string) (string, error) {
+	f.prompt = prompt
+	return "## v2.0.0\n\nFriendly notes.\n\n", nil
+}
+
+func TestRewrite(t *testing.T) {
+	service := &fakeService{}
+	notes, err := Rewrite(context.Background(), service, "## [v2.0.0]\n\n- add changelog command (eeeeeee)\n")
+	if err != nil {
+		t.Fatalf("Rewrite() error = %v", err)
+	}
+	if notes != "## v2.0.0\n\nFriendly notes.\n" {
+		t.Errorf("Rewrite() = %q", notes)
+	}
+	if !strings.Contains(service.prompt, "add changelog command (eeeeeee)") {
+		t.Errorf("prompt does not contain the changelog:\n%s", service.prompt)
+	}
+}
@@ -0,0 +1,64 @@
+package changelog
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/klauern/muse/llm"
+	"github.com/klauern/muse/templates"
+)
+
+//go:embed keepachangelog.tmpl
+var keepAChangelogTemplate string
+
+// Entries returns the entries of the given types, in group and scope order
+func (c *Changelog) Entries(types ...string) []Entry {
+	var entries []Entry
+	for _, typ := range types {
+		g := c.Group(typ)
+		if g == nil {
+			continue
+		}
+		for _, s := range g.Scopes {
+			entries = append(entries, s.Entries...)
+		}
+	}
+	return entries
+}
+
+// Render executes tmpl with c. An empty tmpl renders a Keep a Changelog
+// section, which lists features, fixes, performance changes, refactors and
+// reverts and leaves out chores, docs and the like.
+func Render(c *Changelog, tmpl string) (string, error) {
+	if tmpl == "" {
+		tmpl = keepAChangelogTemplate
+	}
+
+	funcs := templates.SafeFuncMap()
+	funcs["section"] = func(title string, entries []Entry) map[string]any {
+		return map[string]any{"Title": title, "Entries": entries}
+	}
+	t, err := template.New("changelog").Funcs(funcs).Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse changelog template: %w", err)
+	}
+
+	var buf strings.Builder
+	if err := t.Execute(&buf, c); err != nil {
+		return "", fmt.Errorf("failed to render changelog: %w", err)
+	}
+	return strings.TrimSpace(buf.String()) + "\n", nil
+}
+
+// Rewrite asks service to turn a rendered changelog into release notes
+// written for users rather than developers
+func Rewrite(ctx context.Context, service llm.LLMService, changelog string) (string, error) {
+	notes, err := llm.CompletePrompt(ctx, service, "release_notes", map[string]any{"Changelog": changelog})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(notes) + "\n", nil
+}
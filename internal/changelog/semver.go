@@ -0,0 +1,58 @@
+package changelog
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// Bump is a semantic version increment
+type Bump string
+
+const (
+	BumpNone  Bump = "none"
+	BumpPatch Bump = "patch"
+	BumpMinor Bump = "minor"
+	BumpMajor Bump = "major"
+)
+
+// versionPattern matches a release tag such as v1.2.3 or 1.2.3
+var versionPattern = regexp.MustCompile(`^(v?)(\d+)\.(\d+)\.(\d+)$`)
+
+// SuggestBump returns the increment the changes call for: major for
+// breaking changes, minor for features and patch for fixes and performance
+// improvements
+func SuggestBump(c *Changelog) Bump {
+	switch {
+	case len(c.Breaking) > 0:
+		return BumpMajor
+	case c.Group("feat") != nil:
+		return BumpMinor
+	case c.Group("fix") != nil || c.Group("perf") != nil:
+		return BumpPatch
+	default:
+		return BumpNone
+	}
+}
+
+// NextVersion applies bump to version, keeping a leading v. The bool is
+// false when version is not of the form v1.2.3.
+func NextVersion(version string, bump Bump) (string, bool) {
+	m := versionPattern.FindStringSubmatch(version)
+	if m == nil {
+		return "", false
+	}
+	major, _ := strconv.Atoi(m[2])
+	minor, _ := strconv.Atoi(m[3])
+	patch, _ := strconv.Atoi(m[4])
+
+	switch bump {
+	case BumpMajor:
+		major, minor, patch = major+1, 0, 0
+	case BumpMinor:
+		minor, patch = minor+1, 0
+	case BumpPatch:
+		patch++
+	}
+	return fmt.Sprintf("%s%d.%d.%d", m[1], major, minor, patch), true
+}
@@ -1,33 +1,44 @@
 package configloader
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
 
 	museconfig "github.com/klauern/muse/config"
+	"github.com/klauern/muse/internal/credentials"
 	"github.com/klauern/muse/internal/security"
+	"github.com/klauern/muse/llm"
 	"github.com/knadh/koanf"
-	"github.com/knadh/koanf/parsers/yaml"
-	"github.com/knadh/koanf/providers/file"
-	"github.com/knadh/koanf/providers/rawbytes"
+	"github.com/knadh/koanf/maps"
+	"github.com/knadh/koanf/providers/confmap"
 )
 
-// ConfigLoader provides thread-safe configuration loading with caching
+// ConfigLoader provides thread-safe configuration loading with caching. The
+// cache stays valid until one of the loaded config files changes on disk.
 type ConfigLoader struct {
 	mu           sync.RWMutex
 	cachedConfig *CachedConfig
 	loadTimeout  time.Duration
+	// overrides are flattened keys from command-line flags
+	overrides map[string]any
+	watcher   *configWatcher
+	// dir selects the repository whose config and profile are loaded; empty
+	// means the working directory
+	dir string
 }
 
-// CachedConfig represents a cached configuration with expiration
+// CachedConfig represents a cached configuration and how it was built
 type CachedConfig struct {
-	Config    *museconfig.Config
-	LoadTime  time.Time
-	ExpiresAt time.Time
+	Config   *museconfig.Config
+	Origins  museconfig.Origins
+	Sources  []museconfig.Source
+	LoadTime time.Time
 }
 
 // ConfigError represents a configuration loading error
@@ -39,10 +50,14 @@ type ConfigError struct {
 }
 
 func (e ConfigError) Error() string {
+	msg := fmt.Sprintf("config %s failed: %s", e.Stage, e.Reason)
 	if e.Path != "" {
-		return fmt.Sprintf("config %s failed for %s: %s (%v)", e.Stage, e.Path, e.Reason, e.Err)
+		msg = fmt.Sprintf("config %s failed for %s: %s", e.Stage, e.Path, e.Reason)
 	}
-	return fmt.Sprintf("config %s failed: %s (%v)", e.Stage, e.Reason, e.Err)
+	if e.Err != nil {
+		msg += fmt.Sprintf(" (%v)", e.Err)
+	}
+	return msg
 }
 
 func (e ConfigError) Unwrap() error {
@@ -57,13 +72,27 @@ var (
 // GetConfigLoader returns the singleton configuration loader
 func GetConfigLoader() *ConfigLoader {
 	loaderOnce.Do(func() {
-		globalLoader = &ConfigLoader{
-			loadTimeout: 10 * time.Second, // Timeout for configuration loading
-		}
+		globalLoader = newConfigLoader()
 	})
 	return globalLoader
 }
 
+func newConfigLoader() *ConfigLoader {
+	return &ConfigLoader{
+		loadTimeout: 10 * time.Second, // Timeout for configuration loading
+	}
+}
+
+// NewRepoLoader returns a loader for the repository containing dir rather
+// than the working directory, for servers that work on several
+// repositories. Unlike GetConfigLoader it is not shared, so callers must
+// Close it.
+func NewRepoLoader(dir string) *ConfigLoader {
+	loader := newConfigLoader()
+	loader.dir = dir
+	return loader
+}
+
 // SetLoadTimeout configures the timeout for configuration loading operations
 func (cl *ConfigLoader) SetLoadTimeout(timeout time.Duration) {
 	cl.mu.Lock()
@@ -71,14 +100,73 @@ func (cl *ConfigLoader) SetLoadTimeout(timeout time.Duration) {
 	cl.loadTimeout = timeout
 }
 
+// SetOverrides sets flattened config keys (e.g. "llm.config.model") that take
+// precedence over every other layer, and invalidates the cache
+func (cl *ConfigLoader) SetOverrides(overrides map[string]any) {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+
+	cl.overrides = make(map[string]any, len(overrides))
+	for k, v := range overrides {
+		cl.overrides[k] = v
+	}
+	cl.cachedConfig = nil
+}
+
+// Invalidate drops the cached configuration so the next load re-reads it
+func (cl *ConfigLoader) Invalidate() {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	cl.cachedConfig = nil
+}
+
+// Close stops watching config files for changes
+func (cl *ConfigLoader) Close() error {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+
+	if cl.watcher == nil {
+		return nil
+	}
+	err := cl.watcher.Close()
+	cl.watcher = nil
+	return err
+}
+
 // LoadConfigSafe loads configuration with thread safety and caching
 func (cl *ConfigLoader) LoadConfigSafe() (*museconfig.Config, error) {
+	cached, err := cl.load()
+	if err != nil {
+		return nil, err
+	}
+	return cached.Config, nil
+}
+
+// LoadWithOrigins loads configuration along with the layer each value came from
+func (cl *ConfigLoader) LoadWithOrigins() (*museconfig.Config, museconfig.Origins, error) {
+	cached, err := cl.load()
+	if err != nil {
+		return nil, nil, err
+	}
+	return cached.Config, cached.Origins, nil
+}
+
+// Sources returns the configuration layers behind the current configuration
+func (cl *ConfigLoader) Sources() ([]museconfig.Source, error) {
+	cached, err := cl.load()
+	if err != nil {
+		return nil, err
+	}
+	return cached.Sources, nil
+}
+
+func (cl *ConfigLoader) load() (*CachedConfig, error) {
 	cl.mu.RLock()
 	// Check if we have a valid cached config
-	if cl.cachedConfig != nil && time.Now().Before(cl.cachedConfig.ExpiresAt) {
-		config := cl.cachedConfig.Config
+	if cl.cachedConfig != nil {
+		cached := cl.cachedConfig
 		cl.mu.RUnlock()
-		return config, nil
+		return cached, nil
 	}
 	cl.mu.RUnlock()
 
@@ -87,44 +175,78 @@ func (cl *ConfigLoader) LoadConfigSafe() (*museconfig.Config, error) {
 	defer cl.mu.Unlock()
 
 	// Double-check pattern - another goroutine might have loaded while we waited
-	if cl.cachedConfig != nil && time.Now().Before(cl.cachedConfig.ExpiresAt) {
-		return cl.cachedConfig.Config, nil
+	if cl.cachedConfig != nil {
+		return cl.cachedConfig, nil
 	}
 
 	// Load configuration with timeout
-	config, err := cl.loadConfigWithTimeout()
+	cached, err := cl.loadConfigWithTimeout()
 	if err != nil {
 		return nil, err
 	}
 
-	// Cache the loaded configuration (5 minute expiration)
-	cl.cachedConfig = &CachedConfig{
-		Config:    config,
-		LoadTime:  time.Now(),
-		ExpiresAt: time.Now().Add(5 * time.Minute),
+	cl.watchSources(cached.Sources)
+	cl.cachedConfig = cached
+
+	return cached, nil
+}
+
+// watchSources starts watching the loaded config files so the cache is
+// invalidated when they change. Must be called with cl.mu held.
+func (cl *ConfigLoader) watchSources(sources []museconfig.Source) {
+	if cl.watcher == nil {
+		watcher, err := newConfigWatcher(cl.Invalidate)
+		if err != nil {
+			// Without a watcher the cache simply lives until Invalidate is called
+			slog.Debug("Config file watching unavailable", "error", err)
+			return
+		}
+		cl.watcher = watcher
+	}
+
+	for _, path := range cl.candidatePaths(sources) {
+		cl.watcher.Watch(path)
+	}
+}
+
+// candidatePaths returns every config file that could affect the result,
+// including ones that do not exist yet
+func (cl *ConfigLoader) candidatePaths(sources []museconfig.Source) []string {
+	var paths []string
+	for _, source := range sources {
+		if source.Path != "" {
+			paths = append(paths, source.Path)
+		}
 	}
 
-	return config, nil
+	if path := cl.globalConfigPath(cl.captureEnvironment()); path != "" {
+		paths = append(paths, path)
+	}
+	if root := museconfig.RepoRootOf(cl.dir); root != "" {
+		for _, name := range museconfig.RepoConfigNames {
+			paths = append(paths, filepath.Join(root, name))
+		}
+	}
+	return paths
+}
+
+type loadResult struct {
+	cached *CachedConfig
+	err    error
 }
 
 // loadConfigWithTimeout loads configuration with a timeout to prevent hanging
-func (cl *ConfigLoader) loadConfigWithTimeout() (*museconfig.Config, error) {
-	done := make(chan struct {
-		*museconfig.Config
-		error
-	}, 1)
+func (cl *ConfigLoader) loadConfigWithTimeout() (*CachedConfig, error) {
+	done := make(chan loadResult, 1)
 
 	go func() {
-		config, err := cl.loadConfigInternal()
-		done <- struct {
-			*museconfig.Config
-			error
-		}{config, err}
+		cached, err := cl.loadConfigInternal()
+		done <- loadResult{cached, err}
 	}()
 
 	select {
 	case result := <-done:
-		return result.Config, result.error
+		return result.cached, result.err
 	case <-time.After(cl.loadTimeout):
 		return nil, ConfigError{
 			Stage:  "loading",
@@ -134,26 +256,61 @@ func (cl *ConfigLoader) loadConfigWithTimeout() (*museconfig.Config, error) {
 }
 
 // loadConfigInternal performs the actual configuration loading
-func (cl *ConfigLoader) loadConfigInternal() (*museconfig.Config, error) {
+func (cl *ConfigLoader) loadConfigInternal() (*CachedConfig, error) {
 	slog.Debug("Loading config with thread safety")
-	k := koanf.New(".")
 
 	// Atomically capture environment state
 	env := cl.captureEnvironment()
 
-	// Define the list of config file paths to check
-	configPaths := cl.buildConfigPaths(env)
+	root := museconfig.RepoRootOf(cl.dir)
 
-	// Load the first existing config file
-	configPath, err := cl.loadConfigFile(k, configPaths)
+	// Collect the file layers (defaults, global, repo) in precedence order
+	sources, err := cl.loadConfigFiles(env, root)
 	if err != nil {
 		return nil, err
 	}
 
 	// Load environment variables safely
-	if err := cl.loadEnvironmentVariables(k, env); err != nil {
+	envSource, err := cl.loadEnvironmentVariables(env)
+	if err != nil {
 		// Log but don't fail on environment variable errors
 		slog.Warn("Failed to load environment variables", "error", err)
+	} else {
+		sources = append(sources, envSource)
+	}
+
+	// Command-line flags take precedence over everything else
+	sources = append(sources, museconfig.FlatSource(museconfig.LayerFlags, cl.overrides))
+
+	k, origins, err := museconfig.Merge(sources)
+	if err != nil {
+		return nil, ConfigError{
+			Stage:  "merging",
+			Reason: "failed to merge configuration layers",
+			Err:    err,
+		}
+	}
+
+	// A profile sits between the files and the environment, so it is only
+	// known once every layer that can select it has been merged
+	if profileSource, ok, err := selectProfile(k, origins, root); err != nil {
+		return nil, err
+	} else if ok {
+		sources = insertBefore(sources, profileSource, museconfig.LayerEnv, museconfig.LayerFlags)
+		k, origins, err = museconfig.Merge(sources)
+		if err != nil {
+			return nil, ConfigError{
+				Stage:  "merging",
+				Reason: "failed to merge configuration layers",
+				Err:    err,
+			}
+		}
+	}
+
+	// Check the files against the schema first so typos and wrong types are
+	// reported with their line numbers
+	if err := validateSchema(sources, k.String("llm.provider")); err != nil {
+		return nil, err
 	}
 
 	// Unmarshal into the struct
@@ -161,18 +318,156 @@ func (cl *ConfigLoader) loadConfigInternal() (*museconfig.Config, error) {
 	if err := k.Unmarshal("", &cfg); err != nil {
 		return nil, ConfigError{
 			Stage:  "unmarshaling",
-			Path:   configPath,
+			Path:   findUnmarshalCulprit(sources),
 			Reason: "failed to unmarshal configuration",
 			Err:    err,
 		}
 	}
 
+	if err := cfg.Validate(); err != nil {
+		return nil, validationConfigError(err, origins)
+	}
+
 	// Safely handle API keys with environment fallback
 	if err := cl.handleAPIKeys(&cfg, env); err != nil {
 		return nil, err
 	}
 
-	return &cfg, nil
+	return &CachedConfig{
+		Config:   &cfg,
+		Origins:  origins,
+		Sources:  sources,
+		LoadTime: time.Now(),
+	}, nil
+}
+
+// selectProfile returns the layer of the profile named by the profile key,
+// or of the first profile whose match rules select the repository at root
+func selectProfile(k *koanf.Koanf, origins museconfig.Origins, root string) (museconfig.Source, bool, error) {
+	name := k.String("profile")
+	matched := false
+	if name == "" {
+		var profiles map[string]museconfig.Profile
+		// Malformed profiles are reported by the schema check instead
+		if err := k.Unmarshal("profiles", &profiles); err != nil || len(profiles) == 0 {
+			return museconfig.Source{}, false, nil
+		}
+		name = museconfig.MatchProfile(profiles, root, museconfig.RepoRemotes(root))
+		if name == "" {
+			return museconfig.Source{}, false, nil
+		}
+		matched = true
+	}
+
+	// The profile is defined in whichever file set its first key
+	var path string
+	prefix := "profiles." + name + "."
+	for _, key := range origins.Keys() {
+		if strings.HasPrefix(key, prefix) {
+			path = origins[key].Path
+			break
+		}
+	}
+
+	source, ok := museconfig.ProfileSource(k.Raw(), name, path)
+	if !ok {
+		return museconfig.Source{}, false, ConfigError{
+			Stage:  "profile",
+			Path:   origins["profile"].Path,
+			Reason: fmt.Sprintf("unknown profile %q set by %s", name, origins["profile"].Layer),
+		}
+	}
+	if matched {
+		// Record the automatically selected name so it can be reported
+		source.Values["profile"] = name
+	}
+	slog.Debug("Using config profile", "profile", name, "matched", matched)
+	return source, true, nil
+}
+
+// insertBefore inserts source ahead of the first source in any of layers
+func insertBefore(sources []museconfig.Source, source museconfig.Source, layers ...string) []museconfig.Source {
+	for i, s := range sources {
+		for _, layer := range layers {
+			if s.Layer == layer {
+				return append(sources[:i:i], append([]museconfig.Source{source}, sources[i:]...)...)
+			}
+		}
+	}
+	return append(sources, source)
+}
+
+// validateSchema checks every config file against the schema of the
+// effective provider
+func validateSchema(sources []museconfig.Source, provider string) error {
+	schema := museconfig.SchemaForProvider(provider, llm.ProviderConfigSchemas())
+	for _, source := range sources {
+		// A profile layer points at the file that defines it, which is
+		// already checked as its own layer
+		if source.Path == "" || source.Layer == museconfig.LayerProfile {
+			continue
+		}
+		data, err := os.ReadFile(source.Path)
+		if err != nil {
+			return ConfigError{
+				Stage:  "schema",
+				Path:   source.Path,
+				Reason: "failed to read config file",
+				Err:    err,
+			}
+		}
+		if err := museconfig.ValidateYAML(source.Path, data, schema); err != nil {
+			return ConfigError{
+				Stage:  "schema",
+				Path:   source.Path,
+				Reason: "config does not match the schema",
+				Err:    err,
+			}
+		}
+	}
+	return nil
+}
+
+// findUnmarshalCulprit returns the path of the first layer that cannot be
+// unmarshaled on its own, to point users at the file with the bad value
+func findUnmarshalCulprit(sources []museconfig.Source) string {
+	for _, source := range sources {
+		k := koanf.New(".")
+		if err := k.Load(confmapProvider(source.Values), nil); err != nil {
+			continue
+		}
+		var cfg museconfig.Config
+		if err := k.Unmarshal("", &cfg); err != nil {
+			if source.Path != "" {
+				return source.Path
+			}
+			return source.Layer
+		}
+	}
+	return ""
+}
+
+// confmapProvider returns a koanf provider for nested config values
+func confmapProvider(values map[string]any) koanf.Provider {
+	return confmap.Provider(maps.Copy(values), "")
+}
+
+// validationConfigError converts validation errors into a ConfigError that
+// names the layer which set the first invalid value
+func validationConfigError(err error, origins museconfig.Origins) error {
+	configErr := ConfigError{
+		Stage:  "validation",
+		Reason: "invalid configuration values",
+		Err:    err,
+	}
+
+	var errs museconfig.ValidationErrors
+	if errors.As(err, &errs) && len(errs) > 0 {
+		if origin, ok := origins[errs[0].Field]; ok {
+			configErr.Path = origin.String()
+		}
+	}
+	return configErr
 }
 
 // Environment represents captured environment state
@@ -218,123 +513,119 @@ func (cl *ConfigLoader) captureEnvVars() map[string]string {
 	return vars
 }
 
-// buildConfigPaths builds the list of configuration file paths to check
-func (cl *ConfigLoader) buildConfigPaths(env Environment) []string {
-	paths := []string{
-		"./muse.yaml", // Local directory
-	}
-
-	// Add XDG config path if available
+// globalConfigPath returns the global config file path from captured state
+func (cl *ConfigLoader) globalConfigPath(env Environment) string {
 	if env.XDGConfig != "" {
-		paths = append(paths, env.XDGConfig+"/muse/muse.yaml")
+		return filepath.Join(env.XDGConfig, "muse", "muse.yaml")
 	}
-
-	// Add home config path if available
 	if env.Home != "" {
-		paths = append(paths, env.Home+"/.config/muse/muse.yaml")
+		return filepath.Join(env.Home, ".config", "muse", "muse.yaml")
 	}
-
-	return paths
+	return ""
 }
 
-// loadConfigFile loads the first available configuration file
-func (cl *ConfigLoader) loadConfigFile(k *koanf.Koanf, configPaths []string) (string, error) {
-	// Try to load from files
-	for _, path := range configPaths {
-		if path == "" {
-			continue
+// loadConfigFiles loads the embedded defaults, the global config file and the
+// repository config file as separate layers
+func (cl *ConfigLoader) loadConfigFiles(env Environment, root string) ([]museconfig.Source, error) {
+	defaults, err := museconfig.DefaultsSource()
+	if err != nil {
+		return nil, ConfigError{
+			Stage:  "defaults_loading",
+			Reason: "failed to load default config",
+			Err:    err,
 		}
+	}
 
-		if _, err := os.Stat(path); err == nil {
-			if err := k.Load(file.Provider(path), yaml.Parser()); err != nil {
-				return "", ConfigError{
-					Stage:  "file_loading",
-					Path:   path,
-					Reason: "failed to load config file",
-					Err:    err,
-				}
-			}
-			slog.Debug("Loaded config from file", "path", path)
-			return path, nil
-		}
+	files := []struct {
+		layer string
+		path  string
+	}{
+		{museconfig.LayerGlobal, cl.globalConfigPath(env)},
+		{museconfig.LayerRepo, museconfig.RepoConfigPath(root)},
 	}
 
-	// Fallback to example config
-	if err := k.Load(rawbytes.Provider(museconfig.ExampleConfig), yaml.Parser()); err != nil {
-		return "", ConfigError{
-			Stage:  "example_loading",
-			Reason: "failed to load example config",
-			Err:    err,
+	sources := []museconfig.Source{defaults}
+	for _, f := range files {
+		source, err := museconfig.FileSource(f.layer, f.path)
+		if err != nil {
+			return nil, ConfigError{
+				Stage:  "file_loading",
+				Path:   f.path,
+				Reason: "failed to load config file",
+				Err:    err,
+			}
+		}
+		if source.Path != "" {
+			slog.Debug("Loaded config from file", "layer", f.layer, "path", source.Path)
 		}
+		sources = append(sources, source)
 	}
 
-	slog.Debug("Using example config")
-	return "embedded_example", nil
+	return sources, nil
 }
 
-// loadEnvironmentVariables loads environment variables safely
-func (cl *ConfigLoader) loadEnvironmentVariables(k *koanf.Koanf, env Environment) error {
+// loadEnvironmentVariables loads the MUSE_* variables using the mapping
+// documented by museconfig.EnvVars
+func (cl *ConfigLoader) loadEnvironmentVariables(env Environment) (museconfig.Source, error) {
 	// Create a custom environment provider using captured variables
 	envProvider := &SafeEnvProvider{
-		prefix:    "MUSE_",
+		prefix:    museconfig.EnvPrefix,
 		delimiter: ".",
 		variables: env.Variables,
 		transform: func(s string) string {
-			return strings.ReplaceAll(strings.ToLower(s), "_", ".")
+			name := museconfig.EnvPrefix + s
+			key, ok := museconfig.EnvKey(name)
+			if !ok {
+				if suggestion := museconfig.SuggestEnvName(name); suggestion != "" {
+					slog.Warn("Ignoring unsupported environment variable", "name", name, "did_you_mean", suggestion)
+				} else {
+					slog.Debug("Ignoring unsupported environment variable", "name", name)
+				}
+			}
+			return key
 		},
 	}
 
-	if err := k.Load(envProvider, nil); err != nil {
-		return ConfigError{
+	values, err := envProvider.Read()
+	if err != nil {
+		return museconfig.Source{}, ConfigError{
 			Stage:  "env_loading",
 			Reason: "failed to load environment variables",
 			Err:    err,
 		}
 	}
+	for key, value := range values {
+		if raw, ok := value.(string); ok {
+			values[key] = museconfig.EnvValue(key, raw)
+		}
+	}
 
-	return nil
+	// The provider yields flattened keys; nest them so they merge with file layers
+	return museconfig.FlatSource(museconfig.LayerEnv, values), nil
 }
 
-// handleAPIKeys safely handles API key configuration with environment fallback
+// handleAPIKeys checks the API key environment variable of the active
+// provider. The key itself is resolved by the provider through
+// internal/credentials, which also consults api_key_cmd, api_key_file and
+// the keyring, so it is not copied into the config.
 func (cl *ConfigLoader) handleAPIKeys(cfg *museconfig.Config, env Environment) error {
 	if cfg.LLM.Config == nil {
 		cfg.LLM.Config = make(map[string]any)
 	}
 
-	// Create a copy of the config map to avoid concurrent modification
-	configCopy := make(map[string]any)
-	for k, v := range cfg.LLM.Config {
-		configCopy[k] = v
+	envKey := credentials.EnvVar(cfg.LLM.Provider)
+	envValue, exists := env.Variables[envKey]
+	if !exists || envValue == "" {
+		return nil
 	}
 
-	// Handle API keys with environment fallback
-	for key := range configCopy {
-		envKey := strings.ToUpper(fmt.Sprintf("%s_API_KEY", key))
-		envValue, exists := env.Variables[envKey]
-
-		if exists && envValue != "" {
-			// Validate the credential before using it
-			if err := security.ValidateCredential(envValue); err != nil {
-				slog.Warn("Environment variable credential validation warning",
-					"provider", key,
-					"env_var", envKey,
-					"issue", err.Error(),
-					"masked_value", security.MaskCredential(envValue))
-			}
-
-			// Use environment variable (already captured atomically)
-			configCopy[key] = envValue
-
-			slog.Debug("Using API key from environment",
-				"provider", key,
-				"env_var", envKey,
-				"masked_value", security.MaskCredential(envValue))
-		}
+	if err := security.ValidateCredential(envValue); err != nil {
+		slog.Warn("Environment variable credential validation warning",
+			"provider", cfg.LLM.Provider,
+			"env_var", envKey,
+			"issue", err.Error(),
+			"masked_value", security.MaskCredential(envValue))
 	}
-
-	// Atomically replace the configuration
-	cfg.LLM.Config = configCopy
-
 	return nil
 }
 
@@ -363,9 +654,13 @@ func (p *SafeEnvProvider) Read() (map[string]interface{}, error) {
 		// Strip the prefix
 		key = strings.TrimPrefix(key, p.prefix)
 
-		// Transform the key if a transformer is provided
+		// Transform the key if a transformer is provided; an empty result
+		// skips the variable
 		if p.transform != nil {
 			key = p.transform(key)
+			if key == "" {
+				continue
+			}
 		}
 
 		// Set the value
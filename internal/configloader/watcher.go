@@ -0,0 +1,92 @@
+package configloader
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// configWatcher invalidates the config cache when a watched file changes.
+// Directories are watched rather than files so that editors which replace
+// files via rename, and files created after startup, are both noticed.
+type configWatcher struct {
+	mu       sync.Mutex
+	watcher  *fsnotify.Watcher
+	files    map[string]bool
+	dirs     map[string]bool
+	onChange func()
+}
+
+func newConfigWatcher(onChange func()) (*configWatcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	w := &configWatcher{
+		watcher:  watcher,
+		files:    make(map[string]bool),
+		dirs:     make(map[string]bool),
+		onChange: onChange,
+	}
+	go w.run()
+	return w, nil
+}
+
+// Watch starts watching path. Paths whose directory does not exist are
+// ignored.
+func (w *configWatcher) Watch(path string) {
+	path = filepath.Clean(path)
+	dir := filepath.Dir(path)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.files[path] = true
+	if w.dirs[dir] {
+		return
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return
+	}
+	if err := w.watcher.Add(dir); err != nil {
+		slog.Debug("Failed to watch config directory", "dir", dir, "error", err)
+		return
+	}
+	w.dirs[dir] = true
+}
+
+func (w *configWatcher) isWatched(path string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.files[filepath.Clean(path)]
+}
+
+func (w *configWatcher) run() {
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op == fsnotify.Chmod || !w.isWatched(event.Name) {
+				continue
+			}
+			slog.Debug("Config file changed; invalidating cache", "path", event.Name, "op", event.Op.String())
+			w.onChange()
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			slog.Debug("Config watcher error", "error", err)
+		}
+	}
+}
+
+// Close stops the watcher
+func (w *configWatcher) Close() error {
+	return w.watcher.Close()
+}
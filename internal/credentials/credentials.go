@@ -0,0 +1,259 @@
+package credentials
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/klauern/muse/config"
+	"github.com/klauern/muse/internal/fileops"
+)
+
+// Settings under llm.config that name a credential source
+const (
+	KeyAPIKey        = "api_key"
+	KeyAPIKeyCmd     = "api_key_cmd"
+	KeyAPIKeyTimeout = "api_key_cmd_timeout"
+	KeyAPIKeyFile    = "api_key_file"
+)
+
+// Settings lists every setting that configures the API key
+var Settings = []string{KeyAPIKey, KeyAPIKeyCmd, KeyAPIKeyTimeout, KeyAPIKeyFile}
+
+// DefaultCommandTimeout bounds api_key_cmd when api_key_cmd_timeout is unset
+const DefaultCommandTimeout = 10 * time.Second
+
+// Credential is a resolved API key and where it came from
+type Credential struct {
+	Key string
+	// Source describes the origin for diagnostics, e.g. "api_key_cmd"
+	Source string
+}
+
+// Resolver finds API keys for LLM providers
+type Resolver struct {
+	Keyring Keyring
+	Getenv  func(string) string
+	// Dir holds the fallback credential files written by 'muse auth login'
+	Dir string
+}
+
+// NewResolver returns a Resolver that uses the OS keyring, the process
+// environment and the muse config directory
+func NewResolver() *Resolver {
+	dir, err := config.Dir()
+	if err == nil {
+		dir = filepath.Join(dir, "credentials")
+	}
+	return &Resolver{
+		Keyring: SystemKeyring(),
+		Getenv:  os.Getenv,
+		Dir:     dir,
+	}
+}
+
+// Resolve returns the default resolver's credential for provider
+func Resolve(ctx context.Context, provider string, settings map[string]any) (Credential, error) {
+	return NewResolver().Resolve(ctx, provider, settings)
+}
+
+// EnvVar returns the environment variable holding provider's API key, e.g.
+// OPENAI_API_KEY
+func EnvVar(provider string) string {
+	return strings.ToUpper(strings.ReplaceAll(provider, "-", "_")) + "_API_KEY"
+}
+
+// Resolve finds the API key for provider from its llm.config settings. The
+// sources are tried in order: api_key, api_key_cmd, api_key_file, the
+// provider's *_API_KEY environment variable, the OS keyring and the file
+// written by 'muse auth login'. A configured source that fails is an error
+// rather than a reason to fall through to the next one. ErrNotFound is
+// returned when no source has a key.
+func (r *Resolver) Resolve(ctx context.Context, provider string, settings map[string]any) (Credential, error) {
+	if key, _ := settings[KeyAPIKey].(string); key != "" {
+		return Credential{Key: key, Source: "llm.config." + KeyAPIKey}, nil
+	}
+
+	if command, _ := settings[KeyAPIKeyCmd].(string); command != "" {
+		timeout := DefaultCommandTimeout
+		if value, _ := settings[KeyAPIKeyTimeout].(string); value != "" {
+			parsed, err := time.ParseDuration(value)
+			if err != nil {
+				return Credential{}, fmt.Errorf("invalid %s %q: %w", KeyAPIKeyTimeout, value, err)
+			}
+			timeout = parsed
+		}
+		key, err := RunCommand(ctx, command, timeout)
+		if err != nil {
+			return Credential{}, err
+		}
+		return Credential{Key: key, Source: "llm.config." + KeyAPIKeyCmd}, nil
+	}
+
+	if path, _ := settings[KeyAPIKeyFile].(string); path != "" {
+		key, err := ReadKeyFile(path)
+		if err != nil {
+			return Credential{}, err
+		}
+		return Credential{Key: key, Source: "llm.config." + KeyAPIKeyFile}, nil
+	}
+
+	envVar := EnvVar(provider)
+	if key := r.Getenv(envVar); key != "" {
+		return Credential{Key: key, Source: envVar}, nil
+	}
+
+	if r.Keyring != nil {
+		key, err := r.Keyring.Get(provider)
+		switch {
+		case err == nil:
+			return Credential{Key: key, Source: "keyring"}, nil
+		case !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrKeyringUnavailable):
+			return Credential{}, fmt.Errorf("failed to read %s key from keyring: %w", provider, err)
+		}
+	}
+
+	if path := r.filePath(provider); path != "" {
+		if _, err := os.Stat(path); err == nil {
+			key, err := ReadKeyFile(path)
+			if err != nil {
+				return Credential{}, err
+			}
+			return Credential{Key: key, Source: path}, nil
+		}
+	}
+
+	return Credential{}, fmt.Errorf("%w for provider %s", ErrNotFound, provider)
+}
+
+// Store saves a key for provider in the OS keyring, or in a private file in
+// the muse config directory when no keyring is available. It returns where
+// the key was stored.
+func (r *Resolver) Store(provider, key string) (string, error) {
+	if err := checkProvider(provider); err != nil {
+		return "", err
+	}
+	if r.Keyring != nil {
+		err := r.Keyring.Set(provider, key)
+		if err == nil {
+			return "keyring", nil
+		}
+		if !errors.Is(err, ErrKeyringUnavailable) {
+			return "", fmt.Errorf("failed to store %s key in keyring: %w", provider, err)
+		}
+	}
+
+	path := r.filePath(provider)
+	if path == "" {
+		return "", fmt.Errorf("no keyring or config directory available to store the key")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return "", fmt.Errorf("failed to create credentials directory: %w", err)
+	}
+	if err := fileops.AtomicWriteFile(path, []byte(key+"\n"), 0o600); err != nil {
+		return "", fmt.Errorf("failed to write credentials file: %w", err)
+	}
+	return path, nil
+}
+
+// Remove deletes any key stored for provider by Store
+func (r *Resolver) Remove(provider string) error {
+	if err := checkProvider(provider); err != nil {
+		return err
+	}
+	if r.Keyring != nil {
+		if err := r.Keyring.Delete(provider); err != nil &&
+			!errors.Is(err, ErrNotFound) && !errors.Is(err, ErrKeyringUnavailable) {
+			return fmt.Errorf("failed to remove %s key from keyring: %w", provider, err)
+		}
+	}
+	if path := r.filePath(provider); path != "" {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove credentials file: %w", err)
+		}
+	}
+	return nil
+}
+
+// checkProvider rejects provider names that are not a single file name, so
+// a name such as ../../.bashrc cannot reach outside the credentials directory
+func checkProvider(provider string) error {
+	if provider == "" || provider == "." || provider == ".." || filepath.Base(provider) != provider {
+		return fmt.Errorf("invalid provider name %q", provider)
+	}
+	return nil
+}
+
+func (r *Resolver) filePath(provider string) string {
+	if r.Dir == "" {
+		return ""
+	}
+	return filepath.Join(r.Dir, provider)
+}
+
+// RunCommand runs an api_key_cmd such as "pass show openai" through the
+// shell and returns the first line of its output
+func RunCommand(ctx context.Context, command string, timeout time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	shell, flag := "sh", "-c"
+	if runtime.GOOS == "windows" {
+		shell, flag = "cmd", "/C"
+	}
+	cmd := exec.CommandContext(ctx, shell, flag, command)
+	// Do not wait for children that keep the output pipes open after a kill
+	cmd.WaitDelay = time.Second
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return "", fmt.Errorf("%s timed out after %v", KeyAPIKeyCmd, timeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s failed: %w: %s", KeyAPIKeyCmd, err, msg)
+		}
+		return "", fmt.Errorf("%s failed: %w", KeyAPIKeyCmd, err)
+	}
+
+	key, _, _ := strings.Cut(stdout.String(), "\n")
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return "", fmt.Errorf("%s printed no key", KeyAPIKeyCmd)
+	}
+	return key, nil
+}
+
+// ReadKeyFile reads a key from the first line of path. Files that other
+// users can read are refused.
+func ReadKeyFile(path string) (string, error) {
+	path = config.ExpandHome(path)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", KeyAPIKeyFile, err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0o004 != 0 {
+		return "", fmt.Errorf("refusing to read %s: it is world-readable; run 'chmod 600 %s'", path, path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", KeyAPIKeyFile, err)
+	}
+	key, _, _ := strings.Cut(string(data), "\n")
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return "", fmt.Errorf("%s is empty", path)
+	}
+	return key, nil
+}
@@ -0,0 +1,306 @@
+package credentials
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeKeyring is an in-memory Keyring
+type fakeKeyring map[string]string
+
+func (k fakeKeyring) Get(provider string) (string, error) {
+	if secret, ok := k[provider]; ok {
+		return secret, nil
+	}
+	return "", ErrNotFound
+}
+
+func (k fakeKeyring) Set(provider, secret string) error {
+	k[provider] = secret
+	return nil
+}
+
+func (k fakeKeyring) Delete(provider string) error {
+	delete(k, provider)
+	return nil
+}
+
+func newTestResolver(t *testing.T, env map[string]string, keyring Keyring) *Resolver {
+	t.Helper()
+	return &Resolver{
+		Keyring: keyring,
+		Getenv:  func(key string) string { return env[key] },
+		Dir:     t.TempDir(),
+	}
+}
+
+func writeKeyFile(t *testing.T, content string, perm os.FileMode) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(path, []byte(content), perm); err != nil {
+		t.Fatalf("failed to write key file: %v", err)
+	}
+	if err := os.Chmod(path, perm); err != nil {
+		t.Fatalf("failed to chmod key file: %v", err)
+	}
+	return path
+}
+
+func TestResolver_Resolve(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("api_key_cmd tests use a POSIX shell")
+	}
+
+	privateFile := writeKeyFile(t, "sk-from-file\nsecond line ignored\n", 0o600)
+	publicFile := writeKeyFile(t, "sk-from-file\n", 0o644)
+
+	tests := []struct {
+		name       string
+		settings   map[string]any
+		env        map[string]string
+		keyring    fakeKeyring
+		wantKey    string
+		wantSource string
+		wantErr    string
+	}{
+		{
+			name:       "plain key wins",
+			settings:   map[string]any{"api_key": "sk-plain", "api_key_cmd": "echo sk-cmd"},
+			env:        map[string]string{"OPENAI_API_KEY": "sk-env"},
+			wantKey:    "sk-plain",
+			wantSource: "llm.config.api_key",
+		},
+		{
+			name:       "command",
+			settings:   map[string]any{"api_key_cmd": "printf 'sk-cmd\\nextra\\n'"},
+			wantKey:    "sk-cmd",
+			wantSource: "llm.config.api_key_cmd",
+		},
+		{
+			name:     "failing command does not fall through",
+			settings: map[string]any{"api_key_cmd": "echo locked >&2; exit 2"},
+			env:      map[string]string{"OPENAI_API_KEY": "sk-env"},
+			wantErr:  "locked",
+		},
+		{
+			name:     "command timeout",
+			settings: map[string]any{"api_key_cmd": "sleep 5", "api_key_cmd_timeout": "50ms"},
+			wantErr:  "timed out",
+		},
+		{
+			name:     "invalid timeout",
+			settings: map[string]any{"api_key_cmd": "echo sk-cmd", "api_key_cmd_timeout": "soon"},
+			wantErr:  "invalid api_key_cmd_timeout",
+		},
+		{
+			name:     "empty command output",
+			settings: map[string]any{"api_key_cmd": "true"},
+			wantErr:  "printed no key",
+		},
+		{
+			name:       "private file",
+			settings:   map[string]any{"api_key_file": privateFile},
+			wantKey:    "sk-from-file",
+			wantSource: "llm.config.api_key_file",
+		},
+		{
+			name:     "world-readable file",
+			settings: map[string]any{"api_key_file": publicFile},
+			wantErr:  "world-readable",
+		},
+		{
+			name:       "environment",
+			settings:   map[string]any{},
+			env:        map[string]string{"OPENAI_API_KEY": "sk-env"},
+			keyring:    fakeKeyring{"openai": "sk-keyring"},
+			wantKey:    "sk-env",
+			wantSource: "OPENAI_API_KEY",
+		},
+		{
+			name:       "keyring",
+			settings:   map[string]any{},
+			keyring:    fakeKeyring{"openai": "sk-keyring"},
+			wantKey:    "sk-keyring",
+			wantSource: "keyring",
+		},
+		{
+			name:     "nothing configured",
+			settings: map[string]any{},
+			keyring:  fakeKeyring{},
+			wantErr:  ErrNotFound.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolver := newTestResolver(t, tt.env, tt.keyring)
+
+			got, err := resolver.Resolve(context.Background(), "openai", tt.settings)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Resolve() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve() error = %v", err)
+			}
+			if got.Key != tt.wantKey || got.Source != tt.wantSource {
+				t.Errorf("Resolve() = %q from %q, want %q from %q", got.Key, got.Source, tt.wantKey, tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestResolver_StoreFallsBackToFile(t *testing.T) {
+	resolver := newTestResolver(t, nil, unavailableKeyring{})
+
+	location, err := resolver.Store("openai", "sk-stored")
+	if err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+	if location != filepath.Join(resolver.Dir, "openai") {
+		t.Errorf("Store() location = %q, want the credentials file", location)
+	}
+
+	info, err := os.Stat(location)
+	if err != nil {
+		t.Fatalf("credentials file missing: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("credentials file mode = %o, want 600", perm)
+	}
+
+	got, err := resolver.Resolve(context.Background(), "openai", nil)
+	if err != nil || got.Key != "sk-stored" {
+		t.Errorf("Resolve() = %+v, %v; want the stored key", got, err)
+	}
+
+	if err := resolver.Remove("openai"); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if _, err := resolver.Resolve(context.Background(), "openai", nil); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Resolve() after Remove() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestResolver_StorePrefersKeyring(t *testing.T) {
+	keyring := fakeKeyring{}
+	resolver := newTestResolver(t, nil, keyring)
+
+	location, err := resolver.Store("openai", "sk-stored")
+	if err != nil || location != "keyring" {
+		t.Fatalf("Store() = %q, %v; want keyring", location, err)
+	}
+	if keyring["openai"] != "sk-stored" {
+		t.Errorf("keyring entry = %q, want sk-stored", keyring["openai"])
+	}
+}
+
+func TestResolver_RejectsPathsAsProviders(t *testing.T) {
+	resolver := newTestResolver(t, nil, unavailableKeyring{})
+	resolver.Dir = filepath.Join(t.TempDir(), "credentials")
+
+	for _, provider := range []string{"../../.bashrc", "openai/../x", "..", ""} {
+		if location, err := resolver.Store(provider, "sk-stored"); err == nil {
+			t.Errorf("Store(%q) wrote %s, want an error", provider, location)
+		}
+		if err := resolver.Remove(provider); err == nil {
+			t.Errorf("Remove(%q) succeeded, want an error", provider)
+		}
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(resolver.Dir)); len(entries) != 0 {
+		t.Errorf("Store() wrote outside the credentials directory: %v", entries)
+	}
+}
+
+// TestSecretToolKeyring runs the Linux keyring against a fake secret-tool
+// that keeps entries in a directory
+func TestSecretToolKeyring(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("secret-tool is only used on Linux")
+	}
+
+	bin := t.TempDir()
+	store := t.TempDir()
+	script := `#!/bin/sh
+# args: <op> [--label L] service muse account <provider>
+op=$1; shift
+[ "$1" = "--label" ] && shift 2
+file="` + store + `/$4"
+case "$op" in
+  lookup) [ -f "$file" ] || exit 1; cat "$file" ;;
+  store) cat > "$file" ;;
+  clear) rm -f "$file" ;;
+esac
+`
+	if err := os.WriteFile(filepath.Join(bin, "secret-tool"), []byte(script), 0o755); err != nil {
+		t.Fatalf("failed to write fake secret-tool: %v", err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	keyring := &secretToolKeyring{timeout: 5 * time.Second}
+	if _, err := keyring.Get("openai"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get() on empty keyring error = %v, want ErrNotFound", err)
+	}
+	if err := keyring.Set("openai", "sk-secret"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if got, err := keyring.Get("openai"); err != nil || got != "sk-secret" {
+		t.Errorf("Get() = %q, %v; want sk-secret", got, err)
+	}
+	if err := keyring.Delete("openai"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := keyring.Get("openai"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() after Delete() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestSecretToolKeyring_Missing(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	keyring := &secretToolKeyring{timeout: time.Second}
+	if _, err := keyring.Get("openai"); !errors.Is(err, ErrKeyringUnavailable) {
+		t.Errorf("Get() error = %v, want ErrKeyringUnavailable", err)
+	}
+}
+
+// TestMacKeychain_SetKeepsSecretOffCommandLine runs Set against a fake
+// security that records its arguments and stdin
+func TestMacKeychain_SetKeepsSecretOffCommandLine(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake security is a shell script")
+	}
+
+	bin := t.TempDir()
+	out := t.TempDir()
+	script := `#!/bin/sh
+echo "$@" > "` + out + `/args"
+cat > "` + out + `/stdin"
+`
+	if err := os.WriteFile(filepath.Join(bin, "security"), []byte(script), 0o755); err != nil {
+		t.Fatalf("failed to write fake security: %v", err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	keyring := &macKeychain{timeout: 5 * time.Second}
+	if err := keyring.Set("open'ai", "sk-secret"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	args, _ := os.ReadFile(filepath.Join(out, "args"))
+	if strings.TrimSpace(string(args)) != "-i" {
+		t.Errorf("security args = %q, want only -i", args)
+	}
+	stdin, _ := os.ReadFile(filepath.Join(out, "stdin"))
+	want := `add-generic-password -U -s 'muse' -a 'open'"'"'ai' -X 736b2d736563726574` + "\n"
+	if string(stdin) != want {
+		t.Errorf("security stdin = %q, want %q", stdin, want)
+	}
+}
@@ -0,0 +1,162 @@
+package credentials
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/klauern/muse/internal/shell"
+)
+
+// KeyringService is the service name muse stores its secrets under
+const KeyringService = "muse"
+
+var (
+	// ErrNotFound is returned when a credential store has no entry
+	ErrNotFound = errors.New("credential not found")
+	// ErrKeyringUnavailable is returned when the OS keyring cannot be used
+	ErrKeyringUnavailable = errors.New("no supported keyring available")
+)
+
+// Keyring stores secrets in the operating system's credential store, keyed
+// by provider name
+type Keyring interface {
+	Get(provider string) (string, error)
+	Set(provider, secret string) error
+	Delete(provider string) error
+}
+
+// SystemKeyring returns the keyring of the current OS: the Secret Service via
+// secret-tool on Linux and the login keychain via security on macOS
+func SystemKeyring() Keyring {
+	switch runtime.GOOS {
+	case "linux", "freebsd", "openbsd", "netbsd":
+		return &secretToolKeyring{timeout: 10 * time.Second}
+	case "darwin":
+		return &macKeychain{timeout: 10 * time.Second}
+	}
+	return unavailableKeyring{}
+}
+
+// secretToolKeyring uses libsecret's secret-tool command
+type secretToolKeyring struct {
+	timeout time.Duration
+}
+
+func (k *secretToolKeyring) Get(provider string) (string, error) {
+	out, err := runTool(k.timeout, "", "secret-tool", "lookup", "service", KeyringService, "account", provider)
+	if err != nil {
+		var exitErr *exec.ExitError
+		// secret-tool exits 1 without output when nothing matches
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return "", ErrNotFound
+		}
+		return "", err
+	}
+	secret := strings.TrimSpace(out)
+	if secret == "" {
+		return "", ErrNotFound
+	}
+	return secret, nil
+}
+
+func (k *secretToolKeyring) Set(provider, secret string) error {
+	// The secret is passed on stdin so it never appears in the process list
+	_, err := runTool(k.timeout, secret, "secret-tool", "store",
+		"--label", fmt.Sprintf("muse %s API key", provider),
+		"service", KeyringService, "account", provider)
+	return err
+}
+
+func (k *secretToolKeyring) Delete(provider string) error {
+	_, err := runTool(k.timeout, "", "secret-tool", "clear", "service", KeyringService, "account", provider)
+	return err
+}
+
+// macKeychain uses the macOS security command
+type macKeychain struct {
+	timeout time.Duration
+}
+
+// errSecItemNotFound is the exit status of security when no item matches
+const errSecItemNotFound = 44
+
+func (k *macKeychain) Get(provider string) (string, error) {
+	out, err := runTool(k.timeout, "", "security", "find-generic-password", "-s", KeyringService, "-a", provider, "-w")
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == errSecItemNotFound {
+			return "", ErrNotFound
+		}
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+func (k *macKeychain) Set(provider, secret string) error {
+	// The command is passed on stdin to security's interactive mode so the
+	// secret never appears in the process list
+	_, err := runTool(k.timeout, addGenericPasswordCommand(provider, secret), "security", "-i")
+	return err
+}
+
+// addGenericPasswordCommand returns the security command line that stores
+// secret. security splits the line like a shell, so the names are quoted;
+// the secret is hex encoded with -X, so it needs no quoting.
+func addGenericPasswordCommand(provider, secret string) string {
+	return fmt.Sprintf("add-generic-password -U -s %s -a %s -X %s\n",
+		shell.Quote(KeyringService), shell.Quote(provider), hex.EncodeToString([]byte(secret)))
+}
+
+func (k *macKeychain) Delete(provider string) error {
+	_, err := runTool(k.timeout, "", "security", "delete-generic-password", "-s", KeyringService, "-a", provider)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == errSecItemNotFound {
+		return nil
+	}
+	return err
+}
+
+type unavailableKeyring struct{}
+
+func (unavailableKeyring) Get(string) (string, error) { return "", ErrKeyringUnavailable }
+func (unavailableKeyring) Set(string, string) error   { return ErrKeyringUnavailable }
+func (unavailableKeyring) Delete(string) error        { return ErrKeyringUnavailable }
+
+// runTool runs a keyring helper and returns its stdout. A missing helper is
+// reported as ErrKeyringUnavailable.
+func runTool(timeout time.Duration, stdin, name string, args ...string) (string, error) {
+	if _, err := exec.LookPath(name); err != nil {
+		return "", fmt.Errorf("%w: %s not found", ErrKeyringUnavailable, name)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	// Do not wait for children that keep the output pipes open after a kill
+	cmd.WaitDelay = time.Second
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return "", fmt.Errorf("%s timed out after %v", name, timeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s failed: %w: %s", name, err, msg)
+		}
+		return "", fmt.Errorf("%s failed: %w", name, err)
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,134 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/klauern/muse/llm"
+)
+
+// ErrUnavailable is returned when no daemon is listening on the socket
+var ErrUnavailable = errors.New("muse daemon is not running")
+
+// Client talks to a daemon over its Unix socket
+type Client struct {
+	SocketPath string
+	http       *http.Client
+}
+
+// NewClient returns a client for the daemon listening on socketPath
+func NewClient(socketPath string) *Client {
+	dialer := &net.Dialer{Timeout: time.Second}
+	return &Client{
+		SocketPath: socketPath,
+		http: &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					// Make sure the socket is ours before sending it a diff
+					if err := checkSocket(socketPath); err != nil {
+						return nil, err
+					}
+					return dialer.DialContext(ctx, "unix", socketPath)
+				},
+			},
+		},
+	}
+}
+
+func (c *Client) Status(ctx context.Context) (StatusResponse, error) {
+	var resp StatusResponse
+	err := c.do(ctx, http.MethodGet, PathStatus, nil, &resp)
+	return resp, err
+}
+
+func (c *Client) Generate(ctx context.Context, req GenerateRequest) (string, error) {
+	var resp GenerateResponse
+	if err := c.do(ctx, http.MethodPost, PathGenerate, req, &resp); err != nil {
+		return "", err
+	}
+	llm.RecordUsage(ctx, resp.Usage)
+	return resp.Message, nil
+}
+
+func (c *Client) Lint(ctx context.Context, req LintRequest) (LintResponse, error) {
+	var resp LintResponse
+	err := c.do(ctx, http.MethodPost, PathLint, req, &resp)
+	return resp, err
+}
+
+func (c *Client) Summarize(ctx context.Context, req SummarizeRequest) (string, error) {
+	var resp SummarizeResponse
+	if err := c.do(ctx, http.MethodPost, PathSummarize, req, &resp); err != nil {
+		return "", err
+	}
+	return resp.Summary, nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	// The host is ignored; every request goes to the socket
+	req, err := http.NewRequestWithContext(ctx, method, "http://muse"+path, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		var opErr *net.OpError
+		if errors.As(err, &opErr) && opErr.Op == "dial" || errors.Is(err, fs.ErrNotExist) || errors.Is(err, ErrUntrusted) {
+			return fmt.Errorf("%w: %w", ErrUnavailable, err)
+		}
+		return fmt.Errorf("daemon request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp errorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&errResp); err == nil && errResp.Error != "" {
+			return remoteError(errResp)
+		}
+		return fmt.Errorf("daemon returned %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode daemon response: %w", err)
+	}
+	return nil
+}
+
+// remoteError turns an error reported by the daemon back into an error that
+// matches llm.ErrAuth or llm.ErrRateLimit when the daemon classified it
+func remoteError(resp errorResponse) error {
+	switch resp.Kind {
+	case kindAuth:
+		return &daemonError{message: resp.Error, kind: llm.ErrAuth}
+	case kindRateLimit:
+		return &daemonError{message: resp.Error, kind: llm.ErrRateLimit}
+	}
+	return fmt.Errorf("daemon: %s", resp.Error)
+}
+
+type daemonError struct {
+	message string
+	kind    error
+}
+
+func (e *daemonError) Error() string { return "daemon: " + e.message }
+
+func (e *daemonError) Unwrap() error { return e.kind }
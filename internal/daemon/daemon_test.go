@@ -0,0 +1,325 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/klauern/muse/config"
+	"github.com/klauern/muse/llm"
+	"github.com/klauern/muse/templates"
+)
+
+const testProvider = "daemon-test"
+
+// fakeService answers every prompt with a fixed message and counts calls.
+// Diffs containing "denied" fail as if the credentials were rejected.
+type fakeService struct {
+	calls *atomic.Int32
+}
+
+func (s fakeService) GenerateCommitMessage(ctx context.Context, diff string, style templates.CommitStyle) (string, error) {
+	s.calls.Add(1)
+	if strings.Contains(diff, "denied") {
+		return "", fmt.Errorf("%w: invalid api key", llm.ErrAuth)
+	}
+	llm.RecordUsage(ctx, llm.Usage{Requests: 1, PromptTokens: 100, CompletionTokens: 10})
+	if examples := llm.ExamplesFrom(ctx); len(examples) > 0 {
+		return fmt.Sprintf("feat: %s (%d examples)", strings.TrimSpace(diff), len(examples)), nil
+	}
+	return "feat: " + strings.TrimSpace(diff), nil
+}
+
+func (s fakeService) Complete(ctx context.Context, prompt string) (string, error) {
+	s.calls.Add(1)
+	return "summary", nil
+}
+
+type fakeProvider struct {
+	calls *atomic.Int32
+}
+
+func (p fakeProvider) NewService(map[string]any) (llm.LLMService, error) {
+	return fakeService{calls: p.calls}, nil
+}
+
+func registerFakeProvider() *atomic.Int32 {
+	calls := &atomic.Int32{}
+	llm.RegisterProvider(testProvider, fakeProvider{calls: calls})
+	return calls
+}
+
+func testConfig() *config.Config {
+	return &config.Config{
+		LLM:   config.LLMConfig{Provider: testProvider, Config: map[string]any{"model": "fake"}},
+		Cache: config.CacheConfig{Enabled: true, TTL: "1h"},
+	}
+}
+
+// startServer runs a daemon on a socket in a temporary directory
+func startServer(t *testing.T) *Client {
+	t.Helper()
+	// Unix socket paths are limited to ~100 bytes, so avoid t.TempDir()'s
+	// long test-named path
+	socket := filepath.Join(shortTempDir(t), "muse.sock")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	server := NewServer(testConfig().Cache, "test")
+	server.LoadConfig = func(dir, profile string) (*config.Config, error) {
+		cfg := testConfig()
+		cfg.LLM.Config["api_key"] = "sk-daemon"
+		return cfg, nil
+	}
+	go func() { done <- server.ListenAndServe(ctx, socket) }()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("ListenAndServe() error = %v", err)
+		}
+	})
+
+	client := NewClient(socket)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := client.Status(context.Background()); err == nil {
+			return client
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("daemon did not start")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func shortTempDir(t *testing.T) string {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "muse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+func TestServer_GenerateUsesCache(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	calls := registerFakeProvider()
+	client := startServer(t)
+
+	req := GenerateRequest{LLM: testConfig().LLM, Dir: t.TempDir(), Style: "conventional", Diff: "+change"}
+	for i := 0; i < 2; i++ {
+		message, err := client.Generate(context.Background(), req)
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+		if message != "feat: +change" {
+			t.Errorf("Generate() = %q", message)
+		}
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("provider called %d times, want 1 thanks to the cache", got)
+	}
+
+	// Examples change the prompt, so they miss the cache
+	req.Examples = []templates.Example{{Generated: "feat: a", Committed: "feat(x): a"}}
+	message, err := client.Generate(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if message != "feat: +change (1 examples)" {
+		t.Errorf("Generate() with examples = %q, want a fresh message", message)
+	}
+	req.Examples = nil
+
+	req.NoCache = true
+	if _, err := client.Generate(context.Background(), req); err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("provider called %d times, want 3 with no_cache", got)
+	}
+}
+
+func TestServer_GenerateReportsErrors(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	client := startServer(t)
+
+	_, err := client.Generate(context.Background(), GenerateRequest{
+		LLM:  config.LLMConfig{Provider: "no-such-provider"},
+		Dir:  t.TempDir(),
+		Diff: "+change",
+	})
+	if err == nil || !strings.Contains(err.Error(), "unsupported LLM provider") {
+		t.Errorf("Generate() error = %v, want the provider error", err)
+	}
+	if errors.Is(err, ErrUnavailable) {
+		t.Error("a provider error must not look like a missing daemon")
+	}
+}
+
+func TestServer_GenerateForwardsUsageAndErrorKinds(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	registerFakeProvider()
+	client := startServer(t)
+
+	ctx, usage := llm.TrackUsage(context.Background())
+	req := GenerateRequest{LLM: testConfig().LLM, Dir: t.TempDir(), Style: "conventional", Diff: "+usage", NoCache: true}
+	if _, err := client.Generate(ctx, req); err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if got := usage(); got.Requests != 1 || got.TotalTokens() != 110 {
+		t.Errorf("usage = %+v, want the provider's usage", got)
+	}
+
+	req.Diff = "+denied"
+	_, err := client.Generate(context.Background(), req)
+	if !errors.Is(err, llm.ErrAuth) || !strings.Contains(err.Error(), "invalid api key") {
+		t.Errorf("Generate() error = %v, want llm.ErrAuth", err)
+	}
+}
+
+func TestGenerator_ForwardsExamples(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	registerFakeProvider()
+	client := startServer(t)
+
+	cfg := testConfig()
+	cfg.Cache.Enabled = false
+	generator := &Generator{Client: client, Config: cfg, Dir: t.TempDir()}
+	ctx := llm.WithExamples(context.Background(), []templates.Example{{Generated: "feat: a", Committed: "feat(x): a"}})
+	message, err := generator.Generate(ctx, "+examples", "conventional")
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if message != "feat: +examples (1 examples)" {
+		t.Errorf("Generate() = %q, want the examples to reach the provider", message)
+	}
+}
+
+func TestServer_LintAndSummarize(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	registerFakeProvider()
+	client := startServer(t)
+
+	lintResp, err := client.Lint(context.Background(), LintRequest{Message: "Update README", Style: "conventional"})
+	if err != nil {
+		t.Fatalf("Lint() error = %v", err)
+	}
+	if len(lintResp.Issues) == 0 || lintResp.Issues[0].Rule != "header-format" {
+		t.Errorf("Lint() = %+v, want a header-format issue", lintResp.Issues)
+	}
+
+	summary, err := client.Summarize(context.Background(), SummarizeRequest{LLM: testConfig().LLM, Dir: t.TempDir(), Commits: "abc feat: x"})
+	if err != nil || summary != "summary" {
+		t.Errorf("Summarize() = %q, %v", summary, err)
+	}
+}
+
+// keyProvider records the API key its services are created with
+type keyProvider struct {
+	key *atomic.Value
+}
+
+func (p keyProvider) NewService(settings map[string]any) (llm.LLMService, error) {
+	p.key.Store(fmt.Sprint(settings["api_key"]))
+	return fakeService{calls: &atomic.Int32{}}, nil
+}
+
+func TestGenerator_DaemonResolvesCredentials(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	key := &atomic.Value{}
+	llm.RegisterProvider(testProvider, keyProvider{key: key})
+	client := startServer(t)
+
+	cfg := testConfig()
+	cfg.LLM.Config["api_key"] = "sk-client"
+	generator := &Generator{Client: client, Config: cfg, Dir: t.TempDir()}
+	if _, err := generator.Generate(context.Background(), "+keys", "conventional"); err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if got := key.Load(); got != "sk-daemon" {
+		t.Errorf("daemon used API key %v, want the one from its own config", got)
+	}
+	if cfg.LLM.Config["api_key"] != "sk-client" {
+		t.Error("Generate() modified the client's config")
+	}
+
+	_, err := client.Generate(context.Background(), GenerateRequest{LLM: testConfig().LLM, Dir: "relative", Diff: "+x"})
+	if err == nil || !strings.Contains(err.Error(), "absolute path") {
+		t.Errorf("Generate() with a relative dir error = %v", err)
+	}
+}
+
+func TestServer_RefusesSharedSocketDir(t *testing.T) {
+	dir := shortTempDir(t)
+	if err := os.Chmod(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	server := NewServer(config.CacheConfig{}, "test")
+	err := server.ListenAndServe(context.Background(), filepath.Join(dir, "muse.sock"))
+	if !errors.Is(err, ErrUntrusted) {
+		t.Errorf("ListenAndServe() in a directory others can enter error = %v, want ErrUntrusted", err)
+	}
+}
+
+func TestGenerator_SkipsUntrustedSocket(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	calls := registerFakeProvider()
+	dir := filepath.Dir(startServer(t).SocketPath)
+	if err := os.Chmod(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chmod(dir, 0o700) })
+
+	// A new client, as each hook run has, so no connection is reused
+	client := NewClient(filepath.Join(dir, "muse.sock"))
+	if _, err := client.Status(context.Background()); !errors.Is(err, ErrUnavailable) || !errors.Is(err, ErrUntrusted) {
+		t.Errorf("Status() error = %v, want ErrUnavailable and ErrUntrusted", err)
+	}
+	generator := &Generator{Client: client, Config: testConfig(), Dir: t.TempDir()}
+	if _, err := generator.Generate(context.Background(), "+local", "conventional"); err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("provider called %d times in-process, want 1", calls.Load())
+	}
+}
+
+func TestServer_RefusesSecondDaemon(t *testing.T) {
+	client := startServer(t)
+
+	server := NewServer(config.CacheConfig{}, "test")
+	err := server.ListenAndServe(context.Background(), client.SocketPath)
+	if err == nil || !strings.Contains(err.Error(), "already listening") {
+		t.Errorf("ListenAndServe() error = %v, want already listening", err)
+	}
+}
+
+func TestGenerator_FallsBackInProcess(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	calls := registerFakeProvider()
+
+	generator := &Generator{
+		Client: NewClient(filepath.Join(t.TempDir(), "missing.sock")),
+		Config: testConfig(),
+	}
+	message, err := generator.Generate(context.Background(), "+local", "conventional")
+	if err != nil || message != "feat: +local" {
+		t.Fatalf("Generate() = %q, %v; want the in-process message", message, err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("provider called %d times, want 1", calls.Load())
+	}
+
+	if _, err := generator.Client.Status(context.Background()); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Status() error = %v, want ErrUnavailable", err)
+	}
+}
@@ -0,0 +1,77 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"maps"
+	"os"
+
+	"github.com/klauern/muse/config"
+	"github.com/klauern/muse/internal/credentials"
+	"github.com/klauern/muse/llm"
+	"github.com/klauern/muse/templates"
+)
+
+// Generator generates messages through a running daemon and falls back to
+// generating in-process when none is listening
+type Generator struct {
+	Client *Client
+	Config *config.Config
+	// Dir is the directory Config was loaded for, where the daemon finds the
+	// API key settings
+	Dir string
+
+	inProcess llm.Generator
+}
+
+// NewGenerator returns a Generator for the daemon on the default socket,
+// with Config loaded in the working directory
+func NewGenerator(cfg *config.Config) *Generator {
+	dir, _ := os.Getwd()
+	return &Generator{Client: NewClient(DefaultSocketPath()), Config: cfg, Dir: dir}
+}
+
+func (g *Generator) Generate(ctx context.Context, diff string, commitStyle templates.CommitStyle) (string, error) {
+	if _, err := os.Stat(g.Client.SocketPath); err == nil {
+		message, err := g.Client.Generate(ctx, GenerateRequest{
+			LLM:      withoutCredentials(g.Config.LLM),
+			Dir:      g.Dir,
+			Profile:  g.Config.Profile,
+			Style:    commitStyle,
+			Diff:     diff,
+			NoCache:  !g.Config.Cache.Enabled,
+			Examples: llm.ExamplesFrom(ctx),
+		})
+		if !errors.Is(err, ErrUnavailable) {
+			if err == nil {
+				slog.Debug("Generated commit message with the daemon", "socket", g.Client.SocketPath)
+			}
+			return message, err
+		}
+		if errors.Is(err, ErrUntrusted) {
+			slog.Warn("Not using the muse daemon; generating in-process", "error", err)
+		} else {
+			slog.Debug("Daemon not reachable; generating in-process", "error", err)
+		}
+	}
+
+	if g.inProcess == nil {
+		generator, err := llm.NewCommitMessageGenerator(g.Config)
+		if err != nil {
+			return "", err
+		}
+		g.inProcess = generator
+	}
+	return g.inProcess.Generate(ctx, diff, commitStyle)
+}
+
+// withoutCredentials returns llmCfg without its API key settings, which
+// never leave the process
+func withoutCredentials(llmCfg config.LLMConfig) config.LLMConfig {
+	llmCfg.Config = maps.Clone(llmCfg.Config)
+	for _, key := range credentials.Settings {
+		delete(llmCfg.Config, key)
+	}
+	return llmCfg
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package daemon
+
+import "os"
+
+// fileOwner reports that ownership is unknown where files have no uid
+func fileOwner(info os.FileInfo) (int, bool) {
+	return 0, false
+}
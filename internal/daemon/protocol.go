@@ -0,0 +1,152 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/klauern/muse/config"
+	"github.com/klauern/muse/internal/lint"
+	"github.com/klauern/muse/llm"
+	"github.com/klauern/muse/templates"
+)
+
+// APIVersion prefixes every endpoint, so clients and daemons built from
+// different releases fail clearly instead of misreading each other
+const APIVersion = "v1"
+
+// Endpoints served on the socket
+const (
+	PathStatus    = "/" + APIVersion + "/status"
+	PathGenerate  = "/" + APIVersion + "/generate"
+	PathLint      = "/" + APIVersion + "/lint"
+	PathSummarize = "/" + APIVersion + "/summarize"
+)
+
+// GenerateRequest asks for a commit message for a staged diff. The client
+// sends its effective llm settings, so repository and profile config apply
+// even though the daemon is shared. API key settings are left out: the
+// daemon reads them from the config of Dir with Profile applied, and
+// resolves the key itself.
+type GenerateRequest struct {
+	LLM config.LLMConfig `json:"llm"`
+	// Dir is the absolute path of the client's working directory
+	Dir     string                `json:"dir"`
+	Profile string                `json:"profile,omitempty"`
+	Style   templates.CommitStyle `json:"style"`
+	Diff    string                `json:"diff"`
+	NoCache bool                  `json:"no_cache,omitempty"`
+	// Examples are the client's edited messages for the repository
+	Examples []templates.Example `json:"examples,omitempty"`
+}
+
+type GenerateResponse struct {
+	Message string `json:"message"`
+	// Usage is the provider usage of the generation, zero for cache hits
+	Usage llm.Usage `json:"usage"`
+}
+
+type LintRequest struct {
+	Message string                `json:"message"`
+	Style   templates.CommitStyle `json:"style"`
+}
+
+type LintResponse struct {
+	Issues []lint.Issue `json:"issues"`
+}
+
+// SummarizeRequest asks for a summary of a commit log. Its settings are
+// sent as those of GenerateRequest.
+type SummarizeRequest struct {
+	LLM     config.LLMConfig `json:"llm"`
+	Dir     string           `json:"dir"`
+	Profile string           `json:"profile,omitempty"`
+	Commits string           `json:"commits"`
+}
+
+type SummarizeResponse struct {
+	Summary string `json:"summary"`
+}
+
+type StatusResponse struct {
+	Version   string    `json:"version"`
+	PID       int       `json:"pid"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+	// Kind classifies provider errors so clients can tell them apart
+	Kind string `json:"kind,omitempty"`
+}
+
+// Kinds of errorResponse
+const (
+	kindAuth      = "auth"
+	kindRateLimit = "rate_limit"
+)
+
+// ErrUntrusted is returned for a socket or socket directory that another
+// user could have created, since whoever listens on it receives every diff
+var ErrUntrusted = errors.New("untrusted daemon socket")
+
+// DefaultSocketPath returns the socket under $XDG_RUNTIME_DIR, or a
+// per-user directory in the system temp dir when that is not set
+func DefaultSocketPath() string {
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), fmt.Sprintf("muse-%d", os.Getuid()))
+	} else {
+		dir = filepath.Join(dir, "muse")
+	}
+	return filepath.Join(dir, "muse.sock")
+}
+
+// checkSocketDir returns ErrUntrusted unless dir is a directory owned by the
+// current user that nobody else can enter. The fallback directory in the
+// system temp dir has a predictable name, so another user may have created
+// it first.
+func checkSocketDir(dir string) error {
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%w: %s is not a directory", ErrUntrusted, dir)
+	}
+	if _, ok := fileOwner(info); !ok {
+		// Modes mean nothing where files have no owner
+		return nil
+	}
+	if err := checkOwner(dir, info); err != nil {
+		return err
+	}
+	if perm := info.Mode().Perm(); perm != 0o700 {
+		return fmt.Errorf("%w: %s has mode %o; run 'chmod 700 %s'", ErrUntrusted, dir, perm, dir)
+	}
+	return nil
+}
+
+// checkSocket returns ErrUntrusted unless the socket at path and its
+// directory belong to the current user
+func checkSocket(path string) error {
+	if err := checkSocketDir(filepath.Dir(path)); err != nil {
+		return err
+	}
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+	return checkOwner(path, info)
+}
+
+// checkOwner returns ErrUntrusted when the file at path described by info
+// belongs to another user
+func checkOwner(path string, info os.FileInfo) error {
+	if uid, ok := fileOwner(info); ok && uid != os.Getuid() {
+		return fmt.Errorf("%w: %s belongs to uid %d", ErrUntrusted, path, uid)
+	}
+	return nil
+}
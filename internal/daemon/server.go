@@ -0,0 +1,275 @@
+package daemon
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/klauern/muse/config"
+	"github.com/klauern/muse/internal/configloader"
+	"github.com/klauern/muse/internal/credentials"
+	"github.com/klauern/muse/internal/lint"
+	"github.com/klauern/muse/llm"
+)
+
+// maxRequestSize bounds request bodies; diffs are limited to 1MB already
+const maxRequestSize = 4 * 1024 * 1024
+
+// Server answers generate, lint and summarize requests. It keeps one
+// generator per distinct llm config, so provider clients and their HTTP
+// connections stay warm between requests.
+type Server struct {
+	// LoadConfig loads the config of the repository containing dir with
+	// profile applied, for its API key settings. When nil, config is loaded
+	// as the hook would load it there.
+	LoadConfig func(dir, profile string) (*config.Config, error)
+
+	cache     config.CacheConfig
+	version   string
+	startedAt time.Time
+
+	mu         sync.Mutex
+	generators map[string]*llm.CommitMessageGenerator
+}
+
+// NewServer returns a server that caches responses according to cacheCfg
+func NewServer(cacheCfg config.CacheConfig, version string) *Server {
+	return &Server{
+		cache:      cacheCfg,
+		version:    version,
+		startedAt:  time.Now(),
+		generators: make(map[string]*llm.CommitMessageGenerator),
+	}
+}
+
+// Handler returns the HTTP API
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+PathStatus, s.handleStatus)
+	mux.HandleFunc("POST "+PathGenerate, s.handleGenerate)
+	mux.HandleFunc("POST "+PathLint, s.handleLint)
+	mux.HandleFunc("POST "+PathSummarize, s.handleSummarize)
+	return mux
+}
+
+// ListenAndServe serves on a Unix socket at path until ctx is done. The
+// socket is only accessible to the current user. A stale socket left by a
+// daemon that crashed is replaced; a live one is an error.
+func (s *Server) ListenAndServe(ctx context.Context, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create socket directory: %w", err)
+	}
+	if err := checkSocketDir(filepath.Dir(path)); err != nil {
+		return fmt.Errorf("refusing to listen on %s: %w", path, err)
+	}
+	if _, err := os.Stat(path); err == nil {
+		if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+			conn.Close()
+			return fmt.Errorf("a muse daemon is already listening on %s", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("failed to remove stale socket: %w", err)
+		}
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+	defer os.Remove(path)
+	if err := os.Chmod(path, 0o600); err != nil {
+		listener.Close()
+		return fmt.Errorf("failed to restrict socket permissions: %w", err)
+	}
+
+	server := &http.Server{Handler: s.Handler(), ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	slog.Info("muse daemon listening", "socket", path)
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("daemon stopped: %w", err)
+	}
+	return nil
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, StatusResponse{Version: s.version, PID: os.Getpid(), StartedAt: s.startedAt})
+}
+
+func (s *Server) handleGenerate(w http.ResponseWriter, r *http.Request) {
+	var req GenerateRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+
+	generator, err := s.generator(req.LLM, req.Dir, req.Profile, !req.NoCache)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	ctx, usage := llm.TrackUsage(llm.WithExamples(r.Context(), req.Examples))
+	message, err := generator.Generate(ctx, req.Diff, req.Style)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, GenerateResponse{Message: message, Usage: usage()})
+}
+
+func (s *Server) handleLint(w http.ResponseWriter, r *http.Request) {
+	var req LintRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+	issues := lint.Lint(req.Message, req.Style)
+	if issues == nil {
+		issues = []lint.Issue{}
+	}
+	writeJSON(w, http.StatusOK, LintResponse{Issues: issues})
+}
+
+func (s *Server) handleSummarize(w http.ResponseWriter, r *http.Request) {
+	var req SummarizeRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+
+	generator, err := s.generator(req.LLM, req.Dir, req.Profile, true)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	service, err := generator.Service()
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	summary, err := llm.Summarize(r.Context(), service, req.Commits)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, SummarizeResponse{Summary: summary})
+}
+
+// generator returns the generator for an llm config, creating it on first
+// use. The API key settings come from the config the daemon loads for dir
+// and profile, never from the request.
+func (s *Server) generator(llmCfg config.LLMConfig, dir, profile string, useCache bool) (*llm.CommitMessageGenerator, error) {
+	llmCfg, err := s.withCredentials(llmCfg, dir, profile)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(struct {
+		LLM   config.LLMConfig
+		Cache bool
+	}{llmCfg, useCache})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode llm config: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	key := hex.EncodeToString(sum[:])
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if generator, ok := s.generators[key]; ok {
+		return generator, nil
+	}
+
+	cfg := &config.Config{LLM: llmCfg, Cache: s.cache}
+	cfg.Cache.Enabled = s.cache.Enabled && useCache
+	generator, err := llm.NewCommitMessageGenerator(cfg)
+	if err != nil {
+		return nil, err
+	}
+	s.generators[key] = generator
+	return generator, nil
+}
+
+// withCredentials replaces the API key settings of llmCfg with those of the
+// config of dir with profile applied, when that config uses the same
+// provider
+func (s *Server) withCredentials(llmCfg config.LLMConfig, dir, profile string) (config.LLMConfig, error) {
+	if !filepath.IsAbs(dir) {
+		return llmCfg, fmt.Errorf("request dir must be an absolute path, got %q", dir)
+	}
+	load := s.LoadConfig
+	if load == nil {
+		load = loadConfig
+	}
+	cfg, err := load(dir, profile)
+	if err != nil {
+		return llmCfg, err
+	}
+
+	settings := maps.Clone(llmCfg.Config)
+	if settings == nil {
+		settings = map[string]any{}
+	}
+	for _, key := range credentials.Settings {
+		delete(settings, key)
+		if value, ok := cfg.LLM.Config[key]; ok && cfg.LLM.Provider == llmCfg.Provider {
+			settings[key] = value
+		}
+	}
+	llmCfg.Config = settings
+	return llmCfg, nil
+}
+
+// loadConfig loads the config of the repository containing dir as the hook
+// would load it there
+func loadConfig(dir, profile string) (*config.Config, error) {
+	loader := configloader.NewRepoLoader(dir)
+	defer loader.Close()
+	if profile != "" {
+		loader.SetOverrides(map[string]any{"profile": profile})
+	}
+	cfg, err := loader.LoadConfigSafe()
+	if err != nil {
+		return nil, fmt.Errorf("error loading config for %s: %w", dir, err)
+	}
+	return cfg, nil
+}
+
+func readJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize)).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Debug("Failed to write response", "error", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	resp := errorResponse{Error: err.Error()}
+	switch {
+	case errors.Is(err, llm.ErrAuth):
+		resp.Kind = kindAuth
+	case errors.Is(err, llm.ErrRateLimit):
+		resp.Kind = kindRateLimit
+	}
+	writeJSON(w, status, resp)
+}
@@ -0,0 +1,131 @@
+package diff
+
+import (
+	"strings"
+)
+
+// File is the part of a unified diff that changes one file
+type File struct {
+	// OldPath and NewPath are /dev/null for created and deleted files
+	OldPath string
+	NewPath string
+	// Header holds the lines before the first hunk: diff --git, index,
+	// mode, rename and ---/+++ lines
+	Header []string
+	Hunks  []Hunk
+}
+
+// Hunk is a single @@ section of a file diff
+type Hunk struct {
+	// Header is the @@ line
+	Header string
+	Lines  []string
+}
+
+// Path returns the file's path after the change, or before it for
+// deletions
+func (f *File) Path() string {
+	if f.NewPath == "/dev/null" {
+		return f.OldPath
+	}
+	return f.NewPath
+}
+
+// metadataPrefixes start the header lines that change a file beyond its
+// content
+var metadataPrefixes = []string{
+	"rename from ", "copy from ", "old mode ", "new file mode ", "deleted file mode ",
+}
+
+// ChangesMetadata reports whether the header renames, copies, creates or
+// deletes the file or changes its mode. Such a header can only be applied
+// once, so its hunks cannot be applied separately.
+func (f *File) ChangesMetadata() bool {
+	for _, line := range f.Header {
+		for _, prefix := range metadataPrefixes {
+			if strings.HasPrefix(line, prefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// String renders the hunk as it appears in a diff
+func (h Hunk) String() string {
+	return h.Header + "\n" + strings.Join(h.Lines, "\n") + "\n"
+}
+
+// Parse splits the output of git diff into files and hunks. Files without
+// hunks, such as binary files, pure renames and mode changes, have only a
+// header.
+func Parse(text string) []*File {
+	var files []*File
+	var file *File
+	var hunk *Hunk
+
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	for _, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			file = &File{Header: []string{line}}
+			file.OldPath, file.NewPath = pathsFromGitHeader(line)
+			files = append(files, file)
+			hunk = nil
+		case file == nil:
+			// Text before the first file, such as git show's commit header
+			continue
+		case strings.HasPrefix(line, "@@"):
+			file.Hunks = append(file.Hunks, Hunk{Header: line})
+			hunk = &file.Hunks[len(file.Hunks)-1]
+		case hunk != nil:
+			hunk.Lines = append(hunk.Lines, line)
+		default:
+			file.Header = append(file.Header, line)
+			if p, ok := strings.CutPrefix(line, "--- "); ok {
+				file.OldPath = trimPathPrefix(p, "a/")
+			} else if p, ok := strings.CutPrefix(line, "+++ "); ok {
+				file.NewPath = trimPathPrefix(p, "b/")
+			}
+		}
+	}
+	return files
+}
+
+// Patch renders the file header and the hunks at the given indexes as a
+// patch that git apply accepts. All hunks are included when indexes is nil.
+func (f *File) Patch(indexes []int) string {
+	var b strings.Builder
+	for _, line := range f.Header {
+		b.WriteString(line + "\n")
+	}
+	if indexes == nil {
+		for _, h := range f.Hunks {
+			b.WriteString(h.String())
+		}
+		return b.String()
+	}
+	for _, i := range indexes {
+		b.WriteString(f.Hunks[i].String())
+	}
+	return b.String()
+}
+
+// pathsFromGitHeader reads the paths from "diff --git a/x b/y". Paths with
+// spaces are ambiguous there, so ---/+++ lines override them when present.
+func pathsFromGitHeader(line string) (string, string) {
+	rest := strings.TrimPrefix(line, "diff --git ")
+	if i := strings.Index(rest, " b/"); i >= 0 {
+		return trimPathPrefix(rest[:i], "a/"), rest[i+1+len("b/"):]
+	}
+	return "", ""
+}
+
+func trimPathPrefix(path, prefix string) string {
+	// git appends a tab to paths containing spaces
+	path = strings.TrimSuffix(path, "\t")
+	if path == "/dev/null" {
+		return path
+	}
+	return strings.TrimPrefix(path, prefix)
+}
@@ -0,0 +1,88 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+)
+
+const testDiff = `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1,3 +1,4 @@
+ package main
++import "fmt"
+ 
+ func main() {
+@@ -10,2 +11,3 @@ func main() {
+ 	run()
++	fmt.Println("done")
+ }
+diff --git a/docs/new file.md b/docs/new file.md
+new file mode 100644
+index 0000000..3333333
+--- /dev/null
++++ b/docs/new file.md	
+@@ -0,0 +1 @@
++# Docs
+\ No newline at end of file
+diff --git a/logo.png b/logo.png
+index 4444444..5555555 100644
+Binary files a/logo.png and b/logo.png differ
+diff --git a/old.go b/old.go
+deleted file mode 100644
+index 6666666..0000000
+--- a/old.go
++++ /dev/null
+@@ -1 +0,0 @@
+-package old
+`
+
+func TestParse(t *testing.T) {
+	files := Parse(testDiff)
+	if len(files) != 4 {
+		t.Fatalf("Parse() returned %d files, want 4", len(files))
+	}
+
+	tests := []struct {
+		path    string
+		oldPath string
+		hunks   int
+		header  int
+	}{
+		{"main.go", "main.go", 2, 4},
+		{"docs/new file.md", "/dev/null", 1, 5},
+		{"logo.png", "logo.png", 0, 3},
+		{"old.go", "old.go", 1, 5},
+	}
+	for i, tt := range tests {
+		f := files[i]
+		if f.Path() != tt.path || f.OldPath != tt.oldPath || len(f.Hunks) != tt.hunks || len(f.Header) != tt.header {
+			t.Errorf("file %d = path %q, old %q, %d hunks, %d header lines; want %+v",
+				i, f.Path(), f.OldPath, len(f.Hunks), len(f.Header), tt)
+		}
+	}
+
+	if got := files[0].Hunks[1].Lines; len(got) != 3 || got[1] != `+	fmt.Println("done")` {
+		t.Errorf("second hunk lines = %q", got)
+	}
+	if got := files[1].Hunks[0].Lines; got[len(got)-1] != `\ No newline at end of file` {
+		t.Errorf("new file hunk lines = %q", got)
+	}
+}
+
+func TestFile_Patch(t *testing.T) {
+	files := Parse(testDiff)
+
+	if got := files[0].Patch(nil) + files[1].Patch(nil) + files[2].Patch(nil) + files[3].Patch(nil); got != testDiff {
+		t.Errorf("patches of all hunks do not reproduce the diff:\n%s", got)
+	}
+
+	second := files[0].Patch([]int{1})
+	if !strings.HasPrefix(second, "diff --git a/main.go b/main.go\n") || strings.Contains(second, `import "fmt"`) {
+		t.Errorf("Patch([1]) =\n%s", second)
+	}
+	if !strings.HasSuffix(second, "@@ -10,2 +11,3 @@ func main() {\n \trun()\n+\tfmt.Println(\"done\")\n }\n") {
+		t.Errorf("Patch([1]) does not end with the second hunk:\n%s", second)
+	}
+}
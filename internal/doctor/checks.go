@@ -0,0 +1,254 @@
+package doctor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/klauern/muse/config"
+	"github.com/klauern/muse/hooks"
+	"github.com/klauern/muse/internal/configloader"
+	"github.com/klauern/muse/internal/credentials"
+	"github.com/klauern/muse/internal/git"
+	"github.com/klauern/muse/internal/security"
+	"github.com/klauern/muse/llm"
+)
+
+// Doctor builds the standard set of muse diagnostics
+type Doctor struct {
+	cfg    *config.Config
+	cfgErr error
+	// pingTimeout bounds the provider reachability check
+	pingTimeout time.Duration
+	// stdin is inspected by the TTY check
+	stdin    *os.File
+	resolver *credentials.Resolver
+}
+
+// New creates a Doctor for the loaded configuration. cfgErr is the error,
+// if any, returned while loading cfg.
+func New(cfg *config.Config, cfgErr error) *Doctor {
+	return &Doctor{
+		cfg:         cfg,
+		cfgErr:      cfgErr,
+		pingTimeout: 10 * time.Second,
+		stdin:       os.Stdin,
+		resolver:    credentials.NewResolver(),
+	}
+}
+
+// Checks returns all diagnostics in the order they should be reported
+func (d *Doctor) Checks() []Check {
+	return []Check{
+		{Name: "Config", Run: d.checkConfig},
+		{Name: "Credentials", Run: d.checkCredentials},
+		{Name: "Provider", Run: d.checkProvider},
+		{Name: "Hook", Run: d.checkHook},
+		{Name: "core.hooksPath", Run: d.checkHooksPath},
+		{Name: "Git", Run: d.checkGitVersion},
+		{Name: "TTY", Run: d.checkTTY},
+	}
+}
+
+func (d *Doctor) checkConfig(ctx context.Context) Result {
+	if d.cfgErr != nil {
+		return fail(d.cfgErr.Error(), "Fix the YAML syntax or field types in the config file")
+	}
+
+	sources, err := configloader.GetConfigLoader().Sources()
+	if err != nil {
+		return fail(err.Error(), "Fix the YAML syntax in the config file")
+	}
+
+	var files []string
+	for _, source := range sources {
+		if source.Path != "" {
+			files = append(files, fmt.Sprintf("%s (%s)", source.Path, source.Layer))
+		}
+	}
+	if len(files) == 0 {
+		return warn("no config file found; using built-in defaults",
+			"Run 'muse configure' to create one")
+	}
+	return pass("loaded " + strings.Join(files, ", "))
+}
+
+func (d *Doctor) checkCredentials(ctx context.Context) Result {
+	if d.cfg == nil {
+		return fail("skipped because the config could not be loaded", "")
+	}
+
+	provider := d.cfg.LLM.Provider
+	credential, err := d.resolver.Resolve(ctx, provider, d.cfg.LLM.Config)
+	if errors.Is(err, credentials.ErrNotFound) {
+		return fail("no API key configured for provider "+provider,
+			fmt.Sprintf("Run 'muse auth login %s', set %s, or set llm.config.api_key_cmd in muse.yaml", provider, credentials.EnvVar(provider)))
+	}
+	if err != nil {
+		return fail(err.Error(), "Fix the credential source in llm.config")
+	}
+
+	key, source := credential.Key, credential.Source
+	if err := security.ValidateCredential(key); err != nil {
+		var credErr *security.CredentialError
+		if errors.As(err, &credErr) && credErr.Type == "placeholder" {
+			return fail(fmt.Sprintf("%s from %s (%s)", err, source, security.MaskCredential(key)),
+				"Replace the example API key with a real one")
+		}
+		return warn(fmt.Sprintf("%s from %s (%s)", err, source, security.MaskCredential(key)),
+			"Double-check the API key value")
+	}
+
+	return pass(fmt.Sprintf("API key found in %s (%s)", source, security.MaskCredential(key)))
+}
+
+func (d *Doctor) checkProvider(ctx context.Context) Result {
+	if d.cfg == nil {
+		return fail("skipped because the config could not be loaded", "")
+	}
+
+	service, err := llm.NewLLMService(&d.cfg.LLM)
+	if err != nil {
+		return fail(err.Error(), "Check llm.provider and the provider settings in muse.yaml")
+	}
+
+	pinger, ok := service.(llm.Pinger)
+	if !ok {
+		return warn(fmt.Sprintf("provider %s does not support reachability checks", d.cfg.LLM.Provider), "")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, d.pingTimeout)
+	defer cancel()
+
+	start := time.Now()
+	if err := pinger.Ping(ctx); err != nil {
+		return fail(fmt.Sprintf("provider %s is not reachable: %v", d.cfg.LLM.Provider, err),
+			"Check network access, llm.config.api_base, the API key and the model name")
+	}
+	return pass(fmt.Sprintf("provider %s responded in %s", d.cfg.LLM.Provider, time.Since(start).Round(time.Millisecond)))
+}
+
+// installedHooks returns every hook file that muse manages for this repository
+func installedHooks() ([]*hooks.HookInfo, error) {
+	var paths []string
+	if localPath, err := hooks.LocalHookPath(); err == nil {
+		paths = append(paths, localPath)
+	}
+
+	state, err := hooks.LoadGlobalState()
+	if err != nil {
+		return nil, err
+	}
+	if state != nil {
+		paths = append(paths, state.HookPath)
+	}
+
+	var infos []*hooks.HookInfo
+	for _, path := range paths {
+		info, err := hooks.InspectHook(path)
+		if err != nil {
+			return nil, err
+		}
+		if info.Installed {
+			infos = append(infos, info)
+		}
+	}
+	return infos, nil
+}
+
+func (d *Doctor) checkHook(ctx context.Context) Result {
+	infos, err := installedHooks()
+	if err != nil {
+		return fail(err.Error(), "")
+	}
+	if len(infos) == 0 {
+		return warn("prepare-commit-msg hook is not installed", "Run 'muse install' or 'muse install --global'")
+	}
+
+	for _, info := range infos {
+		if !info.Executable {
+			return fail(info.Path+" is not executable", "Run 'chmod +x "+info.Path+"'")
+		}
+		if info.BinaryPath == "" {
+			return fail(info.Path+" does not call a muse binary", "Re-run 'muse install' to rewrite the hook")
+		}
+		stat, err := os.Stat(info.BinaryPath)
+		if err != nil {
+			return fail(fmt.Sprintf("%s calls %s, which no longer exists", info.Path, info.BinaryPath),
+				"Re-run 'muse install' with the current muse binary")
+		}
+		if stat.Mode().Perm()&0o111 == 0 {
+			return fail(fmt.Sprintf("%s calls %s, which is not executable", info.Path, info.BinaryPath),
+				"Re-run 'muse install' with the current muse binary")
+		}
+	}
+
+	paths := make([]string, 0, len(infos))
+	for _, info := range infos {
+		paths = append(paths, info.Path)
+	}
+	return pass("installed in " + strings.Join(paths, ", "))
+}
+
+func (d *Doctor) checkHooksPath(ctx context.Context) Result {
+	gitOps, err := git.NewGitOperations("")
+	if err != nil {
+		return warn("not inside a git repository", "")
+	}
+
+	hooksPath, isSet, err := gitOps.GetConfig("core.hooksPath")
+	if err != nil {
+		return fail(err.Error(), "")
+	}
+	if !isSet {
+		return pass("not set; .git/hooks is used")
+	}
+
+	state, err := hooks.LoadGlobalState()
+	if err != nil {
+		return fail(err.Error(), "")
+	}
+	if state != nil && state.Mode == hooks.HooksPathMode && hooksPath == state.ConfigValue {
+		return pass("points at the muse global hooks directory")
+	}
+
+	if activePath, err := hooks.LocalHookPath(); err == nil {
+		if installed, _ := hooks.HasMuseBlock(activePath); installed {
+			return pass(fmt.Sprintf("set to %s, which has the muse hook", hooksPath))
+		}
+	}
+	if commonDir, err := gitOps.GetCommonDir(); err == nil {
+		localPath := filepath.Join(commonDir, "hooks", "prepare-commit-msg")
+		if installed, _ := hooks.HasMuseBlock(localPath); installed {
+			return fail(fmt.Sprintf("set to %s, so git ignores the muse hook in %s", hooksPath, localPath),
+				"Re-run 'muse install' to install into "+hooksPath+", unset core.hooksPath, or run 'muse install --global'")
+		}
+	}
+	return warn(fmt.Sprintf("set to %s; muse hooks in .git/hooks will not run", hooksPath),
+		"Run 'muse install' to add muse to that directory, or use 'muse install --global'")
+}
+
+func (d *Doctor) checkGitVersion(ctx context.Context) Result {
+	version, err := git.Version()
+	if err != nil {
+		return fail(err.Error(), "Install git and make sure it is on PATH")
+	}
+	return pass("git " + version)
+}
+
+func (d *Doctor) checkTTY(ctx context.Context) Result {
+	stat, err := d.stdin.Stat()
+	if err == nil && stat.Mode()&os.ModeCharDevice != 0 {
+		return pass("stdin is a terminal")
+	}
+
+	if d.cfg != nil && d.cfg.Hook.Preview {
+		return warn("stdin is not a terminal, so preview prompts cannot be answered",
+			"Set hook.preview to false when committing from IDEs or scripts")
+	}
+	return pass("stdin is not a terminal; preview is disabled")
+}
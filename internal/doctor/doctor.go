@@ -0,0 +1,125 @@
+package doctor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Status is the outcome of a single diagnostic check
+type Status string
+
+const (
+	StatusPass Status = "pass"
+	StatusWarn Status = "warn"
+	StatusFail Status = "fail"
+)
+
+// Result is the outcome of a check along with a remediation hint
+type Result struct {
+	Name    string `json:"name"`
+	Status  Status `json:"status"`
+	Message string `json:"message"`
+	Hint    string `json:"hint,omitempty"`
+}
+
+// Check is a named diagnostic
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) Result
+}
+
+// RunChecks runs each check in order. A check that panics is reported as a
+// failure instead of aborting the remaining checks.
+func RunChecks(ctx context.Context, checks []Check) []Result {
+	results := make([]Result, 0, len(checks))
+	for _, check := range checks {
+		results = append(results, runCheck(ctx, check))
+	}
+	return results
+}
+
+func runCheck(ctx context.Context, check Check) (result Result) {
+	defer func() {
+		if r := recover(); r != nil {
+			result = Result{
+				Name:    check.Name,
+				Status:  StatusFail,
+				Message: fmt.Sprintf("check panicked: %v", r),
+			}
+		}
+	}()
+
+	result = check.Run(ctx)
+	result.Name = check.Name
+	return result
+}
+
+// Failures returns the number of failed checks
+func Failures(results []Result) int {
+	count := 0
+	for _, r := range results {
+		if r.Status == StatusFail {
+			count++
+		}
+	}
+	return count
+}
+
+// WriteReport writes a human-readable pass/warn/fail report
+func WriteReport(w io.Writer, results []Result) error {
+	var warnings int
+	for _, r := range results {
+		if _, err := fmt.Fprintf(w, "[%s] %s: %s\n", strings.ToUpper(string(r.Status)), r.Name, r.Message); err != nil {
+			return err
+		}
+		if r.Hint != "" && r.Status != StatusPass {
+			if _, err := fmt.Fprintf(w, "       hint: %s\n", r.Hint); err != nil {
+				return err
+			}
+		}
+		if r.Status == StatusWarn {
+			warnings++
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "\n%d checks, %d warnings, %d failures\n", len(results), warnings, Failures(results))
+	return err
+}
+
+// Report is the JSON form of a set of check results
+type Report struct {
+	Checks   []Result `json:"checks"`
+	Warnings int      `json:"warnings"`
+	Failures int      `json:"failures"`
+}
+
+// WriteJSON writes results as an indented Report
+func WriteJSON(w io.Writer, results []Result) error {
+	report := Report{Checks: results, Failures: Failures(results)}
+	if report.Checks == nil {
+		report.Checks = []Result{}
+	}
+	for _, r := range results {
+		if r.Status == StatusWarn {
+			report.Warnings++
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+func pass(message string) Result {
+	return Result{Status: StatusPass, Message: message}
+}
+
+func warn(message, hint string) Result {
+	return Result{Status: StatusWarn, Message: message, Hint: hint}
+}
+
+func fail(message, hint string) Result {
+	return Result{Status: StatusFail, Message: message, Hint: hint}
+}
@@ -0,0 +1,160 @@
+package doctor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/klauern/muse/config"
+)
+
+func TestRunChecks(t *testing.T) {
+	checks := []Check{
+		{Name: "ok", Run: func(ctx context.Context) Result { return pass("fine") }},
+		{Name: "boom", Run: func(ctx context.Context) Result { panic("unexpected") }},
+		{Name: "meh", Run: func(ctx context.Context) Result { return warn("not great", "do better") }},
+	}
+
+	results := RunChecks(context.Background(), checks)
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(results))
+	}
+
+	if results[0].Name != "ok" || results[0].Status != StatusPass {
+		t.Errorf("Unexpected first result: %+v", results[0])
+	}
+	if results[1].Status != StatusFail || !strings.Contains(results[1].Message, "panicked") {
+		t.Errorf("Expected panicking check to fail, got %+v", results[1])
+	}
+	if results[2].Name != "meh" || results[2].Status != StatusWarn {
+		t.Errorf("Unexpected third result: %+v", results[2])
+	}
+
+	if got := Failures(results); got != 1 {
+		t.Errorf("Failures() = %d, want 1", got)
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+	results := []Result{
+		{Name: "Config", Status: StatusPass, Message: "loaded muse.yaml", Hint: "ignored for passing checks"},
+		{Name: "Hook", Status: StatusWarn, Message: "not installed", Hint: "Run 'muse install'"},
+		{Name: "Git", Status: StatusFail, Message: "git not found"},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteReport(&buf, results); err != nil {
+		t.Fatalf("WriteReport() failed: %v", err)
+	}
+
+	output := buf.String()
+	for _, want := range []string{
+		"[PASS] Config: loaded muse.yaml",
+		"[WARN] Hook: not installed",
+		"hint: Run 'muse install'",
+		"[FAIL] Git: git not found",
+		"3 checks, 1 warnings, 1 failures",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Report missing %q:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "ignored for passing checks") {
+		t.Error("Hints should not be printed for passing checks")
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	results := []Result{
+		{Name: "Config", Status: StatusPass, Message: "loaded muse.yaml"},
+		{Name: "Hook", Status: StatusWarn, Message: "not installed", Hint: "Run 'muse install'"},
+		{Name: "Git", Status: StatusFail, Message: "git not found"},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, results); err != nil {
+		t.Fatalf("WriteJSON() failed: %v", err)
+	}
+
+	var report Report
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("Output is not JSON: %v\n%s", err, buf.String())
+	}
+	if len(report.Checks) != 3 || report.Warnings != 1 || report.Failures != 1 {
+		t.Errorf("Report = %+v, want 3 checks, 1 warning and 1 failure", report)
+	}
+	if report.Checks[1].Hint != "Run 'muse install'" {
+		t.Errorf("Hint = %q, want it kept", report.Checks[1].Hint)
+	}
+
+	buf.Reset()
+	if err := WriteJSON(&buf, nil); err != nil {
+		t.Fatalf("WriteJSON(nil) failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"checks": []`) {
+		t.Errorf("Empty report should have an empty checks array:\n%s", buf.String())
+	}
+}
+
+func TestCheckCredentials(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "")
+
+	tests := []struct {
+		name   string
+		config map[string]any
+		env    string
+		want   Status
+	}{
+		{name: "missing", config: map[string]any{}, want: StatusFail},
+		{name: "placeholder", config: map[string]any{"api_key": "your-api-key"}, want: StatusFail},
+		{name: "too short", config: map[string]any{"api_key": "abc"}, want: StatusWarn},
+		{name: "valid config key", config: map[string]any{"api_key": "sk-abcdefghijklmnop"}, want: StatusPass},
+		{name: "valid env key", config: map[string]any{}, env: "sk-abcdefghijklmnop", want: StatusPass},
+		{name: "key command", config: map[string]any{"api_key_cmd": "echo sk-abcdefghijklmnop"}, want: StatusPass},
+		{name: "failing key command", config: map[string]any{"api_key_cmd": "exit 3"}, want: StatusFail},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("OPENAI_API_KEY", tt.env)
+			cfg := &config.Config{LLM: config.LLMConfig{Provider: "openai", Config: tt.config}}
+
+			d := New(cfg, nil)
+			// Keep the user's keyring and stored keys out of the test
+			d.resolver.Keyring = nil
+			d.resolver.Dir = t.TempDir()
+
+			result := d.checkCredentials(context.Background())
+			if result.Status != tt.want {
+				t.Errorf("checkCredentials() = %+v, want status %s", result, tt.want)
+			}
+			if strings.Contains(result.Message, "abcdefghijklmnop") {
+				t.Errorf("Credential leaked in message: %s", result.Message)
+			}
+		})
+	}
+}
+
+func TestCheckTTY(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "stdin"))
+	if err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	defer f.Close()
+
+	d := New(&config.Config{Hook: config.Hook{Preview: true}}, nil)
+	d.stdin = f
+	if result := d.checkTTY(context.Background()); result.Status != StatusWarn {
+		t.Errorf("Expected warning for preview without a TTY, got %+v", result)
+	}
+
+	d = New(&config.Config{Hook: config.Hook{Preview: false}}, nil)
+	d.stdin = f
+	if result := d.checkTTY(context.Background()); result.Status != StatusPass {
+		t.Errorf("Expected pass when preview is disabled, got %+v", result)
+	}
+}
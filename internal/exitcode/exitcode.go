@@ -0,0 +1,87 @@
+// Package exitcode maps errors to process exit statuses, so scripts and
+// editor plugins can tell failure classes apart
+package exitcode
+
+import (
+	"errors"
+
+	"github.com/klauern/muse/config"
+	"github.com/klauern/muse/internal/configloader"
+	"github.com/klauern/muse/internal/credentials"
+	"github.com/klauern/muse/internal/git"
+	"github.com/klauern/muse/internal/userinput"
+	"github.com/klauern/muse/llm"
+)
+
+// Exit statuses
+const (
+	OK = 0
+	// Failure is any error without a more specific class
+	Failure = 1
+	// Config means the configuration could not be loaded or is invalid
+	Config = 2
+	// Git means a git command failed or the directory is not a repository
+	Git = 3
+	// Auth means the provider credentials are missing or were rejected
+	Auth = 4
+	// RateLimit means the provider rate limited the request
+	RateLimit = 5
+	// Validation means the input was checked and found wanting, such as a
+	// commit message with lint errors or a review with blocking issues
+	Validation = 6
+	// Rejected means the user declined to go ahead
+	Rejected = 7
+)
+
+// classes names the statuses in JSON output
+var classes = map[int]string{
+	OK:         "ok",
+	Failure:    "error",
+	Config:     "config",
+	Git:        "git",
+	Auth:       "auth",
+	RateLimit:  "rate_limit",
+	Validation: "validation",
+	Rejected:   "rejected",
+}
+
+// Class returns the name of an exit status
+func Class(code int) string {
+	if class, ok := classes[code]; ok {
+		return class
+	}
+	return classes[Failure]
+}
+
+// exitCoder matches errors that carry their own status, such as cli.Exit
+type exitCoder interface {
+	ExitCode() int
+}
+
+// Classify returns the exit status for err
+func Classify(err error) int {
+	if err == nil {
+		return OK
+	}
+
+	var coder exitCoder
+	var configErr configloader.ConfigError
+	var validationErrs config.ValidationErrors
+	var gitCommandErr git.GitCommandError
+	var gitValidationErr git.GitValidationError
+	switch {
+	case errors.As(err, &coder):
+		return coder.ExitCode()
+	case errors.Is(err, userinput.ErrRejected):
+		return Rejected
+	case errors.Is(err, llm.ErrAuth), errors.Is(err, credentials.ErrNotFound):
+		return Auth
+	case errors.Is(err, llm.ErrRateLimit):
+		return RateLimit
+	case errors.As(err, &configErr), errors.As(err, &validationErrs):
+		return Config
+	case errors.As(err, &gitCommandErr), errors.As(err, &gitValidationErr):
+		return Git
+	}
+	return Failure
+}
@@ -0,0 +1,42 @@
+package exitcode
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/klauern/muse/config"
+	"github.com/klauern/muse/internal/configloader"
+	"github.com/klauern/muse/internal/credentials"
+	"github.com/klauern/muse/internal/git"
+	"github.com/klauern/muse/internal/userinput"
+	"github.com/klauern/muse/llm"
+	"github.com/urfave/cli/v2"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, OK},
+		{"plain error", errors.New("boom"), Failure},
+		{"exit coder", cli.Exit("", Validation), Validation},
+		{"rejected", fmt.Errorf("generated commit message %w", userinput.ErrRejected), Rejected},
+		{"auth", fmt.Errorf("failed to generate: %w", fmt.Errorf("%w: 401", llm.ErrAuth)), Auth},
+		{"missing key", fmt.Errorf("openai api key not set: %w", credentials.ErrNotFound), Auth},
+		{"rate limit", fmt.Errorf("%w: 429", llm.ErrRateLimit), RateLimit},
+		{"config", fmt.Errorf("error loading config: %w", configloader.ConfigError{Stage: "validation"}), Config},
+		{"validation errors", config.ValidationErrors{{Field: "llm.provider", Reason: "required"}}, Config},
+		{"git command", fmt.Errorf("failed to get staged diff: %w", git.GitCommandError{ExitCode: 128}), Git},
+		{"not a repository", git.GitValidationError{Reason: "not a git repository"}, Git},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Classify(tt.err); got != tt.want {
+				t.Errorf("Classify() = %d (%s), want %d (%s)", got, Class(got), tt.want, Class(tt.want))
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package explain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/klauern/muse/internal/git"
+	"github.com/klauern/muse/llm"
+	"github.com/klauern/muse/templates"
+)
+
+// DefaultAudience is used when no audience is given
+const DefaultAudience = "reviewer"
+
+// maxPromptShow is the largest git show output sent in full; bigger changes
+// are explained from the commit messages and a per-file summary instead
+const maxPromptShow = 200 * 1024
+
+// Change is a commit or range to explain
+type Change struct {
+	Revision string
+	Show     string
+	// Stat is set when Show holds a per-file summary instead of patches
+	Stat bool
+}
+
+// Collect reads rev, a commit or a range, through git show
+func Collect(ops *git.GitOperations, rev string) (*Change, error) {
+	c := &Change{Revision: rev}
+	show, err := ops.Show(rev)
+	if err != nil && !errors.Is(err, git.ErrDiffTooLarge) {
+		return nil, err
+	}
+	if err != nil || len(show) > maxPromptShow {
+		slog.Debug("Changes too large, explaining from the file summary", "revision", rev, "size", len(show))
+		if show, err = ops.ShowStat(rev); err != nil {
+			return nil, err
+		}
+		c.Stat = true
+	}
+	if strings.TrimSpace(show) == "" {
+		return nil, fmt.Errorf("no commits in %s", rev)
+	}
+	c.Show = show
+	return c, nil
+}
+
+func (c *Change) promptData() map[string]any {
+	return map[string]any{"Revision": c.Revision, "Show": c.Show, "Stat": c.Stat}
+}
+
+// Markdown asks service to explain c to audience in markdown
+func Markdown(ctx context.Context, service llm.LLMService, c *Change, audience string) (string, error) {
+	completer, ok := service.(llm.Completer)
+	if !ok {
+		return "", fmt.Errorf("the configured provider does not support free-form prompts")
+	}
+	prompt, err := templates.RenderExplainPrompt(audience, c.promptData())
+	if err != nil {
+		return "", err
+	}
+	slog.Debug("Sending prompt", "name", "explain", "audience", audience, "length", len(prompt))
+
+	response, err := completer.Complete(ctx, prompt)
+	if err != nil {
+		return "", fmt.Errorf("failed to complete explain prompt: %w", err)
+	}
+	return strings.TrimSpace(response), nil
+}
+
+// Structured asks service to explain c to audience following
+// templates.ExplanationSchema
+func Structured(ctx context.Context, service llm.LLMService, c *Change, audience string) (*templates.Explanation, error) {
+	schema := templates.ExplanationSchema()
+	data := c.promptData()
+	data["Schema"] = schema
+	prompt, err := templates.RenderExplainPrompt(audience, data)
+	if err != nil {
+		return nil, err
+	}
+
+	var explanation templates.Explanation
+	if err := llm.CompleteStructured(ctx, service, "explain", prompt, schema, &explanation); err != nil {
+		return nil, err
+	}
+	if explanation.Changes == nil {
+		explanation.Changes = []string{}
+	}
+	if explanation.Risks == nil {
+		explanation.Risks = []string{}
+	}
+	return &explanation, nil
+}
@@ -0,0 +1,90 @@
+package explain
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/klauern/muse/internal/git"
+	"github.com/klauern/muse/internal/gittest"
+	"github.com/klauern/muse/templates"
+)
+
+// fakeService records the prompt and answers with a fixed response
+type fakeService struct {
+	response string
+	prompt   string
+}
+
+func (f *fakeService) GenerateCommitMessage(ctx context.Context, diff string, style templates.CommitStyle) (string, error) {
+	return "", nil
+}
+
+func (f *fakeService) Complete(ctx context.Context, prompt string) (string, error) {
+	f.prompt = prompt
+	return f.response, nil
+}
+
+func newTestRepo(t *testing.T) *git.GitOperations {
+	t.Helper()
+	repo := gittest.New(t)
+	repo.Commit("chore: initial")
+	repo.Write("retry.go", "package main\n\nconst retries = 3\n")
+	repo.Commit("fix: retry failed uploads")
+
+	ops, err := git.NewGitOperations(repo.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ops
+}
+
+func TestMarkdown(t *testing.T) {
+	change, err := Collect(newTestRepo(t), "HEAD")
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	if change.Stat || !strings.Contains(change.Show, "const retries = 3") {
+		t.Fatalf("Collect() = %+v, want the patch", change)
+	}
+
+	service := &fakeService{response: "\nRetries uploads.\n"}
+	got, err := Markdown(context.Background(), service, change, "newcomer")
+	if err != nil {
+		t.Fatalf("Markdown() error = %v", err)
+	}
+	if got != "Retries uploads." {
+		t.Errorf("Markdown() = %q", got)
+	}
+	for _, want := range []string{"fix: retry failed uploads", "new to the project", "Respond with markdown"} {
+		if !strings.Contains(service.prompt, want) {
+			t.Errorf("prompt does not contain %q:\n%s", want, service.prompt)
+		}
+	}
+
+	if _, err := Markdown(context.Background(), service, change, "manager"); err == nil || !strings.Contains(err.Error(), "release-manager") {
+		t.Errorf("Markdown() with an unknown audience error = %v, want the available audiences", err)
+	}
+}
+
+func TestStructured(t *testing.T) {
+	change := &Change{Revision: "v1.0..v1.1", Show: "commit abc\n\n    feat: add watch\n", Stat: true}
+	service := &fakeService{response: `{"summary": "Adds watch.", "changes": ["muse watch"], "impact": "Faster commits."}`}
+
+	got, err := Structured(context.Background(), service, change, "release-manager")
+	if err != nil {
+		t.Fatalf("Structured() error = %v", err)
+	}
+	if got.Summary != "Adds watch." || len(got.Changes) != 1 || got.Risks == nil {
+		t.Errorf("Structured() = %+v", got)
+	}
+	for _, want := range []string{"release manager", "too large", `"impact"`} {
+		if !strings.Contains(service.prompt, want) {
+			t.Errorf("prompt does not contain %q:\n%s", want, service.prompt)
+		}
+	}
+
+	if _, err := Collect(newTestRepo(t), "HEAD..HEAD"); err == nil {
+		t.Error("Collect() of an empty range succeeded")
+	}
+}
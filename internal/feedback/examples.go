@@ -0,0 +1,152 @@
+package feedback
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/klauern/muse/config"
+	"github.com/klauern/muse/internal/diff"
+	"github.com/klauern/muse/internal/ledger"
+	"github.com/klauern/muse/llm"
+	"github.com/klauern/muse/templates"
+)
+
+// maxExampleSize skips pairs too long to be worth their share of the prompt
+const maxExampleSize = 2000
+
+// Query describes the generation that examples are chosen for
+type Query struct {
+	Repo  string
+	Style string
+	// Files are the paths the diff changes
+	Files []string
+	// Max is the number of examples to return
+	Max int
+	// HalfLife is the age at which a pair counts half as much
+	HalfLife time.Duration
+	Now      time.Time
+}
+
+// Select returns up to q.Max edited pairs from q.Repo in q.Style, best
+// first. A pair's weight halves every q.HalfLife and grows up to double the
+// more its files match the diff's, so recent edits to related code win.
+// Messages accepted as generated carry nothing to learn and are skipped.
+func Select(pairs []Pair, q Query) []Pair {
+	type candidate struct {
+		pair   Pair
+		weight float64
+	}
+	var candidates []candidate
+	for _, p := range pairs {
+		if p.Repo != q.Repo || p.Style != q.Style || p.Outcome == ledger.OutcomeAccepted {
+			continue
+		}
+		if len(p.Generated)+len(p.Committed) > maxExampleSize {
+			continue
+		}
+		weight := 1 + overlap(p.Files, q.Files)
+		if q.HalfLife > 0 {
+			age := max(q.Now.Sub(p.Time), 0)
+			weight *= math.Exp2(-age.Hours() / q.HalfLife.Hours())
+		}
+		candidates = append(candidates, candidate{p, weight})
+	}
+
+	// Among equal weights, the newer pair wins
+	slices.SortStableFunc(candidates, func(a, b candidate) int {
+		if a.weight != b.weight {
+			if a.weight > b.weight {
+				return -1
+			}
+			return 1
+		}
+		return b.pair.Time.Compare(a.pair.Time)
+	})
+
+	var selected []Pair
+	for _, c := range candidates[:min(q.Max, len(candidates))] {
+		selected = append(selected, c.pair)
+	}
+	return selected
+}
+
+// overlap returns the Jaccard similarity of two sets of paths
+func overlap(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	inA := map[string]bool{}
+	for _, path := range a {
+		inA[path] = true
+	}
+	shared, union := 0, len(inA)
+	seen := map[string]bool{}
+	for _, path := range b {
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		if inA[path] {
+			shared++
+		} else {
+			union++
+		}
+	}
+	return float64(shared) / float64(union)
+}
+
+// DiffFiles returns the paths a unified diff changes
+func DiffFiles(text string) []string {
+	var files []string
+	for _, f := range diff.Parse(text) {
+		if path := f.Path(); path != "" {
+			files = append(files, path)
+		}
+	}
+	return files
+}
+
+// WithExamples returns a context that shows the provider the repository's
+// best edited messages in style for diff, as chosen by Select under
+// cfg.Feedback. repo is the repository root, or empty for the working
+// directory's. Any failure leaves ctx without examples.
+func WithExamples(ctx context.Context, cfg *config.Config, repo string, style templates.CommitStyle, diff string) context.Context {
+	if !cfg.Feedback.Enabled || cfg.Feedback.MaxExamples == 0 {
+		return ctx
+	}
+	if repo == "" {
+		repo = config.RepoRoot()
+	}
+	store, err := DefaultStore()
+	if err != nil {
+		slog.Debug("Skipping feedback examples", "error", err)
+		return ctx
+	}
+	pairs, err := store.Pairs(time.Time{})
+	if err != nil {
+		slog.Debug("Skipping feedback examples", "error", err)
+		return ctx
+	}
+
+	halfLife, _ := time.ParseDuration(cfg.Feedback.HalfLife)
+	selected := Select(pairs, Query{
+		Repo:     repo,
+		Style:    string(style),
+		Files:    DiffFiles(diff),
+		Max:      cfg.Feedback.MaxExamples,
+		HalfLife: halfLife,
+		Now:      time.Now(),
+	})
+	if len(selected) == 0 {
+		return ctx
+	}
+	examples := make([]templates.Example, len(selected))
+	for i, p := range selected {
+		examples[i] = p.Example()
+	}
+	slog.Debug("Using edited messages as examples", "count", len(examples))
+	return llm.WithExamples(ctx, examples)
+}
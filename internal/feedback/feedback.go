@@ -0,0 +1,157 @@
+// Package feedback records how users edit generated commit messages before
+// committing them, and turns the edits into examples for later prompts.
+package feedback
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/klauern/muse/config"
+	"github.com/klauern/muse/internal/fileops"
+	"github.com/klauern/muse/internal/ledger"
+	"github.com/klauern/muse/templates"
+)
+
+// Pair is a generated message and the message it was committed with
+type Pair struct {
+	Time     time.Time `json:"timestamp"`
+	Repo     string    `json:"repo"`
+	Commit   string    `json:"commit"`
+	Style    string    `json:"style"`
+	Provider string    `json:"provider,omitempty"`
+	Model    string    `json:"model,omitempty"`
+	// Files are the paths the commit changed
+	Files     []string `json:"files,omitempty"`
+	Generated string   `json:"generated"`
+	Committed string   `json:"committed"`
+	// Diff shows the edit line by line, with - for removed and + for added
+	// lines; it is empty when the message was accepted as generated
+	Diff    string `json:"diff,omitempty"`
+	Outcome string `json:"outcome"`
+}
+
+// Example returns the pair as a prompt example
+func (p Pair) Example() templates.Example {
+	return templates.Example{Generated: p.Generated, Committed: p.Committed}
+}
+
+// Store is an append-only JSONL file of pairs
+type Store struct {
+	Path string
+}
+
+// DefaultStore returns the store at feedback.jsonl in the muse state
+// directory
+func DefaultStore() (*Store, error) {
+	stateDir, err := config.StateDir()
+	if err != nil {
+		return nil, err
+	}
+	return &Store{Path: filepath.Join(stateDir, "feedback.jsonl")}, nil
+}
+
+// Append adds p to the store
+func (s *Store) Append(p Pair) error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("failed to encode feedback: %w", err)
+	}
+	if err := fileops.AppendLine(s.Path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write feedback: %w", err)
+	}
+	return nil
+}
+
+// Pairs returns the pairs recorded at or after since, oldest first. Lines
+// that cannot be decoded are skipped.
+func (s *Store) Pairs(since time.Time) ([]Pair, error) {
+	var pairs []Pair
+	err := fileops.ReadJSONLines(s.Path, func(p Pair) {
+		if !p.Time.Before(since) {
+			pairs = append(pairs, p)
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read feedback: %w", err)
+	}
+	return pairs, nil
+}
+
+// Compare classifies how generated became committed and returns the edit.
+// Messages that differ only in whitespace were accepted, and messages that
+// kept fewer than half of the generated words were rejected and rewritten.
+func Compare(generated, committed string) (string, string) {
+	before, after := lines(generated), lines(committed)
+	if strings.Join(before, "\n") == strings.Join(after, "\n") {
+		return ledger.OutcomeAccepted, ""
+	}
+
+	remaining := map[string]int{}
+	for _, word := range strings.Fields(committed) {
+		remaining[word]++
+	}
+	words := strings.Fields(generated)
+	kept := 0
+	for _, word := range words {
+		if remaining[word] > 0 {
+			remaining[word]--
+			kept++
+		}
+	}
+	outcome := ledger.OutcomeEdited
+	if 2*kept < len(words) {
+		outcome = ledger.OutcomeRejected
+	}
+	return outcome, lineDiff(before, after)
+}
+
+// lines splits a message into lines without trailing whitespace, dropping
+// the blank lines around it
+func lines(message string) []string {
+	split := strings.Split(strings.TrimSpace(message), "\n")
+	for i, line := range split {
+		split[i] = strings.TrimRight(line, " \t\r")
+	}
+	return split
+}
+
+// lineDiff returns the lines of a and b prefixed with "- ", "+ " or "  ",
+// keeping their longest common subsequence unchanged
+func lineDiff(a, b []string) string {
+	// common[i][j] is the length of the longest common subsequence of a[i:]
+	// and b[j:]
+	common := make([][]int, len(a)+1)
+	for i := range common {
+		common[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+
+	var out strings.Builder
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			out.WriteString("  " + a[i] + "\n")
+			i++
+			j++
+		case i < len(a) && (j == len(b) || common[i+1][j] >= common[i][j+1]):
+			out.WriteString("- " + a[i] + "\n")
+			i++
+		default:
+			out.WriteString("+ " + b[j] + "\n")
+			j++
+		}
+	}
+	return out.String()
+}
@@ -0,0 +1,189 @@
+package feedback
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/klauern/muse/config"
+	"github.com/klauern/muse/internal/ledger"
+	"github.com/klauern/muse/llm"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name        string
+		generated   string
+		committed   string
+		wantOutcome string
+		wantDiff    string
+	}{
+		{
+			name:        "accepted",
+			generated:   "feat: add stats\n\nSummarizes the ledger.  \n",
+			committed:   "feat: add stats\n\nSummarizes the ledger.",
+			wantOutcome: ledger.OutcomeAccepted,
+		},
+		{
+			name:        "edited subject",
+			generated:   "feat: add stats\n\nSummarizes the ledger.",
+			committed:   "feat(cli): add muse stats\n\nSummarizes the ledger.",
+			wantOutcome: ledger.OutcomeEdited,
+			wantDiff:    "- feat: add stats\n+ feat(cli): add muse stats\n  \n  Summarizes the ledger.\n",
+		},
+		{
+			name:        "subject reworded",
+			generated:   "feat: add thing",
+			committed:   "feat: add the thing",
+			wantOutcome: ledger.OutcomeEdited,
+			wantDiff:    "- feat: add thing\n+ feat: add the thing\n",
+		},
+		{
+			name:        "body added",
+			generated:   "fix: handle empty diffs",
+			committed:   "fix: handle empty diffs\n\nCloses #12",
+			wantOutcome: ledger.OutcomeEdited,
+			wantDiff:    "  fix: handle empty diffs\n+ \n+ Closes #12\n",
+		},
+		{
+			name:        "rewritten",
+			generated:   "chore: update files",
+			committed:   "docs: explain the ledger",
+			wantOutcome: ledger.OutcomeRejected,
+			wantDiff:    "- chore: update files\n+ docs: explain the ledger\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outcome, diff := Compare(tt.generated, tt.committed)
+			if outcome != tt.wantOutcome {
+				t.Errorf("Compare() outcome = %q, want %q", outcome, tt.wantOutcome)
+			}
+			if diff != tt.wantDiff {
+				t.Errorf("Compare() diff = %q, want %q", diff, tt.wantDiff)
+			}
+		})
+	}
+}
+
+func TestStore(t *testing.T) {
+	store := &Store{Path: filepath.Join(t.TempDir(), "muse", "feedback.jsonl")}
+	pairs, err := store.Pairs(time.Time{})
+	if err != nil || pairs != nil {
+		t.Fatalf("Pairs() on missing store = %v, %v; want nil, nil", pairs, err)
+	}
+
+	old := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, p := range []Pair{
+		{Time: old, Repo: "/a", Generated: "feat: a", Committed: "feat(x): a", Outcome: ledger.OutcomeEdited},
+		{Time: old.AddDate(0, 1, 0), Repo: "/b", Generated: "fix: b", Committed: "fix: b", Outcome: ledger.OutcomeAccepted},
+	} {
+		if err := store.Append(p); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+
+	pairs, err = store.Pairs(old.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("Pairs() error = %v", err)
+	}
+	if len(pairs) != 1 || pairs[0].Repo != "/b" {
+		t.Errorf("Pairs(since) = %+v, want only the newer pair", pairs)
+	}
+}
+
+func TestPending(t *testing.T) {
+	gitDir := t.TempDir()
+	if p, err := TakePending(gitDir); p != nil || err != nil {
+		t.Fatalf("TakePending() without a pending message = %v, %v", p, err)
+	}
+
+	want := Pending{LedgerID: "abc", Parent: "1234", Style: "conventional", Message: "feat: x"}
+	if err := SavePending(gitDir, want); err != nil {
+		t.Fatalf("SavePending() error = %v", err)
+	}
+	got, err := TakePending(gitDir)
+	if err != nil {
+		t.Fatalf("TakePending() error = %v", err)
+	}
+	if got == nil || got.LedgerID != want.LedgerID || got.Parent != want.Parent || got.Message != want.Message {
+		t.Errorf("TakePending() = %+v, want %+v", got, want)
+	}
+	if p, _ := TakePending(gitDir); p != nil {
+		t.Error("TakePending() returned the message twice")
+	}
+}
+
+func TestSelect(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	pair := func(committed string, age time.Duration, files ...string) Pair {
+		return Pair{
+			Time: now.Add(-age), Repo: "/repo", Style: "conventional", Files: files,
+			Generated: "feat: change", Committed: committed, Outcome: ledger.OutcomeEdited,
+		}
+	}
+	pairs := []Pair{
+		pair("old related", 30*day, "cmd/stats.go"),
+		pair("new unrelated", day, "README.md"),
+		pair("recent related", 2*day, "cmd/stats.go"),
+		pair("newest unrelated", 0, "go.mod"),
+		{Time: now, Repo: "/other", Style: "conventional", Committed: "other repo", Outcome: ledger.OutcomeEdited},
+		{Time: now, Repo: "/repo", Style: "gitmoji", Committed: "other style", Outcome: ledger.OutcomeEdited},
+		{Time: now, Repo: "/repo", Style: "conventional", Committed: "accepted", Outcome: ledger.OutcomeAccepted},
+	}
+
+	selected := Select(pairs, Query{
+		Repo: "/repo", Style: "conventional", Files: []string{"cmd/stats.go"},
+		Max: 3, HalfLife: 14 * day, Now: now,
+	})
+	var got []string
+	for _, p := range selected {
+		got = append(got, p.Committed)
+	}
+	want := []string{"recent related", "newest unrelated", "new unrelated"}
+	if len(got) != len(want) {
+		t.Fatalf("Select() = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Select() = %q, want %q", got, want)
+		}
+	}
+
+	if selected := Select(pairs, Query{Repo: "/repo", Style: "conventional", Max: 0, Now: now}); len(selected) != 0 {
+		t.Errorf("Select() with Max 0 = %v, want none", selected)
+	}
+}
+
+func TestWithExamples(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	store, err := DefaultStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Append(Pair{
+		Time: time.Now(), Repo: "/repo", Style: "conventional", Files: []string{"a.go"},
+		Generated: "feat: a", Committed: "feat(a): add a", Outcome: ledger.OutcomeEdited,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{
+		Feedback: config.FeedbackConfig{Enabled: true, MaxExamples: 2, HalfLife: "336h"},
+	}
+	diff := "diff --git a/a.go b/a.go\n--- a/a.go\n+++ b/a.go\n@@ -1 +1 @@\n-a\n+b\n"
+	examples := llm.ExamplesFrom(WithExamples(context.Background(), cfg, "/repo", "conventional", diff))
+	if len(examples) != 1 || examples[0].Committed != "feat(a): add a" {
+		t.Errorf("examples = %+v, want the stored edit", examples)
+	}
+	if examples := llm.ExamplesFrom(WithExamples(context.Background(), cfg, "/repo", "gitmoji", diff)); examples != nil {
+		t.Errorf("examples for another style = %+v, want none", examples)
+	}
+
+	cfg.Feedback.MaxExamples = 0
+	if examples := llm.ExamplesFrom(WithExamples(context.Background(), cfg, "/repo", "conventional", diff)); examples != nil {
+		t.Errorf("examples with max_examples 0 = %+v, want none", examples)
+	}
+}
@@ -0,0 +1,68 @@
+package feedback
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/klauern/muse/internal/fileops"
+)
+
+// Pending is the message the prepare-commit-msg hook wrote, kept until the
+// post-commit hook compares it with the commit
+type Pending struct {
+	// LedgerID is the usage ledger entry of the generation, if recorded
+	LedgerID string    `json:"ledger_id,omitempty"`
+	Time     time.Time `json:"timestamp"`
+	// Parent is HEAD when the message was generated, empty on an unborn
+	// branch. A commit whose parent differs was not made from this message.
+	Parent   string   `json:"parent"`
+	Style    string   `json:"style"`
+	Provider string   `json:"provider,omitempty"`
+	Model    string   `json:"model,omitempty"`
+	Files    []string `json:"files,omitempty"`
+	Message  string   `json:"message"`
+}
+
+func pendingPath(gitDir string) string {
+	return filepath.Join(gitDir, "muse", "pending-message.json")
+}
+
+// SavePending keeps p in the repository's git directory, replacing any
+// earlier pending message
+func SavePending(gitDir string, p Pending) error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("failed to encode pending message: %w", err)
+	}
+	path := pendingPath(gitDir)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	return fileops.AtomicWriteFile(path, data, 0o600)
+}
+
+// TakePending removes and returns the pending message, or nil when there is
+// none
+func TakePending(gitDir string) (*Pending, error) {
+	path := pendingPath(gitDir)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pending message: %w", err)
+	}
+	if err := os.Remove(path); err != nil {
+		return nil, fmt.Errorf("failed to remove pending message: %w", err)
+	}
+
+	var p Pending
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse pending message %s: %w", path, err)
+	}
+	return &p, nil
+}
@@ -0,0 +1,65 @@
+package fileops
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// maxJSONLine bounds a line ReadJSONLines can decode
+const maxJSONLine = 1024 * 1024
+
+// AppendLine appends line and a newline to filename, creating the file with
+// perm and its directory as private to the user when missing. A single
+// write to a file opened for appending does not interleave with other
+// processes appending to it.
+func AppendLine(filename string, line []byte, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(filename), 0o700); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	f, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, perm)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", filename, err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write %s: %w", filename, err)
+	}
+	return f.Close()
+}
+
+// ReadJSONLines decodes each line of the JSON lines file filename into a T
+// and passes it to fn, in order. Empty lines are ignored and lines that
+// cannot be decoded are logged and skipped. A missing file has no lines.
+func ReadJSONLines[T any](filename string, fn func(T)) error {
+	f, err := os.Open(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", filename, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), maxJSONLine)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var v T
+		if err := json.Unmarshal(scanner.Bytes(), &v); err != nil {
+			slog.Warn("Skipping unreadable line", "path", filename, "line", line, "error", err)
+			continue
+		}
+		fn(v)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read %s: %w", filename, err)
+	}
+	return nil
+}
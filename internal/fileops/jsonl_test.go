@@ -0,0 +1,55 @@
+package fileops
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAppendLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "log.jsonl")
+
+	for _, line := range []string{`{"a":1}`, `{"b":2}`} {
+		if err := AppendLine(path, []byte(line), 0o600); err != nil {
+			t.Fatalf("AppendLine() error = %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(data), "{\"a\":1}\n{\"b\":2}\n"; got != want {
+		t.Errorf("file content = %q, want %q", got, want)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("file permissions = %o, want 600", perm)
+	}
+}
+
+func TestReadJSONLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.jsonl")
+	type record struct {
+		N int `json:"n"`
+	}
+
+	var got []int
+	collect := func(r record) { got = append(got, r.N) }
+	if err := ReadJSONLines(path, collect); err != nil || got != nil {
+		t.Fatalf("ReadJSONLines() on a missing file = %v, %v; want nothing", got, err)
+	}
+
+	if err := os.WriteFile(path, []byte("{\"n\":1}\n\nnot json\n{\"n\":2}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ReadJSONLines(path, collect); err != nil {
+		t.Fatalf("ReadJSONLines() error = %v", err)
+	}
+	if len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Errorf("ReadJSONLines() decoded %v, want [1 2]", got)
+	}
+}
@@ -0,0 +1,87 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// globalOperations returns a GitOperations that is not tied to a repository,
+// for commands that only touch the user's global configuration.
+func globalOperations() *GitOperations {
+	dir, err := os.UserHomeDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return &GitOperations{
+		workingDir: dir,
+		timeout:    10 * time.Second,
+	}
+}
+
+// GetGlobalConfig reads a key from the user's global Git configuration. The
+// returned bool is false when the key is not set.
+func GetGlobalConfig(key string) (string, bool, error) {
+	g := globalOperations()
+	ctx, cancel := context.WithTimeout(context.Background(), g.timeout)
+	defer cancel()
+
+	output, err := g.executeGitCommand(ctx, "config", "--global", "--get", key)
+	if err != nil {
+		// git config exits with 1 when the key does not exist
+		var cmdErr GitCommandError
+		if errors.As(err, &cmdErr) && cmdErr.ExitCode == 1 {
+			return "", false, nil
+		}
+		return "", false, fmt.Errorf("failed to read global git config %s: %w", key, err)
+	}
+
+	return strings.TrimSpace(string(output)), true, nil
+}
+
+// SetGlobalConfig writes a key to the user's global Git configuration
+func SetGlobalConfig(key, value string) error {
+	g := globalOperations()
+	ctx, cancel := context.WithTimeout(context.Background(), g.timeout)
+	defer cancel()
+
+	if _, err := g.executeGitCommand(ctx, "config", "--global", key, value); err != nil {
+		return fmt.Errorf("failed to set global git config %s: %w", key, err)
+	}
+	return nil
+}
+
+// UnsetGlobalConfig removes a key from the user's global Git configuration.
+// Removing a key that is not set is not an error.
+func UnsetGlobalConfig(key string) error {
+	g := globalOperations()
+	ctx, cancel := context.WithTimeout(context.Background(), g.timeout)
+	defer cancel()
+
+	if _, err := g.executeGitCommand(ctx, "config", "--global", "--unset", key); err != nil {
+		// git config --unset exits with 5 when the key does not exist
+		var cmdErr GitCommandError
+		if errors.As(err, &cmdErr) && cmdErr.ExitCode == 5 {
+			return nil
+		}
+		return fmt.Errorf("failed to unset global git config %s: %w", key, err)
+	}
+	return nil
+}
+
+// Version returns the installed Git version, e.g. "2.43.0"
+func Version() (string, error) {
+	g := globalOperations()
+	ctx, cancel := context.WithTimeout(context.Background(), g.timeout)
+	defer cancel()
+
+	output, err := g.executeGitCommand(ctx, "version")
+	if err != nil {
+		return "", fmt.Errorf("failed to get git version: %w", err)
+	}
+
+	return strings.TrimPrefix(strings.TrimSpace(string(output)), "git version "), nil
+}
@@ -0,0 +1,62 @@
+package git
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGlobalConfig_RoundTrip(t *testing.T) {
+	// Point git at an isolated global config file
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	const key = "muse.testValue"
+
+	if _, ok, err := GetGlobalConfig(key); err != nil {
+		t.Fatalf("GetGlobalConfig() failed: %v", err)
+	} else if ok {
+		t.Fatal("Expected key to be unset in a fresh global config")
+	}
+
+	if err := SetGlobalConfig(key, "/tmp/hooks"); err != nil {
+		t.Fatalf("SetGlobalConfig() failed: %v", err)
+	}
+
+	value, ok, err := GetGlobalConfig(key)
+	if err != nil {
+		t.Fatalf("GetGlobalConfig() failed: %v", err)
+	}
+	if !ok || value != "/tmp/hooks" {
+		t.Errorf("GetGlobalConfig() = %q, %v; want %q, true", value, ok, "/tmp/hooks")
+	}
+
+	if err := UnsetGlobalConfig(key); err != nil {
+		t.Fatalf("UnsetGlobalConfig() failed: %v", err)
+	}
+	if err := UnsetGlobalConfig(key); err != nil {
+		t.Errorf("UnsetGlobalConfig() on missing key should succeed, got: %v", err)
+	}
+
+	if _, ok, _ := GetGlobalConfig(key); ok {
+		t.Error("Expected key to be unset")
+	}
+}
+
+func TestGitCommandError(t *testing.T) {
+	err := GitCommandError{ExitCode: 128, Output: "", Stderr: "fatal: bad revision"}
+
+	expected := "git command failed (exit code 128): , stderr: fatal: bad revision"
+	if err.Error() != expected {
+		t.Errorf("GitCommandError.Error() = %q, want %q", err.Error(), expected)
+	}
+}
+
+func TestVersion(t *testing.T) {
+	version, err := Version()
+	if err != nil {
+		t.Fatalf("Version() failed: %v", err)
+	}
+	if version == "" || strings.HasPrefix(version, "git version") {
+		t.Errorf("Version() = %q, want bare version number", version)
+	}
+}
@@ -0,0 +1,83 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// GetStagedPatch returns the staged changes as a patch that git apply
+// accepts, including binary files
+func (g *GitOperations) GetStagedPatch() (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), g.timeout)
+	defer cancel()
+
+	output, err := g.executeGitCommand(ctx, "diff", "--cached", "--no-ext-diff", "--binary")
+	if err != nil {
+		return "", fmt.Errorf("failed to get staged diff: %w", err)
+	}
+	if len(output) > maxDiffSize {
+		return "", fmt.Errorf("%w (%d bytes), maximum allowed: %d bytes",
+			ErrDiffTooLarge, len(output), maxDiffSize)
+	}
+	return string(output), nil
+}
+
+// ApplyToIndex applies patch to the index only, leaving the working tree
+// alone. With reverse the patch is unapplied.
+func (g *GitOperations) ApplyToIndex(patch string, reverse bool) error {
+	args := []string{"apply", "--cached", "--whitespace=nowarn"}
+	if reverse {
+		args = append(args, "--reverse")
+	}
+	args = append(args, "-")
+
+	ctx, cancel := context.WithTimeout(context.Background(), g.timeout)
+	defer cancel()
+
+	if _, err := g.executeGitCommandWithInput(ctx, nil, strings.NewReader(patch), args...); err != nil {
+		return fmt.Errorf("failed to apply patch to the index: %w", err)
+	}
+	return nil
+}
+
+// WriteIndexTree records the index as a tree and returns its hash, so that
+// the index can later be restored with ReadIndexTree
+func (g *GitOperations) WriteIndexTree() (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), g.timeout)
+	defer cancel()
+
+	output, err := g.executeGitCommand(ctx, "write-tree")
+	if err != nil {
+		return "", fmt.Errorf("failed to write the index tree: %w", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// ReadIndexTree replaces the index with tree
+func (g *GitOperations) ReadIndexTree(tree string) error {
+	if err := ValidateRevision(tree); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), g.timeout)
+	defer cancel()
+
+	if _, err := g.executeGitCommand(ctx, "read-tree", tree); err != nil {
+		return fmt.Errorf("failed to restore the index: %w", err)
+	}
+	return nil
+}
+
+// Commit records the index as a new commit with message and returns the new
+// commit's hash. It runs the repository's commit hooks, such as a muse
+// review, which can take far longer than other git commands, so only ctx
+// bounds it.
+func (g *GitOperations) Commit(ctx context.Context, message string) (string, error) {
+	// The message is read from stdin so that it is not subject to the
+	// argument checks
+	if _, err := g.executeGitCommandWithInput(ctx, nil, strings.NewReader(message), "commit", "--quiet", "--file=-"); err != nil {
+		return "", fmt.Errorf("failed to commit: %w", err)
+	}
+	return g.ResolveCommit("HEAD")
+}
@@ -0,0 +1,114 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klauern/muse/internal/gittest"
+)
+
+func TestIndexOperations(t *testing.T) {
+	repo := gittest.New(t)
+	run := repo.Git
+	repo.Git("config", "user.name", "Test")
+	repo.Git("config", "user.email", "test@example.com")
+	repo.Write("a.txt", "a\n")
+	repo.Commit("initial")
+
+	ops, err := NewGitOperations(repo.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo.Write("a.txt", "a\nb\n")
+	repo.Write("c.txt", "c\n")
+	run("add", "a.txt", "c.txt")
+	patch, err := ops.GetStagedPatch()
+	if err != nil {
+		t.Fatalf("GetStagedPatch() error = %v", err)
+	}
+	staged, err := ops.WriteIndexTree()
+	if err != nil {
+		t.Fatalf("WriteIndexTree() error = %v", err)
+	}
+
+	if err := ops.ApplyToIndex(patch, true); err != nil {
+		t.Fatalf("ApplyToIndex(reverse) error = %v", err)
+	}
+	if got := run("diff", "--cached", "--name-only"); got != "" {
+		t.Errorf("index still has changes after reversing the patch: %s", got)
+	}
+	if err := ops.ApplyToIndex(patch, false); err != nil {
+		t.Fatalf("ApplyToIndex() error = %v", err)
+	}
+
+	hash, err := ops.Commit(context.Background(), "feat: add b and c\n\nWith | and ; in the body.\n")
+	if err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	if got := run("log", "-1", "--format=%H %B"); got != hash+" feat: add b and c\n\nWith | and ; in the body." {
+		t.Errorf("commit = %q", got)
+	}
+
+	if err := ops.ReadIndexTree("HEAD~1"); err != nil {
+		t.Fatalf("ReadIndexTree() error = %v", err)
+	}
+	if got := run("diff", "--cached", "--name-only"); got != "a.txt\nc.txt" {
+		t.Errorf("staged after reading HEAD~1 = %q", got)
+	}
+	if err := ops.ReadIndexTree(staged); err != nil {
+		t.Fatalf("ReadIndexTree() error = %v", err)
+	}
+	if got := run("diff", "--cached", "--name-only"); got != "" {
+		t.Errorf("staged after restoring the tree = %q", got)
+	}
+}
+
+func TestGetWorkingTreeDiff(t *testing.T) {
+	repo := gittest.New(t)
+	repo.Write("a.txt", "a\n")
+	repo.Write("b.txt", "b\n")
+	repo.Commit("initial")
+
+	repo.Write("a.txt", "a staged\n")
+	repo.Git("add", "a.txt")
+	repo.Write("b.txt", "b unstaged\n")
+
+	ops, err := NewGitOperations(repo.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	diff, err := ops.GetWorkingTreeDiff()
+	if err != nil {
+		t.Fatalf("GetWorkingTreeDiff() error = %v", err)
+	}
+	if !strings.Contains(diff, "+a staged") || !strings.Contains(diff, "+b unstaged") {
+		t.Errorf("GetWorkingTreeDiff() is missing staged or unstaged changes:\n%s", diff)
+	}
+}
+
+func TestCommit_OutlastsTimeout(t *testing.T) {
+	repo := gittest.New(t)
+	repo.Git("config", "user.name", "Test")
+	repo.Git("config", "user.email", "test@example.com")
+	repo.Write("a.txt", "a\n")
+	repo.Git("add", "a.txt")
+	// A slow hook such as a muse review
+	hook := filepath.Join(repo.Dir, ".git", "hooks", "pre-commit")
+	if err := os.WriteFile(hook, []byte("#!/bin/sh\nsleep 1\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	ops, err := NewGitOperations(repo.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ops.SetTimeout(100 * time.Millisecond)
+	if _, err := ops.Commit(context.Background(), "feat: add a\n"); err != nil {
+		t.Fatalf("Commit() with a hook slower than the timeout error = %v", err)
+	}
+}
@@ -0,0 +1,155 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// PromptLogLimit bounds the commits GetLog loads for a prompt, such as a
+// range summary, that could not fit more anyway
+const PromptLogLimit = 500
+
+// Commit is a single commit as reported by git log
+type Commit struct {
+	Hash    string
+	Author  string
+	Date    time.Time
+	Message string
+}
+
+// Subject returns the first line of the commit message
+func (c Commit) Subject() string {
+	subject, _, _ := strings.Cut(c.Message, "\n")
+	return subject
+}
+
+// ShortHash returns the first 7 characters of the hash
+func (c Commit) ShortHash() string {
+	if len(c.Hash) > 7 {
+		return c.Hash[:7]
+	}
+	return c.Hash
+}
+
+// logFormat separates fields with NUL and commits with a record separator,
+// so that messages may contain any text
+const logFormat = "--format=%H%x00%an%x00%aI%x00%B%x1e"
+
+// GetLog returns the commits in revRange, such as "v1.0..HEAD", newest
+// first. Merge commits are skipped. A limit above zero makes ranges with
+// more commits than that an error rather than silently cutting them short.
+func (g *GitOperations) GetLog(revRange string, limit int) ([]Commit, error) {
+	if err := ValidateRevision(revRange); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), g.timeout)
+	defer cancel()
+
+	args := []string{"log", "--no-merges", logFormat}
+	if limit > 0 {
+		args = append(args, fmt.Sprintf("--max-count=%d", limit+1))
+	}
+	output, err := g.executeGitCommand(ctx, append(args, revRange, "--")...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get log for %s: %w", revRange, err)
+	}
+
+	commits, err := parseLog(string(output))
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 && len(commits) > limit {
+		return nil, fmt.Errorf("%s has more than %d commits; use a shorter range", revRange, limit)
+	}
+	return commits, nil
+}
+
+func parseLog(output string) ([]Commit, error) {
+	var commits []Commit
+	for _, record := range strings.Split(output, "\x1e") {
+		record = strings.TrimLeft(record, "\n")
+		if record == "" {
+			continue
+		}
+		fields := strings.SplitN(record, "\x00", 4)
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("unexpected git log output: %q", record)
+		}
+		date, err := time.Parse(time.RFC3339, fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("unexpected commit date %q: %w", fields[2], err)
+		}
+		commits = append(commits, Commit{
+			Hash:    fields[0],
+			Author:  fields[1],
+			Date:    date,
+			Message: strings.TrimSpace(fields[3]),
+		})
+	}
+	return commits, nil
+}
+
+// ValidateRevision rejects revisions that git would read as options or
+// that contain characters never found in ref names
+func ValidateRevision(rev string) error {
+	if rev == "" {
+		return fmt.Errorf("revision must not be empty")
+	}
+	if strings.HasPrefix(rev, "-") {
+		return fmt.Errorf("invalid revision %q: must not start with '-'", rev)
+	}
+	if strings.ContainsAny(rev, " \t\n\x00\\") {
+		return fmt.Errorf("invalid revision %q: contains whitespace or control characters", rev)
+	}
+	return nil
+}
+
+// GetCommitMessage returns the full message of the commit rev
+func (g *GitOperations) GetCommitMessage(rev string) (string, error) {
+	if err := ValidateRevision(rev); err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), g.timeout)
+	defer cancel()
+
+	output, err := g.executeGitCommand(ctx, "show", "-s", "--format=%B", rev, "--")
+	if err != nil {
+		return "", fmt.Errorf("failed to read the message of %s: %w", rev, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// Show returns git show output for rev, a commit or a range: the commit
+// messages followed by their patches
+func (g *GitOperations) Show(rev string) (string, error) {
+	return g.show(rev, "--patch")
+}
+
+// ShowStat is Show with a per-file summary instead of patches
+func (g *GitOperations) ShowStat(rev string) (string, error) {
+	return g.show(rev, "--stat")
+}
+
+func (g *GitOperations) show(rev string, flags ...string) (string, error) {
+	if err := ValidateRevision(rev); err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), g.timeout)
+	defer cancel()
+
+	args := append([]string{"show", "--no-color", "--no-ext-diff"}, flags...)
+	output, err := g.executeGitCommand(ctx, append(args, rev, "--")...)
+	if err != nil {
+		return "", fmt.Errorf("failed to show %s: %w", rev, err)
+	}
+	if len(output) > maxDiffSize {
+		return "", fmt.Errorf("%w (%d bytes), maximum allowed: %d bytes",
+			ErrDiffTooLarge, len(output), maxDiffSize)
+	}
+	return string(output), nil
+}
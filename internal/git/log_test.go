@@ -0,0 +1,97 @@
+package git
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/klauern/muse/internal/gittest"
+)
+
+// newTestRepo creates a repository with the given commit messages, oldest
+// first
+func newTestRepo(t *testing.T, messages ...string) *GitOperations {
+	t.Helper()
+	repo := gittest.New(t)
+	for _, message := range messages {
+		repo.Commit(message)
+	}
+
+	ops, err := NewGitOperations(repo.Dir)
+	if err != nil {
+		t.Fatalf("Failed to create GitOperations: %v", err)
+	}
+	return ops
+}
+
+func TestGetLog(t *testing.T) {
+	ops := newTestRepo(t, "chore: initial", "feat(cli): add watch\n\nWatches the index.", "fix: handle | and & in messages")
+
+	commits, err := ops.GetLog("HEAD~2..HEAD", 0)
+	if err != nil {
+		t.Fatalf("GetLog() error = %v", err)
+	}
+	if len(commits) != 2 {
+		t.Fatalf("GetLog() returned %d commits, want 2", len(commits))
+	}
+	if commits[0].Subject() != "fix: handle | and & in messages" {
+		t.Errorf("newest commit subject = %q", commits[0].Subject())
+	}
+	if commits[1].Message != "feat(cli): add watch\n\nWatches the index." {
+		t.Errorf("commit message = %q", commits[1].Message)
+	}
+	if commits[1].Author != "Test" || commits[1].Date.IsZero() || len(commits[1].ShortHash()) != 7 {
+		t.Errorf("commit metadata = %+v", commits[1])
+	}
+
+	if commits, err := ops.GetLog("HEAD~2..HEAD", 2); err != nil || len(commits) != 2 {
+		t.Errorf("GetLog() at the limit = %d commits, %v", len(commits), err)
+	}
+	if _, err := ops.GetLog("HEAD~2..HEAD", 1); err == nil || !strings.Contains(err.Error(), "more than 1 commits") {
+		t.Errorf("GetLog() over the limit error = %v, want an error instead of a truncated log", err)
+	}
+}
+
+func TestGetCommitMessage(t *testing.T) {
+	ops := newTestRepo(t, "feat(cli): add watch\n\nWatches the index.")
+
+	message, err := ops.GetCommitMessage("HEAD")
+	if err != nil {
+		t.Fatalf("GetCommitMessage() error = %v", err)
+	}
+	if message != "feat(cli): add watch\n\nWatches the index." {
+		t.Errorf("GetCommitMessage() = %q", message)
+	}
+	if _, err := ops.GetCommitMessage("HEAD~1"); err == nil {
+		t.Error("GetCommitMessage() of a missing commit succeeded")
+	}
+}
+
+func TestValidateRevision(t *testing.T) {
+	for _, rev := range []string{"HEAD", "v1.0..HEAD", "main...feature", "abc123^"} {
+		if err := ValidateRevision(rev); err != nil {
+			t.Errorf("ValidateRevision(%q) = %v", rev, err)
+		}
+	}
+	for _, rev := range []string{"", "--output=/tmp/x", "a b", "a\nb"} {
+		if err := ValidateRevision(rev); err == nil {
+			t.Errorf("ValidateRevision(%q) accepted an invalid revision", rev)
+		}
+	}
+}
+
+func TestShow(t *testing.T) {
+	ops := newTestRepo(t, "chore: initial", "feat: add watch", "fix: handle errors")
+
+	output, err := ops.Show("HEAD~2..HEAD")
+	if err != nil {
+		t.Fatalf("Show() error = %v", err)
+	}
+	if !strings.Contains(output, "feat: add watch") || !strings.Contains(output, "fix: handle errors") ||
+		strings.Contains(output, "chore: initial") {
+		t.Errorf("Show() output does not match the range:\n%s", output)
+	}
+
+	if _, err := ops.ShowStat("--output=/tmp/x"); err == nil {
+		t.Error("ShowStat() accepted an option as revision")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -11,6 +12,12 @@ import (
 	"time"
 )
 
+// maxDiffSize bounds diffs read into memory
+const maxDiffSize = 1024 * 1024 // 1MB
+
+// ErrDiffTooLarge is returned when a diff exceeds the size limit
+var ErrDiffTooLarge = errors.New("diff too large")
+
 // GitOperations provides safe Git operations with validation and security controls
 type GitOperations struct {
 	workingDir string
@@ -27,6 +34,18 @@ func (e GitValidationError) Error() string {
 	return fmt.Sprintf("git validation failed: %s (path: %s)", e.Reason, e.Path)
 }
 
+// GitCommandError represents a Git command that ran but exited non-zero
+type GitCommandError struct {
+	Args     []string
+	ExitCode int
+	Output   string
+	Stderr   string
+}
+
+func (e GitCommandError) Error() string {
+	return fmt.Sprintf("git command failed (exit code %d): %s, stderr: %s", e.ExitCode, e.Output, e.Stderr)
+}
+
 // NewGitOperations creates a new GitOperations instance with validation
 func NewGitOperations(workingDir string) (*GitOperations, error) {
 	if workingDir == "" {
@@ -107,6 +126,13 @@ func (g *GitOperations) validateGitRepository() error {
 
 // executeGitCommand safely executes a Git command with validation
 func (g *GitOperations) executeGitCommand(ctx context.Context, args ...string) ([]byte, error) {
+	return g.executeGitCommandWithInput(ctx, nil, nil, args...)
+}
+
+// executeGitCommandWithInput is executeGitCommand with extra environment
+// variables and standard input, for plumbing commands that read data such
+// as commit messages from stdin rather than arguments
+func (g *GitOperations) executeGitCommandWithInput(ctx context.Context, env []string, stdin io.Reader, args ...string) ([]byte, error) {
 	// Validate arguments
 	if err := g.validateGitArgs(args); err != nil {
 		return nil, fmt.Errorf("invalid git arguments: %w", err)
@@ -114,20 +140,26 @@ func (g *GitOperations) executeGitCommand(ctx context.Context, args ...string) (
 
 	cmd := exec.CommandContext(ctx, "git", args...)
 	cmd.Dir = g.workingDir
+	cmd.Stdin = stdin
 
 	// Set environment to prevent Git from reading user config in some cases
 	cmd.Env = append(os.Environ(),
 		"GIT_CONFIG_NOSYSTEM=1",
 		"GIT_TERMINAL_PROMPT=0",
 	)
+	cmd.Env = append(cmd.Env, env...)
 
 	output, err := cmd.Output()
 	if err != nil {
 		// Enhanced error context
 		var exitErr *exec.ExitError
 		if errors.As(err, &exitErr) {
-			return nil, fmt.Errorf("git command failed (exit code %d): %s, stderr: %s",
-				exitErr.ExitCode(), string(output), string(exitErr.Stderr))
+			return nil, GitCommandError{
+				Args:     args,
+				ExitCode: exitErr.ExitCode(),
+				Output:   string(output),
+				Stderr:   string(exitErr.Stderr),
+			}
 		}
 		return nil, fmt.Errorf("git command execution failed: %w", err)
 	}
@@ -143,13 +175,25 @@ func (g *GitOperations) validateGitArgs(args []string) error {
 
 	// Whitelist allowed git commands for safety
 	allowedCommands := map[string]bool{
-		"diff":      true,
-		"status":    true,
-		"rev-parse": true,
-		"log":       true,
-		"show":      true,
-		"branch":    true,
-		"config":    true,
+		"diff":       true,
+		"status":     true,
+		"rev-parse":  true,
+		"merge-base": true,
+		"log":        true,
+		"show":       true,
+		"branch":     true,
+		"config":     true,
+		"version":    true,
+		// commit-tree and update-ref only create objects and move refs;
+		// they are used to reword history without a rebase
+		"commit-tree": true,
+		"update-ref":  true,
+		// apply, write-tree, read-tree and commit only touch the index and
+		// the current branch; they are used to split staged changes
+		"apply":      true,
+		"write-tree": true,
+		"read-tree":  true,
+		"commit":     true,
 	}
 
 	command := args[0]
@@ -187,15 +231,32 @@ func (g *GitOperations) GetStagedDiff() (string, error) {
 	diff := string(output)
 
 	// Validate diff size to prevent memory exhaustion
-	const maxDiffSize = 1024 * 1024 // 1MB
 	if len(diff) > maxDiffSize {
-		return "", fmt.Errorf("diff too large (%d bytes), maximum allowed: %d bytes",
-			len(diff), maxDiffSize)
+		return "", fmt.Errorf("%w (%d bytes), maximum allowed: %d bytes",
+			ErrDiffTooLarge, len(diff), maxDiffSize)
 	}
 
 	return diff, nil
 }
 
+// GetWorkingTreeDiff returns the staged and unstaged changes to tracked
+// files, compared with HEAD
+func (g *GitOperations) GetWorkingTreeDiff() (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), g.timeout)
+	defer cancel()
+
+	output, err := g.executeGitCommand(ctx, "diff", "--no-ext-diff", "HEAD", "--")
+	if err != nil {
+		return "", fmt.Errorf("failed to get working tree diff: %w", err)
+	}
+
+	if len(output) > maxDiffSize {
+		return "", fmt.Errorf("%w (%d bytes), maximum allowed: %d bytes",
+			ErrDiffTooLarge, len(output), maxDiffSize)
+	}
+	return string(output), nil
+}
+
 // GetRepository returns information about the current repository
 func (g *GitOperations) GetRepositoryInfo() (*RepositoryInfo, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), g.timeout)
@@ -219,6 +280,62 @@ func (g *GitOperations) GetRepositoryInfo() (*RepositoryInfo, error) {
 	}, nil
 }
 
+// GetRepositoryRoot returns the top-level directory of the working tree
+func (g *GitOperations) GetRepositoryRoot() (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), g.timeout)
+	defer cancel()
+
+	output, err := g.executeGitCommand(ctx, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", fmt.Errorf("failed to get repository root: %w", err)
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
+// GetGitDir returns the absolute path of the repository's .git directory
+func (g *GitOperations) GetGitDir() (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), g.timeout)
+	defer cancel()
+
+	output, err := g.executeGitCommand(ctx, "rev-parse", "--absolute-git-dir")
+	if err != nil {
+		return "", fmt.Errorf("failed to get git directory: %w", err)
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
+// GetCommonDir returns the absolute path of the .git directory shared by all
+// worktrees of the repository
+func (g *GitOperations) GetCommonDir() (string, error) {
+	return g.revParsePath("--git-common-dir")
+}
+
+// GetHooksDir returns the absolute path of the directory git runs hooks
+// from, which core.hooksPath moves out of .git/hooks
+func (g *GitOperations) GetHooksDir() (string, error) {
+	return g.revParsePath("--git-path", "hooks")
+}
+
+// revParsePath runs git rev-parse for a path, which git prints relative to
+// the working directory
+func (g *GitOperations) revParsePath(args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), g.timeout)
+	defer cancel()
+
+	output, err := g.executeGitCommand(ctx, append([]string{"rev-parse"}, args...)...)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve git path: %w", err)
+	}
+
+	path := strings.TrimSpace(string(output))
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(g.workingDir, path)
+	}
+	return path, nil
+}
+
 // GetStatus safely retrieves the repository status
 func (g *GitOperations) GetStatus() (string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), g.timeout)
@@ -232,6 +349,47 @@ func (g *GitOperations) GetStatus() (string, error) {
 	return string(output), nil
 }
 
+// GetConfig reads a key from the repository's effective Git configuration.
+// The returned bool is false when the key is not set.
+func (g *GitOperations) GetConfig(key string) (string, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), g.timeout)
+	defer cancel()
+
+	output, err := g.executeGitCommand(ctx, "config", "--get", key)
+	if err != nil {
+		var cmdErr GitCommandError
+		if errors.As(err, &cmdErr) && cmdErr.ExitCode == 1 {
+			return "", false, nil
+		}
+		return "", false, fmt.Errorf("failed to read git config %s: %w", key, err)
+	}
+
+	return strings.TrimSpace(string(output)), true, nil
+}
+
+// GetRemoteURLs returns the URLs of every configured remote
+func (g *GitOperations) GetRemoteURLs() ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), g.timeout)
+	defer cancel()
+
+	output, err := g.executeGitCommand(ctx, "config", "--get-regexp", `^remote\..*\.url$`)
+	if err != nil {
+		var cmdErr GitCommandError
+		if errors.As(err, &cmdErr) && cmdErr.ExitCode == 1 {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read remote URLs: %w", err)
+	}
+
+	var urls []string
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if _, url, ok := strings.Cut(line, " "); ok {
+			urls = append(urls, strings.TrimSpace(url))
+		}
+	}
+	return urls, nil
+}
+
 // RepositoryInfo contains basic repository information
 type RepositoryInfo struct {
 	Root   string
@@ -8,6 +8,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/klauern/muse/internal/gittest"
 )
 
 func TestNewGitOperations(t *testing.T) {
@@ -69,6 +71,21 @@ func TestValidateGitArgs(t *testing.T) {
 			args:      []string{"status", "--porcelain"},
 			wantError: false,
 		},
+		{
+			name:      "valid merge-base command",
+			args:      []string{"merge-base", "main", "HEAD"},
+			wantError: false,
+		},
+		{
+			name:      "valid commit-tree command",
+			args:      []string{"commit-tree", "HEAD^{tree}", "-p", "HEAD", "-F", "-"},
+			wantError: false,
+		},
+		{
+			name:      "valid apply command",
+			args:      []string{"apply", "--cached", "-"},
+			wantError: false,
+		},
 		{
 			name:      "invalid command",
 			args:      []string{"push", "origin", "main"},
@@ -180,6 +197,28 @@ func TestGetRepositoryInfo(t *testing.T) {
 	t.Logf("Repository info: root=%s, branch=%s", info.Root, info.Branch)
 }
 
+func TestGetGitDir(t *testing.T) {
+	if !isGitRepository() {
+		t.Skip("Skipping test - not in a git repository")
+	}
+
+	ops, err := NewGitOperations("")
+	if err != nil {
+		t.Fatalf("Failed to create GitOperations: %v", err)
+	}
+
+	gitDir, err := ops.GetGitDir()
+	if err != nil {
+		t.Fatalf("GetGitDir() error = %v", err)
+	}
+	if !filepath.IsAbs(gitDir) {
+		t.Errorf("Expected git directory to be absolute path, got %s", gitDir)
+	}
+	if info, err := os.Stat(gitDir); err != nil || !info.IsDir() {
+		t.Errorf("Git directory does not exist: %s", gitDir)
+	}
+}
+
 func TestGetStatus(t *testing.T) {
 	if !isGitRepository() {
 		t.Skip("Skipping test - not in a git repository")
@@ -294,3 +333,29 @@ func TestGetStagedDiff_SizeLimit(t *testing.T) {
 		t.Errorf("Unexpected error: %v", err)
 	}
 }
+
+func TestGetRemoteURLs(t *testing.T) {
+	repo := gittest.New(t)
+
+	ops, err := NewGitOperations(repo.Dir)
+	if err != nil {
+		t.Fatalf("Failed to create GitOperations: %v", err)
+	}
+
+	urls, err := ops.GetRemoteURLs()
+	if err != nil || len(urls) != 0 {
+		t.Fatalf("GetRemoteURLs() = %v, %v; want no remotes", urls, err)
+	}
+
+	repo.Git("remote", "add", "origin", "git@github.com:acme/app.git")
+	repo.Git("remote", "add", "upstream", "https://github.com/upstream/app")
+
+	urls, err = ops.GetRemoteURLs()
+	if err != nil {
+		t.Fatalf("GetRemoteURLs() error = %v", err)
+	}
+	want := []string{"git@github.com:acme/app.git", "https://github.com/upstream/app"}
+	if strings.Join(urls, " ") != strings.Join(want, " ") {
+		t.Errorf("GetRemoteURLs() = %v, want %v", urls, want)
+	}
+}
@@ -0,0 +1,86 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// GetMergeBase returns the best common ancestor of a and b
+func (g *GitOperations) GetMergeBase(a, b string) (string, error) {
+	for _, rev := range []string{a, b} {
+		if err := ValidateRevision(rev); err != nil {
+			return "", err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), g.timeout)
+	defer cancel()
+
+	output, err := g.executeGitCommand(ctx, "merge-base", a, b)
+	if err != nil {
+		var cmdErr GitCommandError
+		if errors.As(err, &cmdErr) && cmdErr.ExitCode == 1 {
+			return "", fmt.Errorf("%s and %s have no common ancestor", a, b)
+		}
+		return "", fmt.Errorf("failed to find merge base of %s and %s: %w", a, b, err)
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
+// GetRangeDiff returns the changes between the from and to revisions. It
+// returns ErrDiffTooLarge when the diff exceeds the size limit.
+func (g *GitOperations) GetRangeDiff(from, to string) (string, error) {
+	return g.rangeDiff(from, to)
+}
+
+// GetRangeDiffStat returns a per-file summary of the changes between the
+// from and to revisions, for ranges too large to send in full
+func (g *GitOperations) GetRangeDiffStat(from, to string) (string, error) {
+	return g.rangeDiff(from, to, "--stat")
+}
+
+func (g *GitOperations) rangeDiff(from, to string, flags ...string) (string, error) {
+	for _, rev := range []string{from, to} {
+		if err := ValidateRevision(rev); err != nil {
+			return "", err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), g.timeout)
+	defer cancel()
+
+	args := append([]string{"diff", "--no-ext-diff"}, flags...)
+	args = append(args, from, to, "--")
+	output, err := g.executeGitCommand(ctx, args...)
+	if err != nil {
+		return "", fmt.Errorf("failed to get diff between %s and %s: %w", from, to, err)
+	}
+
+	if len(output) > maxDiffSize {
+		return "", fmt.Errorf("%w (%d bytes), maximum allowed: %d bytes",
+			ErrDiffTooLarge, len(output), maxDiffSize)
+	}
+	return string(output), nil
+}
+
+// GetDefaultBranch guesses the branch pull requests target: the remote's
+// HEAD when known, otherwise a local main or master
+func (g *GitOperations) GetDefaultBranch() (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), g.timeout)
+	defer cancel()
+
+	if output, err := g.executeGitCommand(ctx, "rev-parse", "--abbrev-ref", "origin/HEAD"); err == nil {
+		if branch := strings.TrimSpace(string(output)); branch != "" && branch != "origin/HEAD" {
+			return branch, nil
+		}
+	}
+	for _, branch := range []string{"main", "master"} {
+		if _, err := g.executeGitCommand(ctx, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch); err == nil {
+			return branch, nil
+		}
+	}
+	return "", fmt.Errorf("could not determine the default branch; pass one explicitly")
+}
@@ -0,0 +1,77 @@
+package git
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/klauern/muse/internal/gittest"
+)
+
+// newBranchRepo creates a repository whose feature branch changes a file
+// after forking from main, and returns it checked out on feature
+func newBranchRepo(t *testing.T) *GitOperations {
+	t.Helper()
+	repo := gittest.New(t, "-b", "main")
+	repo.Write("file.txt", "one\n")
+	repo.Commit("chore: initial")
+	repo.Git("checkout", "-q", "-b", "feature")
+	repo.Write("file.txt", "one\ntwo\n")
+	repo.Commit("feat: add two")
+	repo.Git("checkout", "-q", "main")
+	repo.Commit("chore: unrelated")
+	repo.Git("checkout", "-q", "feature")
+
+	ops, err := NewGitOperations(repo.Dir)
+	if err != nil {
+		t.Fatalf("Failed to create GitOperations: %v", err)
+	}
+	return ops
+}
+
+func TestGetMergeBaseAndRangeDiff(t *testing.T) {
+	ops := newBranchRepo(t)
+
+	base, err := ops.GetMergeBase("main", "HEAD")
+	if err != nil {
+		t.Fatalf("GetMergeBase() error = %v", err)
+	}
+	commits, err := ops.GetLog(base+"..HEAD", 0)
+	if err != nil {
+		t.Fatalf("GetLog() error = %v", err)
+	}
+	if len(commits) != 1 || commits[0].Subject() != "feat: add two" {
+		t.Errorf("commits since merge base = %+v, want only the feature commit", commits)
+	}
+
+	diff, err := ops.GetRangeDiff(base, "HEAD")
+	if err != nil {
+		t.Fatalf("GetRangeDiff() error = %v", err)
+	}
+	if !strings.Contains(diff, "+two") {
+		t.Errorf("GetRangeDiff() = %q, want the feature change", diff)
+	}
+
+	stat, err := ops.GetRangeDiffStat(base, "HEAD")
+	if err != nil {
+		t.Fatalf("GetRangeDiffStat() error = %v", err)
+	}
+	if !strings.Contains(stat, "file.txt | 1 +") {
+		t.Errorf("GetRangeDiffStat() = %q", stat)
+	}
+
+	if _, err := ops.GetMergeBase("--all", "HEAD"); err == nil {
+		t.Error("GetMergeBase() accepted an option as a revision")
+	}
+}
+
+func TestGetDefaultBranch(t *testing.T) {
+	ops := newBranchRepo(t)
+
+	branch, err := ops.GetDefaultBranch()
+	if err != nil {
+		t.Fatalf("GetDefaultBranch() error = %v", err)
+	}
+	if branch != "main" {
+		t.Errorf("GetDefaultBranch() = %q, want main", branch)
+	}
+}
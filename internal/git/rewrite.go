@@ -0,0 +1,176 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// CommitInfo holds what is needed to recreate a commit with a different
+// message
+type CommitInfo struct {
+	Hash        string
+	Tree        string
+	Parents     []string
+	AuthorName  string
+	AuthorEmail string
+	AuthorDate  string
+}
+
+// ResolveCommit returns the full hash of the commit rev names
+func (g *GitOperations) ResolveCommit(rev string) (string, error) {
+	if err := ValidateRevision(rev); err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), g.timeout)
+	defer cancel()
+
+	output, err := g.executeGitCommand(ctx, "rev-parse", "--verify", "--quiet", rev+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("unknown revision %s: %w", rev, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// GetCommitInfo returns the tree, parents and author of a commit
+func (g *GitOperations) GetCommitInfo(rev string) (*CommitInfo, error) {
+	if err := ValidateRevision(rev); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), g.timeout)
+	defer cancel()
+
+	output, err := g.executeGitCommand(ctx, "show", "-s", "--format=%H%x00%T%x00%P%x00%an%x00%ae%x00%aI", rev, "--")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read commit %s: %w", rev, err)
+	}
+
+	fields := strings.Split(strings.TrimSpace(string(output)), "\x00")
+	if len(fields) != 6 {
+		return nil, fmt.Errorf("unexpected git show output: %q", output)
+	}
+	return &CommitInfo{
+		Hash:        fields[0],
+		Tree:        fields[1],
+		Parents:     strings.Fields(fields[2]),
+		AuthorName:  fields[3],
+		AuthorEmail: fields[4],
+		AuthorDate:  fields[5],
+	}, nil
+}
+
+// GetCommitDiff returns the changes a commit introduced. It returns
+// ErrDiffTooLarge when the diff exceeds the size limit.
+func (g *GitOperations) GetCommitDiff(rev string) (string, error) {
+	if err := ValidateRevision(rev); err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), g.timeout)
+	defer cancel()
+
+	output, err := g.executeGitCommand(ctx, "show", "--format=", "--patch", "--no-ext-diff", rev, "--")
+	if err != nil {
+		return "", fmt.Errorf("failed to get diff of %s: %w", rev, err)
+	}
+	if len(output) > maxDiffSize {
+		return "", fmt.Errorf("%w (%d bytes), maximum allowed: %d bytes",
+			ErrDiffTooLarge, len(output), maxDiffSize)
+	}
+	return string(output), nil
+}
+
+// HasMerges reports whether revRange contains merge commits
+func (g *GitOperations) HasMerges(revRange string) (bool, error) {
+	if err := ValidateRevision(revRange); err != nil {
+		return false, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), g.timeout)
+	defer cancel()
+
+	output, err := g.executeGitCommand(ctx, "log", "--merges", "--max-count=1", "--format=%H", revRange, "--")
+	if err != nil {
+		return false, fmt.Errorf("failed to check %s for merges: %w", revRange, err)
+	}
+	return strings.TrimSpace(string(output)) != "", nil
+}
+
+// CreateCommit writes a commit with the tree, parents and author of info
+// and the given message, and returns its hash. The committer is the
+// current user, as with git rebase.
+func (g *GitOperations) CreateCommit(info *CommitInfo, message string) (string, error) {
+	args := []string{"commit-tree", info.Tree}
+	for _, parent := range info.Parents {
+		args = append(args, "-p", parent)
+	}
+	// The message is read from stdin so that it is not subject to the
+	// argument checks
+	args = append(args, "-F", "-")
+	env := []string{
+		"GIT_AUTHOR_NAME=" + info.AuthorName,
+		"GIT_AUTHOR_EMAIL=" + info.AuthorEmail,
+		"GIT_AUTHOR_DATE=" + info.AuthorDate,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), g.timeout)
+	defer cancel()
+
+	output, err := g.executeGitCommandWithInput(ctx, env, strings.NewReader(message), args...)
+	if err != nil {
+		return "", fmt.Errorf("failed to create commit: %w", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// UpdateRef points ref at newHash, provided it still points at oldHash
+func (g *GitOperations) UpdateRef(ref, newHash, oldHash, reason string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), g.timeout)
+	defer cancel()
+
+	if _, err := g.executeGitCommand(ctx, "update-ref", "-m", reason, ref, newHash, oldHash); err != nil {
+		return fmt.Errorf("failed to update %s: %w", ref, err)
+	}
+	return nil
+}
+
+// GetHeadRef returns the ref HEAD points at, such as refs/heads/main, or
+// HEAD when it is detached
+func (g *GitOperations) GetHeadRef() (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), g.timeout)
+	defer cancel()
+
+	output, err := g.executeGitCommand(ctx, "rev-parse", "--symbolic-full-name", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve HEAD: %w", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// GetRemoteBranchesContaining returns the remote-tracking branches, such as
+// origin/main, that contain rev
+func (g *GitOperations) GetRemoteBranchesContaining(rev string) ([]string, error) {
+	if err := ValidateRevision(rev); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), g.timeout)
+	defer cancel()
+
+	output, err := g.executeGitCommand(ctx, "branch", "--remotes", "--contains", rev, "--format=%(refname)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list remote branches containing %s: %w", rev, err)
+	}
+
+	var branches []string
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		// origin/HEAD is an alias for another remote branch
+		if line == "" || strings.HasSuffix(line, "/HEAD") {
+			continue
+		}
+		branches = append(branches, strings.TrimPrefix(line, "refs/remotes/"))
+	}
+	return branches, nil
+}
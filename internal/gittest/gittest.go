@@ -0,0 +1,68 @@
+// Package gittest creates throwaway git repositories for tests, so each
+// package does not carry its own copy of the fixture.
+package gittest
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// identity commits as Test regardless of the user's git config
+var identity = []string{
+	"GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=test@example.com",
+	"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=test@example.com",
+}
+
+// Repo is a repository in a temporary directory removed when the test ends
+type Repo struct {
+	Dir string
+	// Env is added to the environment of every git command, after the
+	// default identity so it can override it
+	Env []string
+
+	t testing.TB
+}
+
+// New runs git init with args in a new temporary directory
+func New(t testing.TB, args ...string) *Repo {
+	t.Helper()
+	r := &Repo{Dir: t.TempDir(), t: t}
+	r.Git(append([]string{"init", "-q"}, args...)...)
+	return r
+}
+
+// Git runs git in the repository and returns its trimmed output, failing
+// the test when it exits non-zero
+func (r *Repo) Git(args ...string) string {
+	r.t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = r.Dir
+	cmd.Env = append(append(cmd.Environ(), identity...), r.Env...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		r.t.Fatalf("git %v failed: %v\n%s", args, err, output)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// Write creates or replaces name, relative to the repository, with content
+func (r *Repo) Write(name, content string) {
+	r.t.Helper()
+	path := filepath.Join(r.Dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		r.t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		r.t.Fatal(err)
+	}
+}
+
+// Commit stages every change and commits it with message
+func (r *Repo) Commit(message string) {
+	r.t.Helper()
+	r.Git("add", "-A")
+	r.Git("commit", "-q", "--allow-empty", "-m", message)
+}
@@ -0,0 +1,209 @@
+package jsonrpc
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+)
+
+// Version is the only JSON-RPC version supported
+const Version = "2.0"
+
+// Standard error codes
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// maxMessageSize bounds a single message; diffs are limited to 1MB already
+const maxMessageSize = 8 * 1024 * 1024
+
+// Request is a call or, when ID is empty, a notification
+type Request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// IsNotification reports whether the request expects no response
+func (r *Request) IsNotification() bool {
+	return len(r.ID) == 0
+}
+
+type Response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+type notification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params,omitempty"`
+}
+
+// Error is a JSON-RPC error object. Handlers return it to choose the code;
+// any other error is reported as an internal error.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("jsonrpc error %d: %s", e.Code, e.Message)
+}
+
+// InvalidParams returns an error for params that could not be decoded or
+// are missing required values
+func InvalidParams(format string, args ...any) *Error {
+	return &Error{Code: CodeInvalidParams, Message: fmt.Sprintf(format, args...)}
+}
+
+// Handler answers one method. params is nil when the request had none.
+type Handler func(ctx context.Context, params json.RawMessage) (any, error)
+
+// Server dispatches newline-delimited JSON-RPC messages, as used by stdio
+// transports, to registered handlers
+type Server struct {
+	handlers map[string]Handler
+
+	writeMu sync.Mutex
+	w       io.Writer
+}
+
+func NewServer() *Server {
+	return &Server{handlers: make(map[string]Handler)}
+}
+
+// Handle registers h for method
+func (s *Server) Handle(method string, h Handler) {
+	s.handlers[method] = h
+}
+
+// Serve reads requests from r and writes responses to w until r is
+// exhausted or ctx is done. Requests are handled one at a time.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	s.w = w
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxMessageSize)
+
+	for scanner.Scan() {
+		if ctx.Err() != nil {
+			return nil
+		}
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		s.handle(ctx, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read request: %w", err)
+	}
+	return nil
+}
+
+func (s *Server) handle(ctx context.Context, line []byte) {
+	var req Request
+	if err := json.Unmarshal(line, &req); err != nil {
+		s.respond(nil, nil, &Error{Code: CodeParseError, Message: err.Error()})
+		return
+	}
+	if req.JSONRPC != Version || req.Method == "" {
+		if !req.IsNotification() {
+			s.respond(req.ID, nil, &Error{Code: CodeInvalidRequest, Message: "expected a JSON-RPC 2.0 request"})
+		}
+		return
+	}
+
+	handler, ok := s.handlers[req.Method]
+	if !ok {
+		if !req.IsNotification() {
+			s.respond(req.ID, nil, &Error{Code: CodeMethodNotFound, Message: "method not found: " + req.Method})
+		}
+		return
+	}
+
+	result, err := handler(withNotifier(ctx, s), req.Params)
+	if req.IsNotification() {
+		if err != nil {
+			slog.Debug("Notification handler failed", "method", req.Method, "error", err)
+		}
+		return
+	}
+	if err != nil {
+		var rpcErr *Error
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &Error{Code: CodeInternalError, Message: err.Error()}
+		}
+		s.respond(req.ID, nil, rpcErr)
+		return
+	}
+	if result == nil {
+		result = struct{}{}
+	}
+	s.respond(req.ID, result, nil)
+}
+
+func (s *Server) respond(id json.RawMessage, result any, rpcErr *Error) {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	s.write(Response{JSONRPC: Version, ID: id, Result: result, Error: rpcErr})
+}
+
+// Notify sends a notification to the client
+func (s *Server) Notify(method string, params any) {
+	s.write(notification{JSONRPC: Version, Method: method, Params: params})
+}
+
+func (s *Server) write(v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		slog.Error("Failed to encode JSON-RPC message", "error", err)
+		return
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	if _, err := s.w.Write(append(data, '\n')); err != nil {
+		slog.Debug("Failed to write JSON-RPC message", "error", err)
+	}
+}
+
+type notifierKey struct{}
+
+func withNotifier(ctx context.Context, s *Server) context.Context {
+	return context.WithValue(ctx, notifierKey{}, s)
+}
+
+// Notify sends a notification from within a handler, e.g. to stream
+// progress. It does nothing outside a handler.
+func Notify(ctx context.Context, method string, params any) {
+	if s, ok := ctx.Value(notifierKey{}).(*Server); ok {
+		s.Notify(method, params)
+	}
+}
+
+// DecodeParams unmarshals params into v, reporting failures as
+// invalid-params errors. Absent params leave v unchanged.
+func DecodeParams(params json.RawMessage, v any) error {
+	if len(params) == 0 || string(params) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(params, v); err != nil {
+		return InvalidParams("invalid params: %v", err)
+	}
+	return nil
+}
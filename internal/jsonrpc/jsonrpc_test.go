@@ -0,0 +1,95 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func serve(t *testing.T, s *Server, input string) []map[string]any {
+	t.Helper()
+	var out bytes.Buffer
+	if err := s.Serve(context.Background(), strings.NewReader(input), &out); err != nil {
+		t.Fatalf("Serve() error = %v", err)
+	}
+
+	var messages []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var m map[string]any
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatalf("invalid output line %q: %v", line, err)
+		}
+		messages = append(messages, m)
+	}
+	return messages
+}
+
+func TestServer_Serve(t *testing.T) {
+	s := NewServer()
+	s.Handle("echo", func(ctx context.Context, params json.RawMessage) (any, error) {
+		var p struct {
+			Text string `json:"text"`
+		}
+		if err := DecodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		Notify(ctx, "progress", map[string]string{"text": p.Text})
+		return map[string]string{"text": p.Text}, nil
+	})
+	s.Handle("fail", func(ctx context.Context, params json.RawMessage) (any, error) {
+		return nil, errors.New("boom")
+	})
+
+	input := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"echo","params":{"text":"hi"}}`,
+		`{"jsonrpc":"2.0","method":"echo","params":{"text":"note"}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"missing"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"fail"}`,
+		`{"jsonrpc":"2.0","id":4,"method":"echo","params":{"text":5}}`,
+		`not json`,
+	}, "\n")
+	messages := serve(t, s, input)
+
+	want := []struct {
+		id     any
+		method string
+		code   float64
+	}{
+		{method: "progress"},
+		{id: float64(1)},
+		// The notification's handler still runs, but gets no response
+		{method: "progress"},
+		{id: float64(2), code: CodeMethodNotFound},
+		{id: float64(3), code: CodeInternalError},
+		{id: float64(4), code: CodeInvalidParams},
+		{id: nil, code: CodeParseError},
+	}
+	if len(messages) != len(want) {
+		t.Fatalf("got %d messages, want %d: %v", len(messages), len(want), messages)
+	}
+	for i, w := range want {
+		m := messages[i]
+		if w.method != "" {
+			if m["method"] != w.method {
+				t.Errorf("message %d = %v, want notification %s", i, m, w.method)
+			}
+			continue
+		}
+		if m["id"] != w.id {
+			t.Errorf("message %d id = %v, want %v", i, m["id"], w.id)
+		}
+		errObj, _ := m["error"].(map[string]any)
+		switch {
+		case w.code == 0 && errObj != nil:
+			t.Errorf("message %d error = %v, want a result", i, errObj)
+		case w.code != 0 && (errObj == nil || errObj["code"] != w.code):
+			t.Errorf("message %d = %v, want error code %v", i, m, w.code)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+// Package ledger records every generation in a local JSONL file, so muse
+// stats can report tokens, estimated costs and latency over time.
+package ledger
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/klauern/muse/config"
+	"github.com/klauern/muse/internal/fileops"
+)
+
+// Outcomes of a generated message, as far as muse can tell
+const (
+	OutcomeAccepted = "accepted"
+	OutcomeEdited   = "edited"
+	OutcomeRejected = "rejected"
+)
+
+// Entry is one line of the ledger
+type Entry struct {
+	// ID identifies the entry, so that its outcome can be recorded later
+	ID   string    `json:"id,omitempty"`
+	Time time.Time `json:"timestamp"`
+	// Command is the muse command that generated, such as
+	// prepare-commit-msg or review
+	Command          string `json:"command"`
+	Repo             string `json:"repo,omitempty"`
+	Provider         string `json:"provider"`
+	Model            string `json:"model,omitempty"`
+	Requests         int    `json:"requests"`
+	PromptTokens     int64  `json:"prompt_tokens"`
+	CompletionTokens int64  `json:"completion_tokens"`
+	// Cost is the estimated cost in USD, or nil when the model has no price
+	Cost      *float64 `json:"cost_usd,omitempty"`
+	LatencyMS int64    `json:"latency_ms"`
+	Retries   int      `json:"retries,omitempty"`
+	// Path is how the message was produced, such as cache or
+	// structured>completion
+	Path string `json:"path,omitempty"`
+	// Outcome is accepted, edited or rejected, or empty when unknown
+	Outcome string `json:"outcome,omitempty"`
+	// OutcomeOf marks a line that only sets the outcome of the entry with
+	// this ID, such as one the post-commit hook learned after the commit
+	OutcomeOf string `json:"outcome_of,omitempty"`
+}
+
+// Ledger is an append-only JSONL file of entries
+type Ledger struct {
+	Path   string
+	Prices []config.ModelPrice
+}
+
+// DefaultPath returns usage.jsonl in the muse directory under the user's
+// XDG state home
+func DefaultPath() (string, error) {
+	stateDir, err := config.StateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(stateDir, "usage.jsonl"), nil
+}
+
+// FromConfig returns the ledger at DefaultPath with the prices from cfg
+// ahead of the built-in ones
+func FromConfig(cfg config.UsageConfig) (*Ledger, error) {
+	path, err := DefaultPath()
+	if err != nil {
+		return nil, err
+	}
+	return &Ledger{Path: path, Prices: append(append([]config.ModelPrice{}, cfg.Prices...), DefaultPrices...)}, nil
+}
+
+// Append adds e to the ledger, estimating its cost when it has none
+func (l *Ledger) Append(e Entry) error {
+	if e.Cost == nil {
+		if cost, ok := Cost(l.Prices, e.Model, e.PromptTokens, e.CompletionTokens); ok {
+			e.Cost = &cost
+		}
+	}
+	return l.appendLine(e)
+}
+
+// SetOutcome records the outcome of the entry with id. The ledger stays
+// append-only; Entries applies the outcome to the entry.
+func (l *Ledger) SetOutcome(id, outcome string) error {
+	return l.appendLine(struct {
+		Time      time.Time `json:"timestamp"`
+		OutcomeOf string    `json:"outcome_of"`
+		Outcome   string    `json:"outcome"`
+	}{time.Now().UTC(), id, outcome})
+}
+
+func (l *Ledger) appendLine(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode ledger entry: %w", err)
+	}
+	if err := fileops.AppendLine(l.Path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write ledger: %w", err)
+	}
+	return nil
+}
+
+// Entries returns the entries recorded at or after since, oldest first, with
+// outcomes set later applied. Lines that cannot be decoded are skipped.
+func (l *Ledger) Entries(since time.Time) ([]Entry, error) {
+	var entries []Entry
+	byID := map[string]int{}
+	err := fileops.ReadJSONLines(l.Path, func(e Entry) {
+		if e.OutcomeOf != "" {
+			if i, ok := byID[e.OutcomeOf]; ok {
+				entries[i].Outcome = e.Outcome
+			}
+			return
+		}
+		if !e.Time.Before(since) {
+			if e.ID != "" {
+				byID[e.ID] = len(entries)
+			}
+			entries = append(entries, e)
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ledger: %w", err)
+	}
+	return entries, nil
+}
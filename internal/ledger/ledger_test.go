@@ -0,0 +1,196 @@
+package ledger
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/klauern/muse/config"
+	"github.com/klauern/muse/llm"
+)
+
+func TestAppendEntries(t *testing.T) {
+	l := &Ledger{
+		Path:   filepath.Join(t.TempDir(), "muse", "usage.jsonl"),
+		Prices: []config.ModelPrice{{Model: "gpt-4o", Input: 2.5, Output: 10}},
+	}
+
+	entries, err := l.Entries(time.Time{})
+	if err != nil || entries != nil {
+		t.Fatalf("Entries() on missing ledger = %v, %v; want nil, nil", entries, err)
+	}
+
+	old := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	recent := time.Date(2025, 2, 1, 12, 0, 0, 0, time.UTC)
+	if err := l.Append(Entry{Time: old, Command: "generate", Model: "gpt-4o", Requests: 1, PromptTokens: 1000, CompletionTokens: 100}); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+	if err := l.Append(Entry{Time: recent, Command: "review", Model: "llama3", Requests: 1, PromptTokens: 10}); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+
+	// A truncated line is skipped rather than failing the whole ledger
+	f, err := os.OpenFile(l.Path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"timestamp":` + "\n")
+	f.Close()
+
+	entries, err = l.Entries(time.Time{})
+	if err != nil {
+		t.Fatalf("Entries() error = %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Entries() returned %d entries, want 2", len(entries))
+	}
+	if entries[0].Cost == nil || *entries[0].Cost != 0.0035 {
+		t.Errorf("priced entry cost = %v, want 0.0035", entries[0].Cost)
+	}
+	if entries[1].Cost != nil {
+		t.Errorf("unpriced entry cost = %v, want nil", *entries[1].Cost)
+	}
+
+	entries, err = l.Entries(recent)
+	if err != nil || len(entries) != 1 || entries[0].Command != "review" {
+		t.Errorf("Entries(since) = %+v, %v; want only the review entry", entries, err)
+	}
+
+	info, err := os.Stat(l.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("ledger permissions = %o, want 600", perm)
+	}
+}
+
+func TestDefaultPath(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", "/tmp/state")
+	path, err := DefaultPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join("/tmp/state", "muse", "usage.jsonl"); path != want {
+		t.Errorf("DefaultPath() = %q, want %q", path, want)
+	}
+}
+
+func TestCost(t *testing.T) {
+	prices := []config.ModelPrice{
+		{Model: "gpt-4o", Input: 1, Output: 1},
+		{Model: "gpt-4o-mini", Input: 0.5, Output: 0.5},
+		{Model: "gpt-4o", Input: 9, Output: 9},
+	}
+	tests := []struct {
+		model  string
+		want   float64
+		wantOK bool
+	}{
+		{"gpt-4o", 2, true},
+		{"gpt-4o-2024-08-06", 2, true},
+		{"gpt-4o-mini-2024-07-18", 1, true},
+		{"gpt-3.5-turbo", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			got, ok := Cost(prices, tt.model, 1e6, 1e6)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("Cost() = %v, %v; want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	cost := func(v float64) *float64 { return &v }
+	day1 := time.Date(2025, 3, 1, 12, 0, 0, 0, time.Local)
+	day2 := day1.AddDate(0, 0, 1)
+	entries := []Entry{
+		{Time: day1, Repo: "/a", Provider: "openai", Model: "gpt-4o", Requests: 1, PromptTokens: 100, Cost: cost(0.5), LatencyMS: 100, Outcome: OutcomeAccepted},
+		{Time: day2, Repo: "/a", Provider: "openai", Model: "gpt-4o", Requests: 2, PromptTokens: 200, Cost: cost(1), LatencyMS: 300, Retries: 1, Outcome: OutcomeEdited},
+		{Time: day2, Repo: "/b", Provider: "ollama", Model: "llama3", Requests: 1, PromptTokens: 50, LatencyMS: 200, Outcome: OutcomeRejected},
+		{Time: day2, Repo: "/b", Provider: "ollama", Model: "llama3", Path: "cache"},
+	}
+
+	byDay, err := Summarize(entries, "day")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(byDay) != 2 || byDay[0].Key != day2.Format("2006-01-02") || byDay[0].Generations != 3 {
+		t.Errorf("Summarize(day) = %+v, want the newest day first with 3 generations", byDay)
+	}
+
+	byModel, err := Summarize(entries, "model")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(byModel) != 2 || byModel[0].Key != "openai/gpt-4o" {
+		t.Fatalf("Summarize(model) = %+v, want the costliest model first", byModel)
+	}
+	gpt := byModel[0]
+	if gpt.Cost != 1.5 || gpt.Requests != 3 || gpt.PromptTokens != 300 || gpt.AvgLatencyMS != 200 || gpt.Retries != 1 || gpt.Accepted != 1 || gpt.Edited != 1 {
+		t.Errorf("openai/gpt-4o row = %+v", gpt)
+	}
+	llama := byModel[1]
+	if llama.Unpriced != 1 || llama.Cached != 1 || llama.Rejected != 1 {
+		t.Errorf("ollama/llama3 row = %+v, want 1 unpriced, 1 cached and 1 rejected", llama)
+	}
+
+	if total := Total(entries); total.Generations != 4 || total.Cost != 1.5 || total.Unpriced != 1 {
+		t.Errorf("Total() = %+v", total)
+	}
+
+	if _, err := Summarize(entries, "week"); err == nil {
+		t.Error("Summarize(week) succeeded, want an error")
+	}
+}
+
+func TestRecord(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	cfg := &config.Config{
+		LLM:   config.LLMConfig{Provider: "openai", Config: map[string]any{"model": "gpt-4o"}},
+		Usage: config.UsageConfig{Enabled: true},
+	}
+
+	// Nothing is recorded without provider requests
+	_, gen := Start(context.Background(), cfg, "generate")
+	gen.Record(OutcomeAccepted)
+
+	ctx, gen := Start(context.Background(), cfg, "generate")
+	gen.Repo = "/repo"
+	llm.RecordUsage(ctx, llm.Usage{Requests: 1, PromptTokens: 10, CompletionTokens: 5, Path: "structured"})
+	gen.Stop()
+	gen.Record("")
+	if gen.ID == "" {
+		t.Fatal("Record() did not set the entry ID")
+	}
+	// The post-commit hook learns the outcome later
+	RecordOutcome(cfg, gen.ID, OutcomeEdited)
+	RecordOutcome(cfg, "unknown", OutcomeRejected)
+
+	disabled := *cfg
+	disabled.Usage.Enabled = false
+	ctx, gen = Start(context.Background(), &disabled, "generate")
+	llm.RecordUsage(ctx, llm.Usage{Requests: 1})
+	gen.Record("")
+
+	l, err := FromConfig(cfg.Usage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := l.Entries(time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("ledger has %d entries, want 1", len(entries))
+	}
+	e := entries[0]
+	if e.Repo != "/repo" || e.Provider != "openai" || e.Model != "gpt-4o" || e.PromptTokens != 10 || e.Path != "structured" || e.Outcome != OutcomeEdited || e.Cost == nil {
+		t.Errorf("recorded entry = %+v", e)
+	}
+}
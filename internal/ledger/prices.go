@@ -0,0 +1,41 @@
+package ledger
+
+import (
+	"strings"
+
+	"github.com/klauern/muse/config"
+)
+
+// DefaultPrices are list prices in USD per million tokens. They go stale,
+// so usage.prices takes precedence over them.
+var DefaultPrices = []config.ModelPrice{
+	{Model: "gpt-4o", Input: 2.50, Output: 10.00},
+	{Model: "gpt-4o-mini", Input: 0.15, Output: 0.60},
+	{Model: "gpt-4.1", Input: 2.00, Output: 8.00},
+	{Model: "gpt-4.1-mini", Input: 0.40, Output: 1.60},
+	{Model: "gpt-4.1-nano", Input: 0.10, Output: 0.40},
+	{Model: "o3-mini", Input: 1.10, Output: 4.40},
+	{Model: "o4-mini", Input: 1.10, Output: 4.40},
+	{Model: "claude-3-5-haiku", Input: 0.80, Output: 4.00},
+	{Model: "claude-3-5-sonnet", Input: 3.00, Output: 15.00},
+	{Model: "claude-3-7-sonnet", Input: 3.00, Output: 15.00},
+	{Model: "claude-sonnet-4", Input: 3.00, Output: 15.00},
+	{Model: "claude-opus-4", Input: 15.00, Output: 75.00},
+}
+
+// Cost estimates the cost in USD of a request to model. The price of the
+// longest model name that model starts with is used, and the earlier price
+// wins a tie. It reports false when no price matches.
+func Cost(prices []config.ModelPrice, model string, promptTokens, completionTokens int64) (float64, bool) {
+	best := -1
+	for i, price := range prices {
+		if strings.HasPrefix(model, price.Model) && (best < 0 || len(price.Model) > len(prices[best].Model)) {
+			best = i
+		}
+	}
+	if best < 0 || model == "" {
+		return 0, false
+	}
+	price := prices[best]
+	return (float64(promptTokens)*price.Input + float64(completionTokens)*price.Output) / 1e6, true
+}
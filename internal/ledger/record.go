@@ -0,0 +1,118 @@
+package ledger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"time"
+
+	"github.com/klauern/muse/config"
+	"github.com/klauern/muse/llm"
+)
+
+// Generation times a command's provider requests and collects their usage
+// for the ledger
+type Generation struct {
+	// Repo is the repository root; when empty, that of the working
+	// directory is recorded
+	Repo string
+	// ID is the ledger entry's ID once Record has written it
+	ID string
+
+	cfg     *config.Config
+	command string
+	start   time.Time
+	end     time.Time
+	usage   func() llm.Usage
+}
+
+// Start begins a generation by command. Provider requests made with the
+// returned context count towards it.
+func Start(ctx context.Context, cfg *config.Config, command string) (context.Context, *Generation) {
+	ctx, usage := llm.TrackUsage(ctx)
+	return ctx, &Generation{cfg: cfg, command: command, start: time.Now(), usage: usage}
+}
+
+// Usage returns the usage recorded so far
+func (g *Generation) Usage() llm.Usage {
+	return g.usage()
+}
+
+// Stop ends the generation's latency, so that time spent waiting for the
+// user to accept the message is not counted
+func (g *Generation) Stop() {
+	if g.end.IsZero() {
+		g.end = time.Now()
+	}
+}
+
+// Elapsed returns the latency of the generation so far
+func (g *Generation) Elapsed() time.Duration {
+	if !g.end.IsZero() {
+		return g.end.Sub(g.start)
+	}
+	return time.Since(g.start)
+}
+
+// Record appends the generation to the ledger with outcome, which may be
+// empty when it is not known. Generations without provider requests or
+// cache hits are skipped. Failures are logged rather than returned, since
+// the ledger must never break a commit.
+func (g *Generation) Record(outcome string) {
+	usage := g.Usage()
+	if g.cfg == nil || !g.cfg.Usage.Enabled || (usage.Requests == 0 && usage.Path == "") {
+		return
+	}
+
+	entry := Entry{
+		ID:               newID(),
+		Time:             g.start.UTC(),
+		Command:          g.command,
+		Repo:             g.Repo,
+		Provider:         g.cfg.LLM.Provider,
+		Requests:         usage.Requests,
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		LatencyMS:        g.Elapsed().Milliseconds(),
+		Retries:          usage.Retries,
+		Path:             usage.Path,
+		Outcome:          outcome,
+	}
+	entry.Model, _ = g.cfg.LLM.Config["model"].(string)
+	if entry.Repo == "" {
+		entry.Repo = config.RepoRoot()
+	}
+
+	l, err := FromConfig(g.cfg.Usage)
+	if err == nil {
+		err = l.Append(entry)
+	}
+	if err != nil {
+		slog.Warn("Failed to record usage", "error", err)
+		return
+	}
+	g.ID = entry.ID
+}
+
+// RecordOutcome sets the outcome of the entry with id, once it is known.
+// Like Record, it only logs failures.
+func RecordOutcome(cfg *config.Config, id, outcome string) {
+	if cfg == nil || !cfg.Usage.Enabled || id == "" {
+		return
+	}
+	l, err := FromConfig(cfg.Usage)
+	if err == nil {
+		err = l.SetOutcome(id, outcome)
+	}
+	if err != nil {
+		slog.Warn("Failed to record outcome", "error", err)
+	}
+}
+
+// newID returns a random ID for an entry
+func newID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,147 @@
+package ledger
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+)
+
+// Groupings accepted by Summarize
+var Groupings = []string{"day", "repo", "model"}
+
+// Row aggregates the entries that share a key
+type Row struct {
+	Key              string  `json:"key"`
+	Generations      int     `json:"generations"`
+	Requests         int     `json:"requests"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	Cost             float64 `json:"cost_usd"`
+	// Unpriced counts generations whose model had no price, so Cost
+	// understates the total
+	Unpriced     int   `json:"unpriced,omitempty"`
+	AvgLatencyMS int64 `json:"avg_latency_ms"`
+	Retries      int   `json:"retries"`
+	Cached       int   `json:"cached"`
+	Accepted     int   `json:"accepted"`
+	Edited       int   `json:"edited"`
+	Rejected     int   `json:"rejected"`
+
+	totalLatencyMS int64
+}
+
+func (r *Row) add(e Entry) {
+	r.Generations++
+	r.Requests += e.Requests
+	r.PromptTokens += e.PromptTokens
+	r.CompletionTokens += e.CompletionTokens
+	if e.Cost != nil {
+		r.Cost += *e.Cost
+	} else if e.Requests > 0 {
+		r.Unpriced++
+	}
+	r.totalLatencyMS += e.LatencyMS
+	r.AvgLatencyMS = r.totalLatencyMS / int64(r.Generations)
+	r.Retries += e.Retries
+	if e.Path == "cache" {
+		r.Cached++
+	}
+	switch e.Outcome {
+	case OutcomeAccepted:
+		r.Accepted++
+	case OutcomeEdited:
+		r.Edited++
+	case OutcomeRejected:
+		r.Rejected++
+	}
+}
+
+// Summarize aggregates entries by day, repo or model. Days are sorted
+// newest first and other keys by cost.
+func Summarize(entries []Entry, by string) ([]Row, error) {
+	var key func(Entry) string
+	switch by {
+	case "day":
+		key = func(e Entry) string { return e.Time.Local().Format("2006-01-02") }
+	case "repo":
+		key = func(e Entry) string { return e.Repo }
+	case "model":
+		key = func(e Entry) string { return strings.TrimPrefix(e.Provider+"/"+e.Model, "/") }
+	default:
+		return nil, fmt.Errorf("unknown grouping %q; use %s", by, strings.Join(Groupings, ", "))
+	}
+
+	rows := map[string]*Row{}
+	for _, e := range entries {
+		k := key(e)
+		if k == "" {
+			k = "(none)"
+		}
+		if rows[k] == nil {
+			rows[k] = &Row{Key: k}
+		}
+		rows[k].add(e)
+	}
+
+	result := make([]Row, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, *row)
+	}
+	slices.SortFunc(result, func(a, b Row) int {
+		if by == "day" {
+			return strings.Compare(b.Key, a.Key)
+		}
+		if a.Cost != b.Cost {
+			if a.Cost > b.Cost {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a.Key, b.Key)
+	})
+	return result, nil
+}
+
+// Total aggregates all entries into one row
+func Total(entries []Entry) Row {
+	total := Row{Key: "total"}
+	for _, e := range entries {
+		total.add(e)
+	}
+	return total
+}
+
+// WriteTable writes rows as an aligned table headed by title, followed by
+// total
+func WriteTable(w io.Writer, title string, rows []Row, total Row) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "%s\tRUNS\tTOKENS IN\tTOKENS OUT\tCOST\tAVG LATENCY\tRETRIES\tCACHED\tACCEPTED\tEDITED\tREJECTED\t\n", strings.ToUpper(title))
+	for _, row := range append(rows, total) {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%s\t%s\t%d\t%d\t%d\t%d\t%d\t\n",
+			row.Key, row.Generations, row.PromptTokens, row.CompletionTokens, formatCost(row),
+			formatLatency(row.AvgLatencyMS), row.Retries, row.Cached, row.Accepted, row.Edited, row.Rejected)
+	}
+	return tw.Flush()
+}
+
+// formatCost shows a cost, marking it as a lower bound when some models
+// had no price
+func formatCost(row Row) string {
+	cost := fmt.Sprintf("$%.4f", row.Cost)
+	if row.Cost > 0 && row.Cost < 0.0001 {
+		cost = "<$0.0001"
+	}
+	if row.Unpriced > 0 {
+		cost = ">=" + cost
+	}
+	return cost
+}
+
+func formatLatency(ms int64) string {
+	if ms < 1000 {
+		return fmt.Sprintf("%dms", ms)
+	}
+	return fmt.Sprintf("%.1fs", float64(ms)/1000)
+}
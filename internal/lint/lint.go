@@ -0,0 +1,114 @@
+package lint
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/klauern/muse/templates"
+)
+
+// Severities of an Issue
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// MaxSubjectLength is the longest header that does not trigger a warning
+const MaxSubjectLength = 72
+
+// ConventionalTypes are the commit types the style templates ask for
+var ConventionalTypes = []string{"feat", "fix", "docs", "style", "refactor", "perf", "test", "build", "ci", "chore", "revert"}
+
+// Issue is a single problem found in a commit message
+type Issue struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	// Line is 1-based; 0 refers to the message as a whole
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+func (i Issue) String() string {
+	if i.Line > 0 {
+		return fmt.Sprintf("%d: %s: %s (%s)", i.Line, i.Severity, i.Message, i.Rule)
+	}
+	return fmt.Sprintf("%s: %s (%s)", i.Severity, i.Message, i.Rule)
+}
+
+// HasErrors reports whether any issue is an error
+func HasErrors(issues []Issue) bool {
+	for _, issue := range issues {
+		if issue.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Lint checks message against the rules of style. Comment lines are
+// ignored, as git strips them.
+func Lint(message string, style templates.CommitStyle) []Issue {
+	message = templates.StripComments(message)
+	if message == "" {
+		return []Issue{{Rule: "empty", Severity: SeverityError, Message: "commit message is empty"}}
+	}
+
+	var issues []Issue
+	lines := strings.Split(message, "\n")
+	header := lines[0]
+
+	if n := utf8.RuneCountInString(header); n > MaxSubjectLength {
+		issues = append(issues, Issue{
+			Rule:     "header-max-length",
+			Severity: SeverityWarning,
+			Line:     1,
+			Message:  fmt.Sprintf("header is %d characters, keep it within %d", n, MaxSubjectLength),
+		})
+	}
+	if strings.HasSuffix(strings.TrimSpace(header), ".") {
+		issues = append(issues, Issue{Rule: "subject-full-stop", Severity: SeverityWarning, Line: 1, Message: "subject ends with a period"})
+	}
+	if len(lines) > 1 && strings.TrimSpace(lines[1]) != "" {
+		issues = append(issues, Issue{Rule: "body-leading-blank", Severity: SeverityError, Line: 2, Message: "separate the header from the body with a blank line"})
+	}
+
+	return append(issues, lintHeader(message, style)...)
+}
+
+func lintHeader(message string, style templates.CommitStyle) []Issue {
+	parsed, err := templates.ParseConventionalCommit(message)
+	if err != nil {
+		return []Issue{{Rule: "header-format", Severity: SeverityError, Line: 1, Message: err.Error()}}
+	}
+
+	var issues []Issue
+	if !contains(ConventionalTypes, parsed.Type) {
+		issues = append(issues, Issue{
+			Rule:     "type-enum",
+			Severity: SeverityWarning,
+			Line:     1,
+			Message:  fmt.Sprintf("type %q is not one of %s", parsed.Type, strings.Join(ConventionalTypes, ", ")),
+		})
+	}
+	if parsed.Subject == "" {
+		issues = append(issues, Issue{Rule: "subject-empty", Severity: SeverityError, Line: 1, Message: "subject is empty"})
+	}
+
+	switch {
+	case style == templates.GitmojiCommitStyle && parsed.Gitmoji == "":
+		issues = append(issues, Issue{Rule: "gitmoji", Severity: SeverityError, Line: 1, Message: "header must start with a gitmoji"})
+	case style != templates.GitmojiCommitStyle && parsed.Gitmoji != "":
+		issues = append(issues, Issue{Rule: "gitmoji", Severity: SeverityWarning, Line: 1, Message: fmt.Sprintf("the %s style does not use gitmoji", style)})
+	}
+	return issues
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package lint
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/klauern/muse/templates"
+)
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name      string
+		message   string
+		style     templates.CommitStyle
+		wantRules []string
+		wantError bool
+	}{
+		{name: "valid", message: "feat(cli): add watch command\n\nBody text.", style: "conventional"},
+		{name: "comments ignored", message: "fix: handle empty diff\n# Please enter the commit message", style: "conventional"},
+		{name: "empty", message: "# only a comment\n", style: "conventional", wantRules: []string{"empty"}, wantError: true},
+		{name: "free-form header", message: "Update README", style: "default", wantRules: []string{"header-format"}, wantError: true},
+		{name: "unknown type", message: "feature: add x", style: "conventional", wantRules: []string{"type-enum"}},
+		{name: "full stop", message: "fix: handle empty diff.", style: "conventional", wantRules: []string{"subject-full-stop"}},
+		{
+			name:      "long header and missing blank line",
+			message:   "fix: " + strings.Repeat("x", 80) + "\nbody",
+			style:     "conventional",
+			wantRules: []string{"header-max-length", "body-leading-blank"},
+			wantError: true,
+		},
+		{name: "gitmoji", message: "✨ feat: add spinner", style: "gitmoji"},
+		{name: "gitmoji missing", message: "feat: add spinner", style: "gitmoji", wantRules: []string{"gitmoji"}, wantError: true},
+		{name: "unexpected gitmoji", message: "✨ feat: add spinner", style: "conventional", wantRules: []string{"gitmoji"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := Lint(tt.message, tt.style)
+
+			var rules []string
+			for _, issue := range issues {
+				rules = append(rules, issue.Rule)
+			}
+			if !reflect.DeepEqual(rules, tt.wantRules) {
+				t.Errorf("Lint() rules = %v, want %v", rules, tt.wantRules)
+			}
+			if HasErrors(issues) != tt.wantError {
+				t.Errorf("HasErrors() = %v, want %v: %v", HasErrors(issues), tt.wantError, issues)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/invopop/jsonschema"
+	"github.com/klauern/muse/config"
+	"github.com/klauern/muse/internal/git"
+	"github.com/klauern/muse/internal/jsonrpc"
+	"github.com/klauern/muse/llm"
+)
+
+// ProtocolVersion is the newest MCP revision this server implements
+const ProtocolVersion = "2025-06-18"
+
+// supportedVersions are the revisions accepted during initialization
+var supportedVersions = map[string]bool{
+	"2025-06-18": true,
+	"2025-03-26": true,
+	"2024-11-05": true,
+}
+
+// Server exposes muse as Model Context Protocol tools
+type Server struct {
+	Config  *config.Config
+	Version string
+	// Git runs repository commands; when nil the working directory is used
+	Git *git.GitOperations
+
+	generator *llm.CommitMessageGenerator
+	tools     []tool
+}
+
+// NewServer returns a server that generates with cfg
+func NewServer(cfg *config.Config, version string) *Server {
+	s := &Server{Config: cfg, Version: version}
+	s.tools = s.registerTools()
+	return s
+}
+
+// Serve speaks MCP over newline-delimited JSON-RPC on r and w until r is
+// closed
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	rpc := jsonrpc.NewServer()
+	rpc.Handle("initialize", s.initialize)
+	rpc.Handle("notifications/initialized", func(context.Context, json.RawMessage) (any, error) { return nil, nil })
+	rpc.Handle("ping", func(context.Context, json.RawMessage) (any, error) { return struct{}{}, nil })
+	rpc.Handle("tools/list", s.listTools)
+	rpc.Handle("tools/call", s.callTool)
+	return rpc.Serve(ctx, r, w)
+}
+
+type initializeParams struct {
+	ProtocolVersion string `json:"protocolVersion"`
+}
+
+type initializeResult struct {
+	ProtocolVersion string         `json:"protocolVersion"`
+	Capabilities    map[string]any `json:"capabilities"`
+	ServerInfo      serverInfo     `json:"serverInfo"`
+	Instructions    string         `json:"instructions,omitempty"`
+}
+
+type serverInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+func (s *Server) initialize(ctx context.Context, params json.RawMessage) (any, error) {
+	var p initializeParams
+	if err := jsonrpc.DecodeParams(params, &p); err != nil {
+		return nil, err
+	}
+
+	// Answer with the client's revision when supported, otherwise with ours
+	// and let the client decide whether to continue
+	version := ProtocolVersion
+	if supportedVersions[p.ProtocolVersion] {
+		version = p.ProtocolVersion
+	}
+	return initializeResult{
+		ProtocolVersion: version,
+		Capabilities:    map[string]any{"tools": map[string]any{}},
+		ServerInfo:      serverInfo{Name: "muse", Version: s.Version},
+		Instructions: "Use generate_commit_message to write commit messages in this repository's configured style, " +
+			"and lint_commit_message to check a message before committing.",
+	}, nil
+}
+
+type toolDescription struct {
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	InputSchema *jsonschema.Schema `json:"inputSchema"`
+}
+
+func (s *Server) listTools(ctx context.Context, params json.RawMessage) (any, error) {
+	descriptions := make([]toolDescription, 0, len(s.tools))
+	for _, t := range s.tools {
+		descriptions = append(descriptions, toolDescription{Name: t.name, Description: t.description, InputSchema: t.schema})
+	}
+	return map[string]any{"tools": descriptions}, nil
+}
+
+type callParams struct {
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+type content struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type callResult struct {
+	Content           []content `json:"content"`
+	StructuredContent any       `json:"structuredContent,omitempty"`
+	IsError           bool      `json:"isError,omitempty"`
+}
+
+func (s *Server) callTool(ctx context.Context, params json.RawMessage) (any, error) {
+	var p callParams
+	if err := jsonrpc.DecodeParams(params, &p); err != nil {
+		return nil, err
+	}
+
+	for _, t := range s.tools {
+		if t.name != p.Name {
+			continue
+		}
+		text, structured, err := t.call(ctx, p.Arguments)
+		if err != nil {
+			// Tool failures are results the model can read and act on,
+			// not protocol errors
+			return callResult{Content: []content{{Type: "text", Text: err.Error()}}, IsError: true}, nil
+		}
+		return callResult{Content: []content{{Type: "text", Text: text}}, StructuredContent: structured}, nil
+	}
+	return nil, jsonrpc.InvalidParams("unknown tool: %s", p.Name)
+}
+
+// gitOps returns the configured GitOperations or one for the working
+// directory
+func (s *Server) gitOps() (*git.GitOperations, error) {
+	if s.Git != nil {
+		return s.Git, nil
+	}
+	ops, err := git.NewGitOperations("")
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize git operations: %w", err)
+	}
+	s.Git = ops
+	return ops, nil
+}
+
+// commitGenerator returns the generator, creating it on first use so that
+// listing tools works without credentials
+func (s *Server) commitGenerator() (*llm.CommitMessageGenerator, error) {
+	if s.generator == nil {
+		generator, err := llm.NewCommitMessageGenerator(s.Config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create commit message generator: %w", err)
+		}
+		s.generator = generator
+	}
+	return s.generator, nil
+}
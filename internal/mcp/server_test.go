@@ -0,0 +1,161 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/klauern/muse/config"
+	"github.com/klauern/muse/internal/git"
+	"github.com/klauern/muse/internal/gittest"
+	"github.com/klauern/muse/llm"
+	"github.com/klauern/muse/templates"
+)
+
+const testProvider = "mcp-test"
+
+// fakeService echoes the diff back as a commit message and answers every
+// other prompt with a fixed summary
+type fakeService struct{}
+
+func (fakeService) GenerateCommitMessage(ctx context.Context, diff string, style templates.CommitStyle) (string, error) {
+	return "feat: " + strings.TrimSpace(diff), nil
+}
+
+func (fakeService) Complete(ctx context.Context, prompt string) (string, error) {
+	return "summary of the range", nil
+}
+
+type fakeProvider struct{}
+
+func (fakeProvider) NewService(map[string]any) (llm.LLMService, error) { return fakeService{}, nil }
+
+func init() {
+	llm.RegisterProvider(testProvider, fakeProvider{})
+}
+
+// newTestRepo returns GitOperations for a repository with two commits and a
+// staged file
+func newTestRepo(t *testing.T) *git.GitOperations {
+	t.Helper()
+	repo := gittest.New(t)
+	repo.Commit("chore: initial")
+	repo.Commit("feat: add watch")
+	repo.Write("file.txt", "hello\n")
+	repo.Git("add", "file.txt")
+
+	ops, err := git.NewGitOperations(repo.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ops
+}
+
+// call sends requests to a fresh server and returns the responses by id
+func call(t *testing.T, s *Server, requests ...string) map[float64]map[string]any {
+	t.Helper()
+	var out bytes.Buffer
+	if err := s.Serve(context.Background(), strings.NewReader(strings.Join(requests, "\n")), &out); err != nil {
+		t.Fatalf("Serve() error = %v", err)
+	}
+
+	responses := map[float64]map[string]any{}
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var m map[string]any
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatalf("invalid response %q: %v", line, err)
+		}
+		id, _ := m["id"].(float64)
+		responses[id] = m
+	}
+	return responses
+}
+
+func toolText(t *testing.T, response map[string]any) (string, bool) {
+	t.Helper()
+	result, ok := response["result"].(map[string]any)
+	if !ok {
+		t.Fatalf("response has no result: %v", response)
+	}
+	content := result["content"].([]any)[0].(map[string]any)
+	isError, _ := result["isError"].(bool)
+	return content["text"].(string), isError
+}
+
+func newTestServer(t *testing.T) *Server {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	cfg := &config.Config{
+		Hook: config.Hook{CommitStyle: "conventional"},
+		LLM:  config.LLMConfig{Provider: testProvider, Config: map[string]any{"model": "fake"}},
+	}
+	s := NewServer(cfg, "test")
+	s.Git = newTestRepo(t)
+	return s
+}
+
+func TestServer_InitializeAndListTools(t *testing.T) {
+	s := newTestServer(t)
+	responses := call(t, s,
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+	)
+
+	init := responses[1]["result"].(map[string]any)
+	if init["protocolVersion"] != "2025-03-26" {
+		t.Errorf("protocolVersion = %v, want the client's supported revision", init["protocolVersion"])
+	}
+
+	var names []string
+	for _, raw := range responses[2]["result"].(map[string]any)["tools"].([]any) {
+		tool := raw.(map[string]any)
+		names = append(names, tool["name"].(string))
+		if schema, _ := tool["inputSchema"].(map[string]any); schema["type"] != "object" {
+			t.Errorf("tool %s inputSchema = %v, want an object schema", tool["name"], schema)
+		}
+	}
+	want := "generate_commit_message lint_commit_message list_styles summarize_range"
+	if strings.Join(names, " ") != want {
+		t.Errorf("tools = %v, want %s", names, want)
+	}
+}
+
+func TestServer_Tools(t *testing.T) {
+	s := newTestServer(t)
+	responses := call(t, s,
+		`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"generate_commit_message","arguments":{}}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"generate_commit_message","arguments":{"diff":"+given"}}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"lint_commit_message","arguments":{"message":"Update README"}}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"list_styles","arguments":{}}}`,
+		`{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"summarize_range","arguments":{"range":"HEAD~1..HEAD"}}}`,
+		`{"jsonrpc":"2.0","id":6,"method":"tools/call","params":{"name":"generate_commit_message","arguments":{"diff":"+x","style":"nope"}}}`,
+		`{"jsonrpc":"2.0","id":7,"method":"tools/call","params":{"name":"no_such_tool"}}`,
+	)
+
+	if text, isError := toolText(t, responses[1]); isError || !strings.Contains(text, "+hello") {
+		t.Errorf("generate from staged diff = %q (error %v), want the staged change", text, isError)
+	}
+	if text, _ := toolText(t, responses[2]); text != "feat: +given" {
+		t.Errorf("generate from given diff = %q", text)
+	}
+	if text, _ := toolText(t, responses[3]); !strings.Contains(text, "header-format") {
+		t.Errorf("lint = %q, want a header-format issue", text)
+	}
+	if structured := responses[3]["result"].(map[string]any)["structuredContent"].(map[string]any); structured["valid"] != false {
+		t.Errorf("lint structuredContent = %v, want valid=false", structured)
+	}
+	if text, _ := toolText(t, responses[4]); !strings.Contains(text, "conventional (configured)") {
+		t.Errorf("list_styles = %q", text)
+	}
+	if text, _ := toolText(t, responses[5]); text != "summary of the range" {
+		t.Errorf("summarize_range = %q", text)
+	}
+	if text, isError := toolText(t, responses[6]); !isError || !strings.Contains(text, "unknown style") {
+		t.Errorf("generate with unknown style = %q (error %v), want a tool error", text, isError)
+	}
+	if _, ok := responses[7]["error"]; !ok {
+		t.Errorf("unknown tool = %v, want a protocol error", responses[7])
+	}
+}
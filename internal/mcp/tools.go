@@ -0,0 +1,220 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/invopop/jsonschema"
+	"github.com/klauern/muse/config"
+	"github.com/klauern/muse/internal/feedback"
+	"github.com/klauern/muse/internal/git"
+	"github.com/klauern/muse/internal/jsonrpc"
+	"github.com/klauern/muse/internal/ledger"
+	"github.com/klauern/muse/internal/lint"
+	"github.com/klauern/muse/llm"
+	"github.com/klauern/muse/templates"
+)
+
+// tool is an MCP tool. call returns the text shown to the model and an
+// optional structured form of the same result.
+type tool struct {
+	name        string
+	description string
+	schema      *jsonschema.Schema
+	call        func(ctx context.Context, args json.RawMessage) (string, any, error)
+}
+
+type generateArgs struct {
+	Diff  string `json:"diff,omitempty" jsonschema_description:"Unified diff to describe; the staged changes are used when omitted"`
+	Style string `json:"style,omitempty" jsonschema_description:"Commit style; defaults to the configured hook.commit_style"`
+}
+
+type lintArgs struct {
+	Message string `json:"message" jsonschema:"required" jsonschema_description:"Commit message to check"`
+	Style   string `json:"style,omitempty" jsonschema_description:"Commit style; defaults to the configured hook.commit_style"`
+}
+
+type listStylesArgs struct{}
+
+type summarizeArgs struct {
+	Range string `json:"range" jsonschema:"required" jsonschema_description:"Revision range such as v1.2.0..HEAD or main..feature"`
+}
+
+type styleInfo struct {
+	Name    string `json:"name"`
+	Default bool   `json:"default"`
+}
+
+func (s *Server) registerTools() []tool {
+	return []tool{
+		{
+			name:        "generate_commit_message",
+			description: "Generate a commit message in the repository's configured style for the staged changes or a given diff",
+			schema:      llm.ReflectSchema(&generateArgs{}),
+			call:        s.generateCommitMessage,
+		},
+		{
+			name:        "lint_commit_message",
+			description: "Check a commit message against the repository's commit style",
+			schema:      llm.ReflectSchema(&lintArgs{}),
+			call:        s.lintCommitMessage,
+		},
+		{
+			name:        "list_styles",
+			description: "List the available commit styles and which one is configured",
+			schema:      llm.ReflectSchema(&listStylesArgs{}),
+			call:        s.listStyles,
+		},
+		{
+			name:        "summarize_range",
+			description: "Summarize the commits in a revision range",
+			schema:      llm.ReflectSchema(&summarizeArgs{}),
+			call:        s.summarizeRange,
+		},
+	}
+}
+
+func (s *Server) generateCommitMessage(ctx context.Context, raw json.RawMessage) (string, any, error) {
+	var args generateArgs
+	if err := jsonrpc.DecodeParams(raw, &args); err != nil {
+		return "", nil, err
+	}
+	style, err := s.style(args.Style)
+	if err != nil {
+		return "", nil, err
+	}
+
+	diff := args.Diff
+	if diff == "" {
+		ops, err := s.gitOps()
+		if err != nil {
+			return "", nil, err
+		}
+		if diff, err = ops.GetStagedDiff(); err != nil {
+			return "", nil, err
+		}
+		if strings.TrimSpace(diff) == "" {
+			return "", nil, fmt.Errorf("no staged changes; stage files with git add or pass a diff")
+		}
+	}
+
+	generator, err := s.commitGenerator()
+	if err != nil {
+		return "", nil, err
+	}
+	ctx, gen := ledger.Start(feedback.WithExamples(ctx, s.Config, "", style, diff), s.Config, "mcp")
+	message, err := generator.Generate(ctx, diff, style)
+	if err != nil {
+		return "", nil, err
+	}
+	gen.Record("")
+	return message, map[string]any{"message": message, "style": style}, nil
+}
+
+func (s *Server) lintCommitMessage(ctx context.Context, raw json.RawMessage) (string, any, error) {
+	var args lintArgs
+	if err := jsonrpc.DecodeParams(raw, &args); err != nil {
+		return "", nil, err
+	}
+	style, err := s.style(args.Style)
+	if err != nil {
+		return "", nil, err
+	}
+
+	issues := lint.Lint(args.Message, style)
+	if issues == nil {
+		issues = []lint.Issue{}
+	}
+	valid := !lint.HasErrors(issues)
+
+	var text strings.Builder
+	if len(issues) == 0 {
+		text.WriteString("The message follows the " + string(style) + " style.")
+	}
+	for _, issue := range issues {
+		text.WriteString(issue.String() + "\n")
+	}
+	return strings.TrimSpace(text.String()), map[string]any{"valid": valid, "issues": issues}, nil
+}
+
+func (s *Server) listStyles(ctx context.Context, raw json.RawMessage) (string, any, error) {
+	names, err := config.AvailableStyleNames()
+	if err != nil {
+		return "", nil, err
+	}
+
+	styles := make([]styleInfo, 0, len(names))
+	lines := make([]string, 0, len(names))
+	for _, name := range names {
+		isDefault := name == string(s.Config.Hook.CommitStyle)
+		styles = append(styles, styleInfo{Name: name, Default: isDefault})
+		if isDefault {
+			name += " (configured)"
+		}
+		lines = append(lines, name)
+	}
+	return strings.Join(lines, "\n"), map[string]any{"styles": styles}, nil
+}
+
+func (s *Server) summarizeRange(ctx context.Context, raw json.RawMessage) (string, any, error) {
+	var args summarizeArgs
+	if err := jsonrpc.DecodeParams(raw, &args); err != nil {
+		return "", nil, err
+	}
+	ops, err := s.gitOps()
+	if err != nil {
+		return "", nil, err
+	}
+	commits, err := ops.GetLog(args.Range, git.PromptLogLimit)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(commits) == 0 {
+		return "", nil, fmt.Errorf("no commits in %s", args.Range)
+	}
+
+	generator, err := s.commitGenerator()
+	if err != nil {
+		return "", nil, err
+	}
+	service, err := generator.Service()
+	if err != nil {
+		return "", nil, err
+	}
+	ctx, gen := ledger.Start(ctx, s.Config, "mcp")
+	summary, err := llm.Summarize(ctx, service, formatCommits(commits))
+	if err != nil {
+		return "", nil, err
+	}
+	gen.Record("")
+	return summary, map[string]any{"summary": summary, "commits": len(commits)}, nil
+}
+
+// style returns the requested style, or the configured one when empty
+func (s *Server) style(name string) (templates.CommitStyle, error) {
+	if name == "" {
+		return s.Config.Hook.CommitStyle, nil
+	}
+	names, err := config.AvailableStyleNames()
+	if err != nil {
+		return "", err
+	}
+	for _, n := range names {
+		if n == name {
+			return templates.CommitStyle(name), nil
+		}
+	}
+	return "", fmt.Errorf("unknown style %q; available styles: %s", name, strings.Join(names, ", "))
+}
+
+// formatCommits renders commits as the log text sent to summary prompts
+func formatCommits(commits []git.Commit) string {
+	var b strings.Builder
+	for _, c := range commits {
+		fmt.Fprintf(&b, "%s %s\n", c.ShortHash(), c.Message)
+		b.WriteString("\n")
+	}
+	return strings.TrimSpace(b.String())
+}
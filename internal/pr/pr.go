@@ -0,0 +1,144 @@
+package pr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauern/muse/internal/git"
+	"github.com/klauern/muse/llm"
+)
+
+// maxPromptDiff is the largest diff sent in full; bigger branches are
+// described from a per-file summary instead
+const maxPromptDiff = 200 * 1024
+
+// TemplatePaths are the locations GitHub reads a pull request template
+// from, relative to the repository root, in order of precedence
+var TemplatePaths = []string{
+	".github/pull_request_template.md",
+	".github/PULL_REQUEST_TEMPLATE.md",
+	"docs/pull_request_template.md",
+	"pull_request_template.md",
+	"PULL_REQUEST_TEMPLATE.md",
+}
+
+// Branch holds the changes a pull request would merge
+type Branch struct {
+	Name    string
+	Target  string
+	Base    string
+	Commits []git.Commit
+	Diff    string
+	// DiffStat is set when Diff holds only a per-file summary
+	DiffStat bool
+}
+
+// Description is a generated pull request title and body
+type Description struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+// Markdown renders the description as a title heading followed by the body
+func (d *Description) Markdown() string {
+	return "# " + d.Title + "\n\n" + d.Body + "\n"
+}
+
+// Collect gathers the commits and changes between the merge base of target
+// and HEAD
+func Collect(ops *git.GitOperations, target string) (*Branch, error) {
+	info, err := ops.GetRepositoryInfo()
+	if err != nil {
+		return nil, err
+	}
+	base, err := ops.GetMergeBase(target, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	commits, err := ops.GetLog(base+"..HEAD", git.PromptLogLimit)
+	if err != nil {
+		return nil, err
+	}
+	if len(commits) == 0 {
+		return nil, fmt.Errorf("no commits between %s and HEAD", target)
+	}
+
+	b := &Branch{Name: info.Branch, Target: target, Base: base, Commits: commits}
+	b.Diff, err = ops.GetRangeDiff(base, "HEAD")
+	if err != nil && !errors.Is(err, git.ErrDiffTooLarge) {
+		return nil, err
+	}
+	if err != nil || len(b.Diff) > maxPromptDiff {
+		if b.Diff, err = ops.GetRangeDiffStat(base, "HEAD"); err != nil {
+			return nil, err
+		}
+		b.DiffStat = true
+	}
+	return b, nil
+}
+
+// FindTemplate returns the repository's pull request template, or an empty
+// string when it has none
+func FindTemplate(root string) (string, error) {
+	for _, path := range TemplatePaths {
+		data, err := os.ReadFile(filepath.Join(root, path))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read pull request template: %w", err)
+		}
+		return string(data), nil
+	}
+	return "", nil
+}
+
+// Generate asks service to describe b. When template is not empty the body
+// follows its structure.
+func Generate(ctx context.Context, service llm.LLMService, b *Branch, template string) (*Description, error) {
+	var commits strings.Builder
+	for _, c := range b.Commits {
+		fmt.Fprintf(&commits, "%s %s\n\n", c.ShortHash(), c.Message)
+	}
+
+	response, err := llm.CompletePrompt(ctx, service, "pr", map[string]any{
+		"Branch":   b.Name,
+		"Target":   b.Target,
+		"Commits":  strings.TrimSpace(commits.String()),
+		"Diff":     b.Diff,
+		"DiffStat": b.DiffStat,
+		"Template": strings.TrimSpace(template),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return parseDescription(response)
+}
+
+// parseDescription splits a response into the title on its first line and
+// the body after it, tolerating the decorations models tend to add
+func parseDescription(response string) (*Description, error) {
+	text := strings.TrimSpace(response)
+	if strings.HasPrefix(text, "```") && strings.HasSuffix(text, "```") {
+		text = strings.TrimSuffix(text, "```")
+		// Drop the opening fence along with its info string
+		if _, rest, ok := strings.Cut(text, "\n"); ok {
+			text = rest
+		}
+		text = strings.TrimSpace(text)
+	}
+
+	title, body, _ := strings.Cut(text, "\n")
+	title = strings.TrimSpace(strings.TrimLeft(title, "#"))
+	title = strings.TrimSpace(strings.TrimPrefix(title, "Title:"))
+	title = strings.Trim(title, "\"`*")
+	if title == "" {
+		return nil, fmt.Errorf("the response did not contain a pull request title")
+	}
+
+	return &Description{Title: title, Body: strings.TrimSpace(body)}, nil
+}
@@ -0,0 +1,130 @@
+package pr
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/klauern/muse/internal/git"
+	"github.com/klauern/muse/internal/gittest"
+	"github.com/klauern/muse/templates"
+)
+
+// fakeService records the prompt it receives and answers with response
+type fakeService struct {
+	prompt   string
+	response string
+}
+
+func (f *fakeService) GenerateCommitMessage(ctx context.Context, diff string, style templates.CommitStyle) (string, error) {
+	return "", nil
+}
+
+func (f *fakeService) Complete(ctx context.Context, prompt string) (string, error) {
+	f.prompt = prompt
+	return f.response, nil
+}
+
+func newFeatureRepo(t *testing.T) (*git.GitOperations, string) {
+	t.Helper()
+	repo := gittest.New(t, "-b", "main")
+	repo.Write("file.txt", "one\n")
+	repo.Commit("chore: initial")
+	repo.Git("checkout", "-q", "-b", "feature/two")
+	repo.Write("file.txt", "one\ntwo\n")
+	repo.Commit("feat: add two")
+
+	ops, err := git.NewGitOperations(repo.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ops, repo.Dir
+}
+
+func TestCollectAndGenerate(t *testing.T) {
+	ops, dir := newFeatureRepo(t)
+
+	b, err := Collect(ops, "main")
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	if b.Name != "feature/two" || len(b.Commits) != 1 || b.DiffStat || !strings.Contains(b.Diff, "+two") {
+		t.Errorf("Collect() = %+v", b)
+	}
+
+	templatePath := filepath.Join(dir, ".github", "pull_request_template.md")
+	if err := os.MkdirAll(filepath.Dir(templatePath), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(templatePath, []byte("## What\n\n## Checklist\n- [ ] Docs\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	template, err := FindTemplate(dir)
+	if err != nil || !strings.Contains(template, "## Checklist") {
+		t.Fatalf("FindTemplate() = %q, %v", template, err)
+	}
+
+	service := &fakeService{response: "Add two\n\n## What\nAdds two."}
+	d, err := Generate(context.Background(), service, b, template)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if d.Title != "Add two" || d.Body != "## What\nAdds two." {
+		t.Errorf("Generate() = %+v", d)
+	}
+	for _, want := range []string{"feature/two into main", "feat: add two", "+two", "## Checklist"} {
+		if !strings.Contains(service.prompt, want) {
+			t.Errorf("prompt does not contain %q:\n%s", want, service.prompt)
+		}
+	}
+	if strings.Contains(service.prompt, "## Motivation") {
+		t.Error("prompt uses the default sections despite a repository template")
+	}
+
+	if _, err := Collect(ops, "HEAD"); err == nil {
+		t.Error("Collect() succeeded for a branch without commits")
+	}
+}
+
+func TestFindTemplate_None(t *testing.T) {
+	template, err := FindTemplate(t.TempDir())
+	if err != nil || template != "" {
+		t.Errorf("FindTemplate() = %q, %v, want no template", template, err)
+	}
+}
+
+func TestParseDescription(t *testing.T) {
+	tests := []struct {
+		name      string
+		response  string
+		wantTitle string
+		wantBody  string
+		wantErr   bool
+	}{
+		{name: "plain", response: "Add PR command\n\n## Summary\nAdds it.", wantTitle: "Add PR command", wantBody: "## Summary\nAdds it."},
+		{name: "heading", response: "# Add PR command\n\nBody", wantTitle: "Add PR command", wantBody: "Body"},
+		{name: "title label", response: "Title: \"Add PR command\"\n\nBody", wantTitle: "Add PR command", wantBody: "Body"},
+		{name: "fenced", response: "```markdown\nAdd PR command\n\nBody\n```", wantTitle: "Add PR command", wantBody: "Body"},
+		{name: "title only", response: "Add PR command", wantTitle: "Add PR command"},
+		{name: "empty", response: "  \n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := parseDescription(tt.response)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseDescription() = %+v, want an error", d)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseDescription() error = %v", err)
+			}
+			if d.Title != tt.wantTitle || d.Body != tt.wantBody {
+				t.Errorf("parseDescription() = %+v, want title %q and body %q", d, tt.wantTitle, tt.wantBody)
+			}
+		})
+	}
+}
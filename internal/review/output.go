@@ -0,0 +1,169 @@
+package review
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/klauern/muse/templates"
+)
+
+// Formats supported by Write
+var Formats = []string{"text", "json", "sarif"}
+
+// Write renders review to w in format. version is reported as the tool
+// version in SARIF output.
+func Write(w io.Writer, review *templates.CodeReview, format, version string) error {
+	switch format {
+	case "text":
+		return WriteText(w, review)
+	case "json":
+		return writeJSON(w, review)
+	case "sarif":
+		return WriteSARIF(w, review, version)
+	}
+	return fmt.Errorf("unknown format %q; use text, json or sarif", format)
+}
+
+// WriteText prints one issue per line as file:line: severity: message,
+// followed by the suggestion and the summary
+func WriteText(w io.Writer, review *templates.CodeReview) error {
+	for _, issue := range review.Issues {
+		location := issue.File
+		if location == "" {
+			location = "(general)"
+		}
+		if issue.Line > 0 {
+			location = fmt.Sprintf("%s:%d", location, issue.Line)
+		}
+		fmt.Fprintf(w, "%s: %s: %s\n", location, issue.Severity, issue.Message)
+		if issue.Suggestion != "" {
+			fmt.Fprintf(w, "    suggestion: %s\n", issue.Suggestion)
+		}
+	}
+	if len(review.Issues) > 0 {
+		fmt.Fprintln(w)
+	}
+	if review.Summary != "" {
+		fmt.Fprintln(w, review.Summary)
+	}
+	_, err := fmt.Fprintf(w, "%d issue(s) found\n", len(review.Issues))
+	return err
+}
+
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// SARIF 2.1.0, limited to what code scanning tools read
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifact `json:"artifactLocation"`
+	Region           *sarifRegion  `json:"region,omitempty"`
+}
+
+type sarifArtifact struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+// sarifRuleID is the single rule every review result is reported under
+const sarifRuleID = "muse-review"
+
+// sarifLevel maps a severity to a SARIF result level
+func sarifLevel(severity string) string {
+	switch severity {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	}
+	return "note"
+}
+
+// WriteSARIF writes review as a SARIF 2.1.0 log for code scanning tools
+func WriteSARIF(w io.Writer, review *templates.CodeReview, version string) error {
+	results := make([]sarifResult, 0, len(review.Issues))
+	for _, issue := range review.Issues {
+		text := issue.Message
+		if issue.Suggestion != "" {
+			text += "\n\nSuggestion: " + issue.Suggestion
+		}
+		result := sarifResult{
+			RuleID:  sarifRuleID,
+			Level:   sarifLevel(issue.Severity),
+			Message: sarifMessage{Text: text},
+		}
+		if issue.File != "" {
+			location := sarifPhysicalLocation{ArtifactLocation: sarifArtifact{URI: issue.File}}
+			if issue.Line > 0 {
+				location.Region = &sarifRegion{StartLine: issue.Line}
+			}
+			result.Locations = []sarifLocation{{PhysicalLocation: location}}
+		}
+		results = append(results, result)
+	}
+
+	return writeJSON(w, sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "muse",
+				Version:        version,
+				InformationURI: "https://github.com/klauern/muse",
+				Rules: []sarifRule{{
+					ID:               sarifRuleID,
+					ShortDescription: sarifMessage{Text: "Issue found by muse review"},
+				}},
+			}},
+			Results: results,
+		}},
+	})
+}
@@ -0,0 +1,98 @@
+package review
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/klauern/muse/llm"
+	"github.com/klauern/muse/templates"
+)
+
+// Severities of review issues, most severe first
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+// Never is the threshold under which no issue blocks a commit
+const Never = "never"
+
+// rank orders severities; unknown severities rank lowest
+func rank(severity string) int {
+	switch severity {
+	case SeverityError:
+		return 3
+	case SeverityWarning:
+		return 2
+	case SeverityInfo:
+		return 1
+	}
+	return 0
+}
+
+// Review asks service to review diff and returns its issues, most severe
+// first
+func Review(ctx context.Context, service llm.LLMService, diff string) (*templates.CodeReview, error) {
+	if strings.TrimSpace(diff) == "" {
+		return nil, fmt.Errorf("nothing to review")
+	}
+
+	schema := templates.ReviewSchema()
+	var review templates.CodeReview
+	data := map[string]any{"Diff": diff, "Schema": schema}
+	if err := llm.CompleteStructuredPrompt(ctx, service, "review", data, schema, &review); err != nil {
+		return nil, err
+	}
+	normalize(&review)
+	return &review, nil
+}
+
+// normalize cleans up model output: severities are lowercased, unknown ones
+// become info, and issues are sorted by severity then location
+func normalize(r *templates.CodeReview) {
+	r.Summary = strings.TrimSpace(r.Summary)
+	if r.Issues == nil {
+		r.Issues = []templates.ReviewIssue{}
+	}
+	for i := range r.Issues {
+		issue := &r.Issues[i]
+		issue.Severity = strings.ToLower(strings.TrimSpace(issue.Severity))
+		if rank(issue.Severity) == 0 {
+			issue.Severity = SeverityInfo
+		}
+		if issue.Line < 0 {
+			issue.Line = 0
+		}
+		issue.Message = strings.TrimSpace(issue.Message)
+		issue.Suggestion = strings.TrimSpace(issue.Suggestion)
+	}
+	sort.SliceStable(r.Issues, func(i, j int) bool {
+		a, b := r.Issues[i], r.Issues[j]
+		if rank(a.Severity) != rank(b.Severity) {
+			return rank(a.Severity) > rank(b.Severity)
+		}
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+}
+
+// Blocking returns the issues at or above threshold. A threshold of never
+// blocks nothing.
+func Blocking(issues []templates.ReviewIssue, threshold string) []templates.ReviewIssue {
+	min := rank(threshold)
+	if min == 0 {
+		return nil
+	}
+	var blocking []templates.ReviewIssue
+	for _, issue := range issues {
+		if rank(issue.Severity) >= min {
+			blocking = append(blocking, issue)
+		}
+	}
+	return blocking
+}
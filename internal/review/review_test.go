@@ -0,0 +1,123 @@
+package review
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/klauern/muse/templates"
+)
+
+// fakeService answers every prompt with a fixed response
+type fakeService struct {
+	response string
+	prompt   string
+}
+
+func (f *fakeService) GenerateCommitMessage(ctx context.Context, diff string, style templates.CommitStyle) (string, error) {
+	return "", nil
+}
+
+func (f *fakeService) Complete(ctx context.Context, prompt string) (string, error) {
+	f.prompt = prompt
+	return f.response, nil
+}
+
+const response = `Here is the review:
+{"summary": "Mostly fine.", "issues": [
+  {"file": "b.go", "line": 3, "severity": "info", "message": "Name is unclear", "suggestion": ""},
+  {"file": "a.go", "line": 12, "severity": "ERROR", "message": "Error is ignored", "suggestion": "Return the error"},
+  {"file": "a.go", "line": 0, "severity": "warning", "message": "Missing test", "suggestion": "Add a test"},
+  {"file": "", "line": -1, "severity": "nit", "message": "General remark", "suggestion": ""}
+]}`
+
+func TestReview(t *testing.T) {
+	service := &fakeService{response: response}
+	review, err := Review(context.Background(), service, "diff --git a/a.go b/a.go\n+x := f()")
+	if err != nil {
+		t.Fatalf("Review() error = %v", err)
+	}
+	if !strings.Contains(service.prompt, "+x := f()") || !strings.Contains(service.prompt, `"severity"`) {
+		t.Errorf("prompt is missing the diff or the schema:\n%s", service.prompt)
+	}
+
+	var got []string
+	for _, issue := range review.Issues {
+		got = append(got, issue.Severity+" "+issue.File)
+	}
+	want := "error a.go,warning a.go,info ,info b.go"
+	if strings.Join(got, ",") != want {
+		t.Errorf("issues = %v, want %s", got, want)
+	}
+	if review.Issues[2].Line != 0 {
+		t.Errorf("negative line = %d, want 0", review.Issues[2].Line)
+	}
+
+	if _, err := Review(context.Background(), service, "  \n"); err == nil {
+		t.Error("Review() of an empty diff succeeded")
+	}
+}
+
+func TestBlocking(t *testing.T) {
+	issues := []templates.ReviewIssue{{Severity: "error"}, {Severity: "warning"}, {Severity: "info"}}
+	tests := map[string]int{"error": 1, "warning": 2, "info": 3, "never": 0}
+	for threshold, want := range tests {
+		if got := len(Blocking(issues, threshold)); got != want {
+			t.Errorf("Blocking(%s) returned %d issues, want %d", threshold, got, want)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	review := &templates.CodeReview{
+		Summary: "One problem.",
+		Issues: []templates.ReviewIssue{
+			{File: "a.go", Line: 12, Severity: "error", Message: "Error is ignored", Suggestion: "Return the error"},
+			{File: "", Severity: "info", Message: "General remark"},
+		},
+	}
+
+	var text bytes.Buffer
+	if err := Write(&text, review, "text", "1.0.0"); err != nil {
+		t.Fatal(err)
+	}
+	wantText := "a.go:12: error: Error is ignored\n    suggestion: Return the error\n(general): info: General remark\n\nOne problem.\n2 issue(s) found\n"
+	if text.String() != wantText {
+		t.Errorf("text output = %q, want %q", text.String(), wantText)
+	}
+
+	var sarif bytes.Buffer
+	if err := Write(&sarif, review, "sarif", "1.0.0"); err != nil {
+		t.Fatal(err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(sarif.Bytes(), &log); err != nil {
+		t.Fatalf("invalid SARIF: %v", err)
+	}
+	results := log.Runs[0].Results
+	if log.Version != "2.1.0" || log.Runs[0].Tool.Driver.Version != "1.0.0" || len(results) != 2 {
+		t.Fatalf("SARIF log = %+v", log)
+	}
+	if results[0].Level != "error" || results[0].Locations[0].PhysicalLocation.Region.StartLine != 12 ||
+		!strings.Contains(results[0].Message.Text, "Suggestion: Return the error") {
+		t.Errorf("first result = %+v", results[0])
+	}
+	if results[1].Level != "note" || results[1].Locations != nil {
+		t.Errorf("general result = %+v, want a note without locations", results[1])
+	}
+
+	var js bytes.Buffer
+	if err := Write(&js, review, "json", ""); err != nil {
+		t.Fatal(err)
+	}
+	var decoded templates.CodeReview
+	if err := json.Unmarshal(js.Bytes(), &decoded); err != nil || len(decoded.Issues) != 2 {
+		t.Errorf("JSON output = %s (%v)", js.String(), err)
+	}
+
+	if err := Write(&js, review, "xml", ""); err == nil {
+		t.Error("Write() accepted an unknown format")
+	}
+}
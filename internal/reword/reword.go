@@ -0,0 +1,203 @@
+package reword
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path"
+	"strings"
+
+	"github.com/klauern/muse/internal/git"
+	"github.com/klauern/muse/templates"
+)
+
+// DefaultProtectedBranches are remote branch patterns whose commits are
+// never rewritten
+var DefaultProtectedBranches = []string{"main", "master", "release/*"}
+
+// Generator writes a commit message for a diff
+type Generator interface {
+	Generate(ctx context.Context, diff string, style templates.CommitStyle) (string, error)
+}
+
+// Step is a commit and the message it will be given
+type Step struct {
+	Commit     git.Commit
+	NewMessage string
+}
+
+// Changed reports whether the step gives the commit a different message
+func (s Step) Changed() bool {
+	return s.NewMessage != "" && strings.TrimSpace(s.NewMessage) != strings.TrimSpace(s.Commit.Message)
+}
+
+// Plan rewords the commits after Base up to Head, the commit Ref points at
+type Plan struct {
+	// Ref is the branch to move, such as refs/heads/feature, or HEAD when
+	// it is detached
+	Ref  string
+	Head string
+	Base string
+	// Steps are ordered oldest first
+	Steps []Step
+	// Pushed lists the unprotected remote branches that already contain
+	// the commits, which will need a force push
+	Pushed []string
+}
+
+// NewPlan collects the commits after base up to HEAD. It refuses ranges
+// with merge commits and commits that are on a remote branch matching one
+// of the protected patterns.
+func NewPlan(ops *git.GitOperations, base string, protected []string) (*Plan, error) {
+	ref, err := ops.GetHeadRef()
+	if err != nil {
+		return nil, err
+	}
+	head, err := ops.ResolveCommit("HEAD")
+	if err != nil {
+		return nil, err
+	}
+	if base, err = ops.ResolveCommit(base); err != nil {
+		return nil, err
+	}
+
+	revRange := base + "..HEAD"
+	hasMerges, err := ops.HasMerges(revRange)
+	if err != nil {
+		return nil, err
+	}
+	if hasMerges {
+		return nil, fmt.Errorf("the range contains merge commits, which reword cannot recreate")
+	}
+	commits, err := ops.GetLog(revRange, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(commits) == 0 {
+		return nil, fmt.Errorf("no commits between %s and HEAD", base)
+	}
+
+	p := &Plan{Ref: ref, Head: head, Base: base}
+	for i := len(commits) - 1; i >= 0; i-- {
+		p.Steps = append(p.Steps, Step{Commit: commits[i]})
+	}
+
+	// A branch that contains any of the commits contains the oldest one
+	remotes, err := ops.GetRemoteBranchesContaining(p.Steps[0].Commit.Hash)
+	if err != nil {
+		return nil, err
+	}
+	for _, remote := range remotes {
+		if isProtected(remote, protected) {
+			return nil, fmt.Errorf("commit %s is already on protected branch %s; rewriting it would require a force push",
+				p.Steps[0].Commit.ShortHash(), remote)
+		}
+		p.Pushed = append(p.Pushed, remote)
+	}
+	return p, nil
+}
+
+// isProtected reports whether a remote branch such as origin/release/1.0
+// matches one of the patterns, which are given without the remote name
+func isProtected(remote string, patterns []string) bool {
+	_, branch, found := strings.Cut(remote, "/")
+	if !found {
+		branch = remote
+	}
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, branch); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Generate writes a new message for each step from the commit's diff.
+// Commits without changes, or with diffs too large to send, keep their
+// message. progress is called before each commit.
+func (p *Plan) Generate(ctx context.Context, ops *git.GitOperations, generator Generator, style templates.CommitStyle, progress func(i int, s Step)) error {
+	for i := range p.Steps {
+		step := &p.Steps[i]
+		if progress != nil {
+			progress(i, *step)
+		}
+
+		diff, err := ops.GetCommitDiff(step.Commit.Hash)
+		if errors.Is(err, git.ErrDiffTooLarge) {
+			slog.Warn("Keeping the message of a commit with a large diff", "commit", step.Commit.ShortHash())
+			step.NewMessage = step.Commit.Message
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(diff) == "" {
+			step.NewMessage = step.Commit.Message
+			continue
+		}
+
+		message, err := generator.Generate(ctx, diff, style)
+		if err != nil {
+			return fmt.Errorf("failed to generate a message for %s: %w", step.Commit.ShortHash(), err)
+		}
+		step.NewMessage = strings.TrimSpace(message)
+	}
+	return nil
+}
+
+// Changes returns the number of steps that change a message
+func (p *Plan) Changes() int {
+	n := 0
+	for _, s := range p.Steps {
+		if s.Changed() {
+			n++
+		}
+	}
+	return n
+}
+
+// Apply recreates the commits with their new messages, keeping trees and
+// authors, and moves Ref to the new head, which it returns. Ref must still
+// point at Head. The working tree and index are left alone since no tree
+// changes.
+func Apply(ops *git.GitOperations, p *Plan) (string, error) {
+	rewritten := map[string]string{}
+	head := p.Head
+	for _, step := range p.Steps {
+		info, err := ops.GetCommitInfo(step.Commit.Hash)
+		if err != nil {
+			return "", err
+		}
+
+		parentChanged := false
+		for i, parent := range info.Parents {
+			if newParent, ok := rewritten[parent]; ok {
+				info.Parents[i] = newParent
+				parentChanged = true
+			}
+		}
+		if !step.Changed() && !parentChanged {
+			continue
+		}
+
+		message := step.Commit.Message
+		if step.Changed() {
+			message = step.NewMessage
+		}
+		hash, err := ops.CreateCommit(info, strings.TrimSpace(message)+"\n")
+		if err != nil {
+			return "", err
+		}
+		rewritten[step.Commit.Hash] = hash
+		head = hash
+	}
+
+	if head == p.Head {
+		return head, nil
+	}
+	if err := ops.UpdateRef(p.Ref, head, p.Head, "muse reword"); err != nil {
+		return "", err
+	}
+	return head, nil
+}
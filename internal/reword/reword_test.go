@@ -0,0 +1,138 @@
+package reword
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/klauern/muse/internal/git"
+	"github.com/klauern/muse/internal/gittest"
+	"github.com/klauern/muse/templates"
+)
+
+// fakeGenerator names each commit after the first added line of its diff
+type fakeGenerator struct{}
+
+func (fakeGenerator) Generate(ctx context.Context, diff string, style templates.CommitStyle) (string, error) {
+	for _, line := range strings.Split(diff, "\n") {
+		if strings.HasPrefix(line, "+") && !strings.HasPrefix(line, "+++") {
+			return "feat: add " + strings.TrimPrefix(line, "+") + "\n", nil
+		}
+	}
+	return "chore: update", nil
+}
+
+func newTestRepo(t *testing.T) (*git.GitOperations, func(args ...string) string) {
+	t.Helper()
+	repo := gittest.New(t, "-b", "feature")
+	repo.Env = []string{
+		"GIT_AUTHOR_NAME=Author & Co", "GIT_AUTHOR_EMAIL=author@example.com",
+		"GIT_AUTHOR_DATE=2020-01-02T03:04:05+01:00",
+	}
+	// Apply commits as the configured user
+	repo.Git("config", "user.name", "Test")
+	repo.Git("config", "user.email", "test@example.com")
+	content := ""
+	for _, c := range []struct{ line, message string }{{"base", "initial"}, {"alpha", "wip"}, {"beta", "fix"}} {
+		content += c.line + "\n"
+		repo.Write("file.txt", content)
+		repo.Commit(c.message)
+	}
+	repo.Commit("empty")
+
+	ops, err := git.NewGitOperations(repo.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ops, repo.Git
+}
+
+func TestPlanAndApply(t *testing.T) {
+	ops, run := newTestRepo(t)
+	oldTree := run("rev-parse", "HEAD^{tree}")
+
+	p, err := NewPlan(ops, "HEAD~3", DefaultProtectedBranches)
+	if err != nil {
+		t.Fatalf("NewPlan() error = %v", err)
+	}
+	if p.Ref != "refs/heads/feature" || len(p.Steps) != 3 || p.Steps[0].Commit.Message != "wip" {
+		t.Fatalf("NewPlan() = %+v", p)
+	}
+
+	if err := p.Generate(context.Background(), ops, fakeGenerator{}, templates.CommitStyle("conventional"), nil); err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if p.Steps[0].NewMessage != "feat: add alpha" || p.Steps[1].NewMessage != "feat: add beta" {
+		t.Errorf("new messages = %q, %q", p.Steps[0].NewMessage, p.Steps[1].NewMessage)
+	}
+	if p.Steps[2].Changed() {
+		t.Errorf("the empty commit was reworded to %q", p.Steps[2].NewMessage)
+	}
+	if p.Changes() != 2 {
+		t.Errorf("Changes() = %d, want 2", p.Changes())
+	}
+
+	head, err := Apply(ops, p)
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if got := run("rev-parse", "HEAD"); got != head || head == p.Head {
+		t.Errorf("HEAD = %s, want the new head %s", got, head)
+	}
+	if got := run("log", "--format=%s", "HEAD~3..HEAD"); got != "empty\nfeat: add beta\nfeat: add alpha" {
+		t.Errorf("messages after Apply() = %q", got)
+	}
+	if got := run("rev-parse", "HEAD^{tree}"); got != oldTree {
+		t.Errorf("tree changed from %s to %s", oldTree, got)
+	}
+	if got := run("log", "-1", "--format=%an|%aI", "HEAD~1"); got != "Author & Co|2020-01-02T03:04:05+01:00" {
+		t.Errorf("author after Apply() = %q", got)
+	}
+	if got := run("log", "-1", "--format=%s", "HEAD~3"); got != "initial" {
+		t.Errorf("base commit = %q, want it untouched", got)
+	}
+}
+
+func TestNewPlan_Refusals(t *testing.T) {
+	ops, run := newTestRepo(t)
+
+	run("update-ref", "refs/remotes/origin/topic", "HEAD~1")
+	p, err := NewPlan(ops, "HEAD~2", DefaultProtectedBranches)
+	if err != nil {
+		t.Fatalf("NewPlan() error = %v", err)
+	}
+	if len(p.Pushed) != 1 || p.Pushed[0] != "origin/topic" {
+		t.Errorf("Pushed = %v, want origin/topic", p.Pushed)
+	}
+
+	run("update-ref", "refs/remotes/origin/release/1.0", "HEAD~1")
+	if _, err := NewPlan(ops, "HEAD~2", DefaultProtectedBranches); err == nil || !strings.Contains(err.Error(), "origin/release/1.0") {
+		t.Errorf("NewPlan() error = %v, want a refusal for the protected branch", err)
+	}
+	// Commits after the protected branch may still be reworded
+	if _, err := NewPlan(ops, "HEAD~1", DefaultProtectedBranches); err != nil {
+		t.Errorf("NewPlan() error = %v for unpushed commits", err)
+	}
+
+	if _, err := NewPlan(ops, "HEAD", nil); err == nil {
+		t.Error("NewPlan() succeeded for an empty range")
+	}
+}
+
+func TestIsProtected(t *testing.T) {
+	tests := []struct {
+		remote string
+		want   bool
+	}{
+		{"origin/main", true},
+		{"upstream/master", true},
+		{"origin/release/2.1", true},
+		{"origin/feature/main", false},
+		{"origin/mainline", false},
+	}
+	for _, tt := range tests {
+		if got := isProtected(tt.remote, DefaultProtectedBranches); got != tt.want {
+			t.Errorf("isProtected(%s) = %v, want %v", tt.remote, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,292 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/klauern/muse/config"
+	"github.com/klauern/muse/internal/daemon"
+	"github.com/klauern/muse/internal/feedback"
+	"github.com/klauern/muse/internal/git"
+	"github.com/klauern/muse/internal/jsonrpc"
+	"github.com/klauern/muse/internal/ledger"
+	"github.com/klauern/muse/internal/lint"
+	"github.com/klauern/muse/llm"
+	"github.com/klauern/muse/templates"
+)
+
+// repoParams select the repository, and so the config, of a request
+type repoParams struct {
+	RepoPath string `json:"repo_path"`
+	// Profile overrides the profile the repository would select
+	Profile string `json:"profile,omitempty"`
+}
+
+type generateParams struct {
+	repoParams
+	Style string `json:"style,omitempty"`
+	// Source is staged, the default, or working-tree
+	Source string `json:"source,omitempty"`
+	// StreamToken, when set, asks for progress notifications carrying it
+	StreamToken string `json:"stream_token,omitempty"`
+}
+
+type regenerateParams struct {
+	generateParams
+	// Message is the message being replaced, such as the commit box content
+	Message string `json:"message,omitempty"`
+	Hint    string `json:"hint"`
+}
+
+type lintParams struct {
+	repoParams
+	Message string `json:"message"`
+	Style   string `json:"style,omitempty"`
+}
+
+// messageResult is the result of generate and regenerate
+type messageResult struct {
+	Message string `json:"message"`
+	// Commit is the message split into its parts; messages that are not
+	// conventional commits only have a subject and body
+	Commit     *templates.ParsedCommit `json:"commit"`
+	Style      string                  `json:"style"`
+	Profile    string                  `json:"profile,omitempty"`
+	Provider   string                  `json:"provider"`
+	Model      string                  `json:"model,omitempty"`
+	Source     string                  `json:"source"`
+	Usage      llm.Usage               `json:"usage"`
+	DurationMS int64                   `json:"duration_ms"`
+}
+
+type styleInfo struct {
+	Name    string `json:"name"`
+	Default bool   `json:"default"`
+}
+
+type profileInfo struct {
+	Name   string `json:"name"`
+	Active bool   `json:"active"`
+}
+
+// request is a generate or regenerate call with its config and diff
+type request struct {
+	cfg    *config.Config
+	style  templates.CommitStyle
+	source string
+	diff   string
+	start  time.Time
+}
+
+func (s *Server) generate(ctx context.Context, params json.RawMessage) (any, error) {
+	var p generateParams
+	if err := jsonrpc.DecodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	req, err := s.prepare(p)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, gen := req.startGeneration(ctx, p.RepoPath, "rpc")
+	ctx = feedback.WithExamples(ctx, req.cfg, gen.Repo, req.style, req.diff)
+	// Use a running 'muse serve' daemon, or generate in-process, as the
+	// hook does
+	generator := daemon.NewGenerator(req.cfg)
+	// The daemon reads API key settings from the config of the repository
+	generator.Dir, _ = filepath.Abs(p.RepoPath)
+	message, err := generator.Generate(ctx, req.diff, req.style)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate commit message: %w", err)
+	}
+	gen.Record("")
+	// Styles are generated as structured output, so the message arrives
+	// whole rather than in pieces, as partialOutput advertises
+	progress(ctx, p.StreamToken, message)
+	return req.result(message, gen.Usage()), nil
+}
+
+func (s *Server) regenerate(ctx context.Context, params json.RawMessage) (any, error) {
+	var p regenerateParams
+	if err := jsonrpc.DecodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(p.Hint) == "" {
+		return nil, jsonrpc.InvalidParams("hint is required")
+	}
+	req, err := s.prepare(p.generateParams)
+	if err != nil {
+		return nil, err
+	}
+
+	service, err := llm.NewLLMService(&req.cfg.LLM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create LLM service: %w", err)
+	}
+	ctx, gen := req.startGeneration(ctx, p.RepoPath, "rpc")
+	message, err := llm.Regenerate(ctx, service, llm.Revision{
+		Diff:     req.diff,
+		Style:    req.style,
+		Previous: templates.StripComments(p.Message),
+		Hint:     p.Hint,
+	}, func(delta string) {
+		progress(ctx, p.StreamToken, delta)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to regenerate commit message: %w", err)
+	}
+	gen.Record("")
+	return req.result(message, gen.Usage()), nil
+}
+
+// prepare loads the config and diff of a generate or regenerate call
+func (s *Server) prepare(p generateParams) (*request, error) {
+	if p.RepoPath == "" {
+		return nil, jsonrpc.InvalidParams("repo_path is required")
+	}
+	cfg, err := s.config(p.RepoPath, p.Profile)
+	if err != nil {
+		return nil, err
+	}
+	style, err := resolveStyle(cfg, p.Style)
+	if err != nil {
+		return nil, err
+	}
+
+	ops, err := git.NewGitOperations(p.RepoPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize git operations: %w", err)
+	}
+	req := &request{cfg: cfg, style: style, source: p.Source, start: time.Now()}
+	switch p.Source {
+	case "", "staged":
+		req.source = "staged"
+		req.diff, err = ops.GetStagedDiff()
+	case "working-tree":
+		req.diff, err = ops.GetWorkingTreeDiff()
+	default:
+		return nil, jsonrpc.InvalidParams("unknown source %q; use staged or working-tree", p.Source)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(req.diff) == "" {
+		if req.source == "staged" {
+			return nil, fmt.Errorf("nothing is staged; stage changes with git add or use the working-tree source")
+		}
+		return nil, fmt.Errorf("no changes to describe in the working tree")
+	}
+	return req, nil
+}
+
+// startGeneration starts a ledger entry for the repository at repoPath,
+// which need not be the server's working directory
+func (r *request) startGeneration(ctx context.Context, repoPath, command string) (context.Context, *ledger.Generation) {
+	ctx, gen := ledger.Start(ctx, r.cfg, command)
+	gen.Repo = config.RepoRootOf(repoPath)
+	return ctx, gen
+}
+
+func (r *request) result(message string, usage llm.Usage) messageResult {
+	result := messageResult{
+		Message:    strings.TrimSpace(message),
+		Commit:     templates.ParseCommit(message),
+		Style:      string(r.style),
+		Profile:    r.cfg.Profile,
+		Provider:   r.cfg.LLM.Provider,
+		Source:     r.source,
+		Usage:      usage,
+		DurationMS: time.Since(r.start).Milliseconds(),
+	}
+	if model, ok := r.cfg.LLM.Config["model"].(string); ok {
+		result.Model = model
+	}
+	return result
+}
+
+func (s *Server) lint(ctx context.Context, params json.RawMessage) (any, error) {
+	var p lintParams
+	if err := jsonrpc.DecodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(p.Message) == "" {
+		return nil, jsonrpc.InvalidParams("message is required")
+	}
+	cfg, err := s.config(p.RepoPath, p.Profile)
+	if err != nil {
+		return nil, err
+	}
+	style, err := resolveStyle(cfg, p.Style)
+	if err != nil {
+		return nil, err
+	}
+
+	issues := lint.Lint(p.Message, style)
+	if issues == nil {
+		issues = []lint.Issue{}
+	}
+	return map[string]any{"valid": !lint.HasErrors(issues), "style": style, "issues": issues}, nil
+}
+
+func (s *Server) listStyles(ctx context.Context, params json.RawMessage) (any, error) {
+	var p repoParams
+	if err := jsonrpc.DecodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	cfg, err := s.config(p.RepoPath, p.Profile)
+	if err != nil {
+		return nil, err
+	}
+	names, err := config.AvailableStyleNames()
+	if err != nil {
+		return nil, err
+	}
+
+	styles := make([]styleInfo, 0, len(names))
+	for _, name := range names {
+		styles = append(styles, styleInfo{Name: name, Default: name == string(cfg.Hook.CommitStyle)})
+	}
+	return map[string]any{"styles": styles}, nil
+}
+
+func (s *Server) listProfiles(ctx context.Context, params json.RawMessage) (any, error) {
+	var p repoParams
+	if err := jsonrpc.DecodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	cfg, err := s.config(p.RepoPath, p.Profile)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(cfg.Profiles))
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	profiles := make([]profileInfo, 0, len(names))
+	for _, name := range names {
+		profiles = append(profiles, profileInfo{Name: name, Active: name == cfg.Profile})
+	}
+	return map[string]any{"profiles": profiles, "active": cfg.Profile}, nil
+}
+
+// resolveStyle returns the requested style, or the configured one when empty
+func resolveStyle(cfg *config.Config, name string) (templates.CommitStyle, error) {
+	if name == "" {
+		return cfg.Hook.CommitStyle, nil
+	}
+	names, err := config.AvailableStyleNames()
+	if err != nil {
+		return "", err
+	}
+	if !slices.Contains(names, name) {
+		return "", jsonrpc.InvalidParams("unknown style %q; available styles: %s", name, strings.Join(names, ", "))
+	}
+	return templates.CommitStyle(name), nil
+}
@@ -0,0 +1,211 @@
+// Package rpc serves muse to editor plugins as JSON-RPC 2.0 over stdio, so
+// an SCM commit box can be filled with the same config and templates as the
+// hook.
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"strings"
+
+	"github.com/klauern/muse/config"
+	"github.com/klauern/muse/internal/configloader"
+	"github.com/klauern/muse/internal/exitcode"
+	"github.com/klauern/muse/internal/jsonrpc"
+)
+
+// ProtocolVersion is the version of the protocol described in the README.
+// Clients must send initialize first; they are accepted when their major
+// version matches, since minor versions only add methods and fields.
+const ProtocolVersion = "1.1"
+
+// Error codes beyond the JSON-RPC standard ones
+const (
+	// CodeRequestFailed reports a failed method; the error data holds the
+	// class of the failure, as used for muse's exit codes
+	CodeRequestFailed = -32001
+	// CodeNotInitialized is returned for calls made before initialize
+	CodeNotInitialized = -32002
+	// CodeUnsupportedVersion is returned by initialize when the client's
+	// major version differs from ProtocolVersion
+	CodeUnsupportedVersion = -32003
+)
+
+// ProgressMethod is the notification that streams partial output of
+// requests that passed a stream_token
+const ProgressMethod = "progress"
+
+// Server answers editor requests for any repository
+type Server struct {
+	Version string
+	// Overrides are flattened config keys, such as those of --model, that
+	// apply to every repository
+	Overrides map[string]any
+	// LoadConfig loads the config of the repository containing dir with
+	// overrides on top. When nil, config is loaded as the hook would load it
+	// in that repository.
+	LoadConfig func(dir string, overrides map[string]any) (*config.Config, error)
+
+	initialized bool
+	// loaders caches config per repository and profile; requests are
+	// handled one at a time, so it needs no lock
+	loaders map[string]*configloader.ConfigLoader
+}
+
+// NewServer returns a server that reports version as muse's version
+func NewServer(version string) *Server {
+	return &Server{Version: version, loaders: make(map[string]*configloader.ConfigLoader)}
+}
+
+// Serve speaks the protocol over newline-delimited JSON-RPC on r and w
+// until r is closed
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	defer s.closeLoaders()
+
+	rpc := jsonrpc.NewServer()
+	rpc.Handle("initialize", s.initialize)
+	rpc.Handle("generate", s.requireInit(s.generate))
+	rpc.Handle("regenerate", s.requireInit(s.regenerate))
+	rpc.Handle("lint", s.requireInit(s.lint))
+	rpc.Handle("styles/list", s.requireInit(s.listStyles))
+	rpc.Handle("profiles/list", s.requireInit(s.listProfiles))
+	return rpc.Serve(ctx, r, w)
+}
+
+// methods lists the methods available after initialize
+var methods = []string{"generate", "regenerate", "lint", "styles/list", "profiles/list"}
+
+// partialOutput reports, for each method that sends progress notifications,
+// whether they carry the output as the model writes it. generate sends the
+// finished message in one notification, since styles are generated as
+// structured output.
+var partialOutput = map[string]bool{"generate": false, "regenerate": true}
+
+type clientInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type initializeParams struct {
+	ProtocolVersion string     `json:"protocol_version"`
+	Client          clientInfo `json:"client"`
+}
+
+type initializeResult struct {
+	ProtocolVersion string         `json:"protocol_version"`
+	Server          clientInfo     `json:"server"`
+	Methods         []string       `json:"methods"`
+	Capabilities    map[string]any `json:"capabilities"`
+}
+
+func (s *Server) initialize(ctx context.Context, params json.RawMessage) (any, error) {
+	var p initializeParams
+	if err := jsonrpc.DecodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	if p.ProtocolVersion == "" {
+		return nil, jsonrpc.InvalidParams("protocol_version is required")
+	}
+	if major(p.ProtocolVersion) != major(ProtocolVersion) {
+		return nil, &jsonrpc.Error{
+			Code:    CodeUnsupportedVersion,
+			Message: fmt.Sprintf("protocol version %s is not supported; this muse speaks %s", p.ProtocolVersion, ProtocolVersion),
+			Data:    map[string]any{"supported": []string{ProtocolVersion}},
+		}
+	}
+
+	slog.Debug("Editor connected", "client", p.Client.Name, "client_version", p.Client.Version, "protocol_version", p.ProtocolVersion)
+	s.initialized = true
+	return initializeResult{
+		ProtocolVersion: ProtocolVersion,
+		Server:          clientInfo{Name: "muse", Version: s.Version},
+		Methods:         methods,
+		Capabilities:    map[string]any{"streaming": true, "partial_output": partialOutput},
+	}, nil
+}
+
+// major returns the major part of a version such as 1.2
+func major(version string) string {
+	m, _, _ := strings.Cut(version, ".")
+	return m
+}
+
+// requireInit rejects calls to h made before initialize, and reports its
+// failures with their class
+func (s *Server) requireInit(h jsonrpc.Handler) jsonrpc.Handler {
+	return func(ctx context.Context, params json.RawMessage) (any, error) {
+		if !s.initialized {
+			return nil, &jsonrpc.Error{Code: CodeNotInitialized, Message: "call initialize first"}
+		}
+		result, err := h(ctx, params)
+		if err != nil {
+			return nil, requestError(err)
+		}
+		return result, nil
+	}
+}
+
+// requestError turns a failure into a JSON-RPC error that editors can act
+// on, such as by asking for an API key when the class is auth
+func requestError(err error) error {
+	var rpcErr *jsonrpc.Error
+	if errors.As(err, &rpcErr) {
+		return rpcErr
+	}
+	code := exitcode.Classify(err)
+	return &jsonrpc.Error{
+		Code:    CodeRequestFailed,
+		Message: err.Error(),
+		Data:    map[string]any{"class": exitcode.Class(code)},
+	}
+}
+
+// config returns the config of the repository containing dir, with profile
+// applied when it is not empty
+func (s *Server) config(dir, profile string) (*config.Config, error) {
+	overrides := maps.Clone(s.Overrides)
+	if overrides == nil {
+		overrides = map[string]any{}
+	}
+	if profile != "" {
+		overrides["profile"] = profile
+	}
+	if s.LoadConfig != nil {
+		return s.LoadConfig(dir, overrides)
+	}
+
+	key := dir + "\x00" + profile
+	loader, ok := s.loaders[key]
+	if !ok {
+		loader = configloader.NewRepoLoader(dir)
+		loader.SetOverrides(overrides)
+		s.loaders[key] = loader
+	}
+	cfg, err := loader.LoadConfigSafe()
+	if err != nil {
+		return nil, fmt.Errorf("error loading config: %w", err)
+	}
+	return cfg, nil
+}
+
+func (s *Server) closeLoaders() {
+	for key, loader := range s.loaders {
+		if err := loader.Close(); err != nil {
+			slog.Debug("Failed to stop watching config files", "error", err)
+		}
+		delete(s.loaders, key)
+	}
+}
+
+// progress streams text to the client when the request passed a token
+func progress(ctx context.Context, token, text string) {
+	if token == "" || text == "" {
+		return
+	}
+	jsonrpc.Notify(ctx, ProgressMethod, map[string]string{"token": token, "text": text})
+}
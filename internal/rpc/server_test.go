@@ -0,0 +1,279 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/klauern/muse/config"
+	"github.com/klauern/muse/internal/gittest"
+	"github.com/klauern/muse/llm"
+	"github.com/klauern/muse/templates"
+)
+
+const testProvider = "rpc-test"
+
+// fakeService echoes the diff back as a commit message and streams
+// regenerated messages in two pieces. Diffs mentioning "denied" fail as if
+// the API key was rejected.
+type fakeService struct{}
+
+func (fakeService) GenerateCommitMessage(ctx context.Context, diff string, style templates.CommitStyle) (string, error) {
+	if strings.Contains(diff, "denied") {
+		return "", fmt.Errorf("%w: invalid api key", llm.ErrAuth)
+	}
+	llm.RecordUsage(ctx, llm.Usage{Requests: 1, PromptTokens: 10, CompletionTokens: 5})
+	return "feat(" + string(style) + "): " + strings.TrimSpace(diff[strings.LastIndex(diff, "+")+1:]), nil
+}
+
+func (fakeService) Complete(ctx context.Context, prompt string) (string, error) {
+	return "fix: complete", nil
+}
+
+func (fakeService) CompleteStream(ctx context.Context, prompt string, onDelta func(string)) (string, error) {
+	if !strings.Contains(prompt, "Follow this guidance from the author: shorter") || !strings.Contains(prompt, "feat: old message") {
+		return "", fmt.Errorf("prompt is missing the hint or previous message:\n%s", prompt)
+	}
+	onDelta("```\nfix: ")
+	onDelta("shorter\n```")
+	llm.RecordUsage(ctx, llm.Usage{Requests: 1, PromptTokens: 20, CompletionTokens: 2})
+	return "```\nfix: shorter\n```", nil
+}
+
+type fakeProvider struct{}
+
+func (fakeProvider) NewService(map[string]any) (llm.LLMService, error) { return fakeService{}, nil }
+
+func init() {
+	llm.RegisterProvider(testProvider, fakeProvider{})
+}
+
+// newTestRepo returns a repository with a staged file containing content
+func newTestRepo(t *testing.T, content string) string {
+	t.Helper()
+	repo := gittest.New(t)
+	repo.Write("file.txt", content+"\n")
+	repo.Git("add", "file.txt")
+	return repo.Dir
+}
+
+// newTestServer returns a server whose config uses the fake provider and
+// selects the work profile unless another one is requested
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	// Keep a daemon running on this machine out of the tests
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+
+	s := NewServer("1.2.3")
+	s.LoadConfig = func(dir string, overrides map[string]any) (*config.Config, error) {
+		profile, _ := overrides["profile"].(string)
+		if profile == "" {
+			profile = "work"
+		}
+		return &config.Config{
+			Hook:     config.Hook{CommitStyle: "conventional"},
+			LLM:      config.LLMConfig{Provider: testProvider, Config: map[string]any{"model": "fake-1"}},
+			Profile:  profile,
+			Profiles: map[string]config.Profile{"work": {}, "oss": {}},
+		}, nil
+	}
+	return s
+}
+
+// serve sends requests to s and returns every message it wrote
+func serve(t *testing.T, s *Server, requests ...string) []map[string]any {
+	t.Helper()
+	var out bytes.Buffer
+	if err := s.Serve(context.Background(), strings.NewReader(strings.Join(requests, "\n")), &out); err != nil {
+		t.Fatalf("Serve() error = %v", err)
+	}
+
+	var messages []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var m map[string]any
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatalf("invalid output line %q: %v", line, err)
+		}
+		messages = append(messages, m)
+	}
+	return messages
+}
+
+// response returns the response with id
+func response(t *testing.T, messages []map[string]any, id float64) map[string]any {
+	t.Helper()
+	for _, m := range messages {
+		if m["id"] == id {
+			return m
+		}
+	}
+	t.Fatalf("no response with id %v in %v", id, messages)
+	return nil
+}
+
+func errorCode(m map[string]any) float64 {
+	rpcErr, _ := m["error"].(map[string]any)
+	code, _ := rpcErr["code"].(float64)
+	return code
+}
+
+const initialize = `{"jsonrpc":"2.0","id":0,"method":"initialize","params":{"protocol_version":"1.1","client":{"name":"test"}}}`
+
+func TestServer_Initialize(t *testing.T) {
+	messages := serve(t, newTestServer(t),
+		`{"jsonrpc":"2.0","id":1,"method":"styles/list"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"initialize","params":{"protocol_version":"2.0"}}`,
+		initialize,
+		`{"jsonrpc":"2.0","id":3,"method":"styles/list"}`,
+	)
+
+	if code := errorCode(response(t, messages, 1)); code != CodeNotInitialized {
+		t.Errorf("call before initialize: code = %v, want %d", code, CodeNotInitialized)
+	}
+	if code := errorCode(response(t, messages, 2)); code != CodeUnsupportedVersion {
+		t.Errorf("initialize with 2.0: code = %v, want %d", code, CodeUnsupportedVersion)
+	}
+
+	result, _ := response(t, messages, 0)["result"].(map[string]any)
+	if result["protocol_version"] != ProtocolVersion {
+		t.Errorf("protocol_version = %v, want %s", result["protocol_version"], ProtocolVersion)
+	}
+	if server, _ := result["server"].(map[string]any); server["version"] != "1.2.3" {
+		t.Errorf("server = %v, want version 1.2.3", result["server"])
+	}
+	capabilities, _ := result["capabilities"].(map[string]any)
+	if partial, _ := capabilities["partial_output"].(map[string]any); partial["generate"] != false || partial["regenerate"] != true {
+		t.Errorf("capabilities = %v, want partial output only from regenerate", capabilities)
+	}
+	if _, ok := response(t, messages, 3)["result"]; !ok {
+		t.Error("styles/list should succeed after initialize")
+	}
+}
+
+func TestServer_Generate(t *testing.T) {
+	repo := newTestRepo(t, "add rpc mode")
+	messages := serve(t, newTestServer(t),
+		initialize,
+		fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"generate","params":{"repo_path":%q,"style":"gitmoji","stream_token":"t1"}}`, repo),
+		fmt.Sprintf(`{"jsonrpc":"2.0","id":2,"method":"generate","params":{"repo_path":%q,"source":"head"}}`, repo),
+		`{"jsonrpc":"2.0","id":3,"method":"generate","params":{}}`,
+	)
+
+	result, _ := response(t, messages, 1)["result"].(map[string]any)
+	if result["message"] != "feat(gitmoji): add rpc mode" || result["style"] != "gitmoji" || result["profile"] != "work" {
+		t.Errorf("generate result = %v", result)
+	}
+	if commit, _ := result["commit"].(map[string]any); commit["scope"] != "gitmoji" {
+		t.Errorf("commit = %v, want the parsed message", result["commit"])
+	}
+	if usage, _ := result["usage"].(map[string]any); usage["prompt_tokens"] != float64(10) {
+		t.Errorf("usage = %v, want the tokens of the request", result["usage"])
+	}
+
+	var streamed []string
+	for _, m := range messages {
+		if m["method"] == ProgressMethod {
+			params, _ := m["params"].(map[string]any)
+			if params["token"] != "t1" {
+				t.Errorf("progress token = %v, want t1", params["token"])
+			}
+			streamed = append(streamed, params["text"].(string))
+		}
+	}
+	if strings.Join(streamed, "") != "feat(gitmoji): add rpc mode" {
+		t.Errorf("streamed %q, want the message", streamed)
+	}
+
+	for _, id := range []float64{2, 3} {
+		if code := errorCode(response(t, messages, id)); code != -32602 {
+			t.Errorf("request %v: code = %v, want invalid params", id, code)
+		}
+	}
+}
+
+func TestServer_GenerateReportsErrorClass(t *testing.T) {
+	repo := newTestRepo(t, "denied")
+	messages := serve(t, newTestServer(t),
+		initialize,
+		fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"generate","params":{"repo_path":%q}}`, repo),
+	)
+
+	rpcErr, _ := response(t, messages, 1)["error"].(map[string]any)
+	if rpcErr["code"] != float64(CodeRequestFailed) {
+		t.Fatalf("error = %v, want code %d", rpcErr, CodeRequestFailed)
+	}
+	if data, _ := rpcErr["data"].(map[string]any); data["class"] != "auth" {
+		t.Errorf("error data = %v, want class auth", rpcErr["data"])
+	}
+}
+
+func TestServer_Regenerate(t *testing.T) {
+	repo := newTestRepo(t, "add rpc mode")
+	messages := serve(t, newTestServer(t),
+		initialize,
+		fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"regenerate","params":{"repo_path":%q,"message":"feat: old message\n# comment","hint":"shorter","stream_token":"t2"}}`, repo),
+		fmt.Sprintf(`{"jsonrpc":"2.0","id":2,"method":"regenerate","params":{"repo_path":%q}}`, repo),
+	)
+
+	result, _ := response(t, messages, 1)["result"].(map[string]any)
+	if result["message"] != "fix: shorter" {
+		t.Errorf("message = %v, want the streamed message without its code fence", result["message"])
+	}
+	if usage, _ := result["usage"].(map[string]any); usage["prompt_tokens"] != float64(20) {
+		t.Errorf("usage = %v, want the tokens of the request", result["usage"])
+	}
+
+	var deltas int
+	for _, m := range messages {
+		if m["method"] == ProgressMethod {
+			deltas++
+		}
+	}
+	if deltas != 2 {
+		t.Errorf("got %d progress notifications, want one per streamed piece", deltas)
+	}
+	if code := errorCode(response(t, messages, 2)); code != -32602 {
+		t.Errorf("regenerate without a hint: code = %v, want invalid params", code)
+	}
+}
+
+func TestServer_LintAndLists(t *testing.T) {
+	messages := serve(t, newTestServer(t),
+		initialize,
+		`{"jsonrpc":"2.0","id":1,"method":"lint","params":{"message":"bad message"}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"lint","params":{"message":"feat: add rpc"}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"styles/list"}`,
+		`{"jsonrpc":"2.0","id":4,"method":"profiles/list","params":{"profile":"oss"}}`,
+	)
+
+	if result, _ := response(t, messages, 1)["result"].(map[string]any); result["valid"] != false {
+		t.Errorf("lint of a bad message = %v, want invalid", result)
+	}
+	if result, _ := response(t, messages, 2)["result"].(map[string]any); result["valid"] != true {
+		t.Errorf("lint of a conventional message = %v, want valid", result)
+	}
+
+	result, _ := response(t, messages, 3)["result"].(map[string]any)
+	styles, _ := result["styles"].([]any)
+	var defaults []string
+	for _, s := range styles {
+		style := s.(map[string]any)
+		if style["default"] == true {
+			defaults = append(defaults, style["name"].(string))
+		}
+	}
+	if len(styles) < 2 || len(defaults) != 1 || defaults[0] != "conventional" {
+		t.Errorf("styles = %v, want conventional as the only default", styles)
+	}
+
+	result, _ = response(t, messages, 4)["result"].(map[string]any)
+	if result["active"] != "oss" {
+		t.Errorf("active profile = %v, want oss", result["active"])
+	}
+	if profiles, _ := result["profiles"].([]any); len(profiles) != 2 {
+		t.Errorf("profiles = %v, want oss and work", result["profiles"])
+	}
+}
@@ -0,0 +1,10 @@
+// Package shell quotes values for the scripts and command lines muse hands
+// to a POSIX shell or to tools that split their input like one.
+package shell
+
+import "strings"
+
+// Quote quotes s as a single shell word
+func Quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
@@ -0,0 +1,18 @@
+package shell
+
+import (
+	"os/exec"
+	"testing"
+)
+
+func TestQuote(t *testing.T) {
+	for _, s := range []string{"", "plain", "two words", "it's", `$HOME "x" \n`, "'"} {
+		output, err := exec.Command("sh", "-c", "printf %s "+Quote(s)).Output()
+		if err != nil {
+			t.Fatalf("sh failed for %q: %v", s, err)
+		}
+		if string(output) != s {
+			t.Errorf("sh read Quote(%q) as %q", s, output)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+package split
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/klauern/muse/internal/diff"
+	"github.com/klauern/muse/internal/git"
+	"github.com/klauern/muse/llm"
+	"github.com/klauern/muse/templates"
+)
+
+// maxHunkLines bounds how much of each hunk is shown when clustering
+const maxHunkLines = 60
+
+// Unit is the smallest change a group can take: a hunk, or a whole file for
+// changes without hunks such as binary files, and for renames and mode
+// changes, whose header can only be applied once
+type Unit struct {
+	ID   string
+	File *diff.File
+	// Hunk indexes File.Hunks, or is -1 for the whole file
+	Hunk int
+}
+
+// Group is a proposed commit
+type Group struct {
+	Summary string   `json:"summary"`
+	Units   []string `json:"hunks"`
+	// Message is the generated commit message
+	Message string `json:"-"`
+}
+
+// Plan splits a staged diff into groups of units
+type Plan struct {
+	Files  []*diff.File
+	Units  []Unit
+	Groups []Group
+}
+
+// NewPlan breaks a staged patch into units, all in one group
+func NewPlan(patch string) (*Plan, error) {
+	p := &Plan{Files: diff.Parse(patch)}
+	if len(p.Files) == 0 {
+		return nil, fmt.Errorf("nothing is staged")
+	}
+
+	all := Group{Summary: "All staged changes"}
+	for _, f := range p.Files {
+		if len(f.Hunks) == 0 || f.ChangesMetadata() {
+			p.addUnit(f, -1)
+			continue
+		}
+		for i := range f.Hunks {
+			p.addUnit(f, i)
+		}
+	}
+	for _, u := range p.Units {
+		all.Units = append(all.Units, u.ID)
+	}
+	p.Groups = []Group{all}
+	return p, nil
+}
+
+func (p *Plan) addUnit(f *diff.File, hunk int) {
+	p.Units = append(p.Units, Unit{ID: fmt.Sprintf("H%d", len(p.Units)+1), File: f, Hunk: hunk})
+}
+
+// Cluster asks service to group the units into commits
+func (p *Plan) Cluster(ctx context.Context, service llm.LLMService) error {
+	if len(p.Units) < 2 {
+		return nil
+	}
+
+	response, err := llm.CompletePrompt(ctx, service, "split", map[string]any{"Changes": p.describeUnits()})
+	if err != nil {
+		return err
+	}
+	groups, err := parseGroups(response)
+	if err != nil {
+		return err
+	}
+	p.Groups = p.normalize(groups)
+	return nil
+}
+
+// describeUnits lists the units with their IDs for the clustering prompt
+func (p *Plan) describeUnits() string {
+	var b strings.Builder
+	for _, u := range p.Units {
+		fmt.Fprintf(&b, "%s: %s\n", u.ID, u.File.Path())
+		if u.Hunk >= 0 {
+			writeHunk(&b, u.File.Hunks[u.Hunk])
+			continue
+		}
+
+		for _, line := range u.File.Header[1:] {
+			if strings.HasPrefix(line, "GIT binary patch") {
+				b.WriteString("binary content changed\n")
+				break
+			}
+			if !strings.HasPrefix(line, "index ") {
+				b.WriteString(line + "\n")
+			}
+		}
+		b.WriteString("\n")
+		for _, hunk := range u.File.Hunks {
+			writeHunk(&b, hunk)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// writeHunk writes hunk as a fenced diff, cut at maxHunkLines
+func writeHunk(b *strings.Builder, hunk diff.Hunk) {
+	b.WriteString("```diff\n" + hunk.Header + "\n")
+	lines := hunk.Lines
+	if len(lines) > maxHunkLines {
+		lines = lines[:maxHunkLines]
+	}
+	for _, line := range lines {
+		b.WriteString(line + "\n")
+	}
+	if len(hunk.Lines) > maxHunkLines {
+		fmt.Fprintf(b, "... %d more lines\n", len(hunk.Lines)-maxHunkLines)
+	}
+	b.WriteString("```\n\n")
+}
+
+// parseGroups reads the JSON object in a response, ignoring any text or
+// code fence around it
+func parseGroups(response string) ([]Group, error) {
+	start := strings.Index(response, "{")
+	end := strings.LastIndex(response, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("the response did not contain JSON: %q", response)
+	}
+
+	var result struct {
+		Groups []Group `json:"groups"`
+	}
+	if err := json.Unmarshal([]byte(response[start:end+1]), &result); err != nil {
+		return nil, fmt.Errorf("failed to parse the proposed groups: %w", err)
+	}
+	return result.Groups, nil
+}
+
+// normalize drops unknown, repeated and empty entries, and collects units
+// the model left out into a final group so that nothing staged is lost
+func (p *Plan) normalize(groups []Group) []Group {
+	known := map[string]bool{}
+	for _, u := range p.Units {
+		known[u.ID] = true
+	}
+
+	assigned := map[string]bool{}
+	var result []Group
+	for _, g := range groups {
+		var units []string
+		for _, id := range g.Units {
+			id = strings.ToUpper(strings.TrimSpace(id))
+			if known[id] && !assigned[id] {
+				assigned[id] = true
+				units = append(units, id)
+			}
+		}
+		if len(units) > 0 {
+			result = append(result, Group{Summary: g.Summary, Units: units})
+		}
+	}
+
+	var rest []string
+	for _, u := range p.Units {
+		if !assigned[u.ID] {
+			rest = append(rest, u.ID)
+		}
+	}
+	if len(rest) > 0 {
+		result = append(result, Group{Summary: "Remaining changes", Units: rest})
+	}
+	return result
+}
+
+// Patch renders the changes of g as a patch for git apply, keeping the
+// order of the staged diff
+func (p *Plan) Patch(g Group) string {
+	selected := map[string]bool{}
+	for _, id := range g.Units {
+		selected[id] = true
+	}
+
+	var b strings.Builder
+	for _, f := range p.Files {
+		var hunks []int
+		whole := false
+		for _, u := range p.Units {
+			if u.File != f || !selected[u.ID] {
+				continue
+			}
+			if u.Hunk < 0 {
+				whole = true
+			} else {
+				hunks = append(hunks, u.Hunk)
+			}
+		}
+		switch {
+		case whole:
+			b.WriteString(f.Patch(nil))
+		case len(hunks) > 0:
+			b.WriteString(f.Patch(hunks))
+		}
+	}
+	return b.String()
+}
+
+// Paths returns the files the group changes
+func (p *Plan) Paths(g Group) []string {
+	var paths []string
+	seen := map[string]bool{}
+	for _, id := range g.Units {
+		for _, u := range p.Units {
+			if u.ID == id && !seen[u.File.Path()] {
+				seen[u.File.Path()] = true
+				paths = append(paths, u.File.Path())
+			}
+		}
+	}
+	return paths
+}
+
+// Generator writes a commit message for a diff
+type Generator interface {
+	Generate(ctx context.Context, diff string, style templates.CommitStyle) (string, error)
+}
+
+// Describe generates a commit message for each group from its patch.
+// progress is called before each group.
+func (p *Plan) Describe(ctx context.Context, generator Generator, style templates.CommitStyle, progress func(i int, g Group)) error {
+	for i := range p.Groups {
+		if progress != nil {
+			progress(i, p.Groups[i])
+		}
+		message, err := generator.Generate(ctx, p.Patch(p.Groups[i]), style)
+		if err != nil {
+			return fmt.Errorf("failed to generate a message for group %d: %w", i+1, err)
+		}
+		p.Groups[i].Message = strings.TrimSpace(message)
+	}
+	return nil
+}
+
+// Session commits the groups of a plan one at a time. The index is reset
+// to HEAD when it starts, and holds whatever was not committed when it
+// finishes.
+type Session struct {
+	ops    *git.GitOperations
+	plan   *Plan
+	staged string
+}
+
+// Start records the staged tree and unstages everything
+func Start(ops *git.GitOperations, plan *Plan) (*Session, error) {
+	staged, err := ops.WriteIndexTree()
+	if err != nil {
+		return nil, err
+	}
+	if err := ops.ReadIndexTree("HEAD"); err != nil {
+		return nil, err
+	}
+	return &Session{ops: ops, plan: plan, staged: staged}, nil
+}
+
+// Commit stages the changes of g and commits them with message. ctx bounds
+// the commit hooks.
+func (s *Session) Commit(ctx context.Context, g Group, message string) (string, error) {
+	if err := s.ops.ApplyToIndex(s.plan.Patch(g), false); err != nil {
+		return "", err
+	}
+	hash, err := s.ops.Commit(ctx, strings.TrimSpace(message)+"\n")
+	if err != nil {
+		// Leave the index as it was for the next group
+		if resetErr := s.ops.ReadIndexTree("HEAD"); resetErr != nil {
+			return "", fmt.Errorf("%w; additionally failed to reset the index: %v", err, resetErr)
+		}
+		return "", err
+	}
+	return hash, nil
+}
+
+// Finish restages the changes that were not committed. Since committed
+// groups are part of HEAD, restoring the original staged tree leaves
+// exactly the rest staged.
+func (s *Session) Finish() error {
+	if err := s.ops.ReadIndexTree(s.staged); err != nil {
+		return fmt.Errorf("%w; restore it with: git read-tree %s", err, s.staged)
+	}
+	return nil
+}
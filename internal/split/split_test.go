@@ -0,0 +1,224 @@
+package split
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/klauern/muse/internal/git"
+	"github.com/klauern/muse/internal/gittest"
+	"github.com/klauern/muse/templates"
+)
+
+// fakeService answers the clustering prompt with response and describes
+// each group by the files in its patch
+type fakeService struct {
+	prompt   string
+	response string
+}
+
+func (f *fakeService) GenerateCommitMessage(ctx context.Context, diff string, style templates.CommitStyle) (string, error) {
+	var files []string
+	for _, line := range strings.Split(diff, "\n") {
+		if name, ok := strings.CutPrefix(line, "+++ b/"); ok {
+			files = append(files, name)
+		}
+	}
+	return "chore: update " + strings.Join(files, ", "), nil
+}
+
+func (f *fakeService) Generate(ctx context.Context, diff string, style templates.CommitStyle) (string, error) {
+	return f.GenerateCommitMessage(ctx, diff, style)
+}
+
+func (f *fakeService) Complete(ctx context.Context, prompt string) (string, error) {
+	f.prompt = prompt
+	return f.response, nil
+}
+
+// newTestRepo commits a.txt with ten lines and stages a change at each end
+// of it plus a new b.txt
+func newTestRepo(t *testing.T) (*git.GitOperations, func(args ...string) string) {
+	t.Helper()
+	repo := gittest.New(t)
+	// Split commits through GitOperations, which does not see repo.Env
+	repo.Git("config", "user.name", "Test")
+	repo.Git("config", "user.email", "test@example.com")
+	write := func(name string, lines ...string) {
+		repo.Write(name, strings.Join(lines, "\n")+"\n")
+	}
+
+	lines := []string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10"}
+	write("a.txt", lines...)
+	repo.Commit("initial")
+
+	write("a.txt", append(append([]string{"top"}, lines...), "bottom")...)
+	write("b.txt", "new")
+	repo.Git("add", "a.txt", "b.txt")
+
+	ops, err := git.NewGitOperations(repo.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ops, repo.Git
+}
+
+func newTestPlan(t *testing.T, ops *git.GitOperations, response string) (*Plan, *fakeService) {
+	t.Helper()
+	patch, err := ops.GetStagedPatch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := NewPlan(patch)
+	if err != nil {
+		t.Fatalf("NewPlan() error = %v", err)
+	}
+	service := &fakeService{response: response}
+	if err := plan.Cluster(context.Background(), service); err != nil {
+		t.Fatalf("Cluster() error = %v", err)
+	}
+	return plan, service
+}
+
+func TestPlan_Cluster(t *testing.T) {
+	ops, _ := newTestRepo(t)
+	plan, service := newTestPlan(t, ops, "```json\n"+`{"groups": [
+		{"summary": "top and new file", "hunks": ["H1", "h3", "H9"]},
+		{"summary": "duplicate", "hunks": ["H1"]}
+	]}`+"\n```")
+
+	if len(plan.Units) != 3 {
+		t.Fatalf("units = %+v, want the two hunks of a.txt and b.txt", plan.Units)
+	}
+	for _, want := range []string{"H1: a.txt", "+top", "H2: a.txt", "+bottom", "H3: b.txt", "+new"} {
+		if !strings.Contains(service.prompt, want) {
+			t.Errorf("prompt does not contain %q:\n%s", want, service.prompt)
+		}
+	}
+
+	if len(plan.Groups) != 2 {
+		t.Fatalf("groups = %+v, want the proposed group and the remaining one", plan.Groups)
+	}
+	if got := strings.Join(plan.Groups[0].Units, ","); got != "H1,H3" {
+		t.Errorf("first group units = %s", got)
+	}
+	if plan.Groups[1].Summary != "Remaining changes" || strings.Join(plan.Groups[1].Units, ",") != "H2" {
+		t.Errorf("remaining group = %+v", plan.Groups[1])
+	}
+	if got := strings.Join(plan.Paths(plan.Groups[0]), ","); got != "a.txt,b.txt" {
+		t.Errorf("Paths() = %s", got)
+	}
+
+	patch := plan.Patch(plan.Groups[0])
+	if !strings.Contains(patch, "+top") || strings.Contains(patch, "+bottom") || !strings.Contains(patch, "+++ b/b.txt") {
+		t.Errorf("Patch() =\n%s", patch)
+	}
+
+	if err := plan.Describe(context.Background(), service, "conventional", nil); err != nil {
+		t.Fatalf("Describe() error = %v", err)
+	}
+	if plan.Groups[0].Message != "chore: update a.txt, b.txt" || plan.Groups[1].Message != "chore: update a.txt" {
+		t.Errorf("messages = %q, %q", plan.Groups[0].Message, plan.Groups[1].Message)
+	}
+}
+
+func TestPlan_ClusterInvalidResponse(t *testing.T) {
+	ops, _ := newTestRepo(t)
+	patch, _ := ops.GetStagedPatch()
+	plan, _ := NewPlan(patch)
+	if err := plan.Cluster(context.Background(), &fakeService{response: "I cannot do that"}); err == nil {
+		t.Error("Cluster() succeeded without JSON in the response")
+	}
+	if len(plan.Groups) != 1 || len(plan.Groups[0].Units) != 3 {
+		t.Errorf("groups after a failed Cluster() = %+v, want everything in one group", plan.Groups)
+	}
+
+	if _, err := NewPlan(""); err == nil {
+		t.Error("NewPlan() succeeded with nothing staged")
+	}
+}
+
+func TestSession(t *testing.T) {
+	ops, run := newTestRepo(t)
+	plan, _ := newTestPlan(t, ops, `{"groups": [{"summary": "bottom", "hunks": ["H2"]}, {"summary": "top", "hunks": ["H1"]}, {"summary": "b", "hunks": ["H3"]}]}`)
+
+	session, err := Start(ops, plan)
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if got := run("diff", "--cached", "--name-only"); got != "" {
+		t.Errorf("staged after Start() = %q, want nothing", got)
+	}
+
+	// Commit the later hunk of a.txt before the earlier one, and skip b.txt
+	if _, err := session.Commit(context.Background(), plan.Groups[0], "feat: add bottom"); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	if _, err := session.Commit(context.Background(), plan.Groups[1], "feat: add top"); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	if err := session.Finish(); err != nil {
+		t.Fatalf("Finish() error = %v", err)
+	}
+
+	if got := run("log", "--format=%s"); got != "feat: add top\nfeat: add bottom\ninitial" {
+		t.Errorf("log = %q", got)
+	}
+	if got := run("show", "--format=", "--stat", "HEAD~1"); !strings.Contains(got, "a.txt | 1 +") {
+		t.Errorf("first split commit = %q, want one line of a.txt", got)
+	}
+	if got := run("diff", "--cached", "--name-only"); got != "b.txt" {
+		t.Errorf("staged after Finish() = %q, want the skipped b.txt", got)
+	}
+	if got := run("status", "--porcelain"); got != "A  b.txt" {
+		t.Errorf("status after Finish() = %q, want a clean working tree apart from b.txt", got)
+	}
+}
+
+func TestNewPlan_KeepsRenamesWhole(t *testing.T) {
+	patch := `diff --git a/old.txt b/new.txt
+old mode 100644
+new mode 100755
+similarity index 80%
+rename from old.txt
+rename to new.txt
+index 1111111..2222222
+--- a/old.txt
++++ b/new.txt
+@@ -1,2 +1,2 @@
+-1
++one
+ 2
+@@ -9,2 +9,2 @@
+ 9
+-10
++ten
+diff --git a/c.txt b/c.txt
+index 3333333..4444444 100644
+--- a/c.txt
++++ b/c.txt
+@@ -1 +1 @@
+-c
++C
+@@ -9 +9 @@
+-d
++D
+`
+	plan, err := NewPlan(patch)
+	if err != nil {
+		t.Fatalf("NewPlan() error = %v", err)
+	}
+	var got []string
+	for _, u := range plan.Units {
+		got = append(got, fmt.Sprintf("%s:%d", u.File.Path(), u.Hunk))
+	}
+	// Both hunks of the renamed file have to be applied with its header
+	want := []string{"new.txt:-1", "c.txt:0", "c.txt:1"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("units = %v, want %v", got, want)
+	}
+	if description := plan.describeUnits(); !strings.Contains(description, "rename from old.txt") || !strings.Contains(description, "+ten") {
+		t.Errorf("describeUnits() leaves out the rename or its hunks:\n%s", description)
+	}
+}
@@ -3,12 +3,17 @@ package userinput
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
 	"time"
 )
 
+// ErrRejected is returned when the user declines to go ahead, such as when
+// rejecting a generated commit message
+var ErrRejected = errors.New("rejected by the user")
+
 // SecureInputHandler provides secure user input handling with timeout and validation
 type SecureInputHandler struct {
 	timeout time.Duration
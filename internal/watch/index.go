@@ -0,0 +1,76 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultDebounce is how long the index must stay unchanged before a change
+// is reported
+const DefaultDebounce = 1500 * time.Millisecond
+
+// IndexWatcher reports changes to a repository's index. Git replaces the
+// index by renaming index.lock over it, so the .git directory is watched
+// rather than the file itself.
+type IndexWatcher struct {
+	GitDir   string
+	Debounce time.Duration
+	// OnChange is called once the index has settled after one or more
+	// changes. Calls never overlap.
+	OnChange func(ctx context.Context)
+}
+
+// Run watches the index until ctx is done. OnChange is also called once at
+// startup so changes staged before the watcher started are picked up.
+func (w *IndexWatcher) Run(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	if err := watcher.Add(w.GitDir); err != nil {
+		return fmt.Errorf("failed to watch %s: %w", w.GitDir, err)
+	}
+
+	debounce := w.Debounce
+	if debounce <= 0 {
+		debounce = DefaultDebounce
+	}
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if filepath.Base(event.Name) != "index" || event.Op == fsnotify.Chmod {
+				continue
+			}
+			slog.Debug("Index changed", "op", event.Op.String())
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+			timer.Reset(debounce)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			slog.Debug("Index watcher error", "error", err)
+		case <-timer.C:
+			w.OnChange(ctx)
+		}
+	}
+}
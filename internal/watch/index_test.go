@@ -0,0 +1,75 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestIndexWatcher_DebouncesChanges(t *testing.T) {
+	gitDir := t.TempDir()
+	var calls atomic.Int32
+	changed := make(chan struct{}, 10)
+
+	w := &IndexWatcher{
+		GitDir:   gitDir,
+		Debounce: 100 * time.Millisecond,
+		OnChange: func(ctx context.Context) {
+			calls.Add(1)
+			changed <- struct{}{}
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- w.Run(ctx) }()
+
+	// The initial call picks up changes staged before startup
+	waitForCall(t, changed)
+
+	// A burst of writes, including git's lock-and-rename, is reported once
+	for i := 0; i < 5; i++ {
+		lock := filepath.Join(gitDir, "index.lock")
+		if err := os.WriteFile(lock, []byte{byte(i)}, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Rename(lock, filepath.Join(gitDir, "index")); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	waitForCall(t, changed)
+
+	// Files other than the index are ignored
+	if err := os.WriteFile(filepath.Join(gitDir, "HEAD"), []byte("ref"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(300 * time.Millisecond)
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("OnChange called %d times, want 2", got)
+	}
+}
+
+func TestIndexWatcher_MissingDir(t *testing.T) {
+	w := &IndexWatcher{GitDir: filepath.Join(t.TempDir(), "missing"), OnChange: func(context.Context) {}}
+	if err := w.Run(context.Background()); err == nil {
+		t.Error("Run() succeeded for a missing directory")
+	}
+}
+
+func waitForCall(t *testing.T, changed <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-changed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("OnChange was not called")
+	}
+}
@@ -0,0 +1,38 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/openai/openai-go"
+)
+
+var (
+	// ErrAuth is returned when the provider rejects the credentials
+	ErrAuth = errors.New("provider authentication failed")
+	// ErrRateLimit is returned when the provider rate limits requests or
+	// the account is out of quota
+	ErrRateLimit = errors.New("provider rate limit exceeded")
+)
+
+// providerError marks err with ErrAuth or ErrRateLimit when it is an API
+// error with a status that says so
+func providerError(err error) error {
+	var apiErr *openai.Error
+	if errors.As(err, &apiErr) {
+		return withStatus(apiErr.StatusCode, err)
+	}
+	return err
+}
+
+// withStatus marks err by the HTTP status of the response that caused it
+func withStatus(status int, err error) error {
+	switch status {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("%w: %w", ErrAuth, err)
+	case http.StatusTooManyRequests:
+		return fmt.Errorf("%w: %w", ErrRateLimit, err)
+	}
+	return err
+}
@@ -0,0 +1,25 @@
+package llm
+
+import (
+	"context"
+
+	"github.com/klauern/muse/templates"
+)
+
+type examplesKey struct{}
+
+// WithExamples returns a context in which commit messages are generated
+// with examples of how the user edited earlier messages
+func WithExamples(ctx context.Context, examples []templates.Example) context.Context {
+	if len(examples) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, examplesKey{}, examples)
+}
+
+// ExamplesFrom returns the examples set with WithExamples, if any.
+// Providers pass them to the style template.
+func ExamplesFrom(ctx context.Context) []templates.Example {
+	examples, _ := ctx.Value(examplesKey{}).([]templates.Example)
+	return examples
+}
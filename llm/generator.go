@@ -2,11 +2,15 @@ package llm
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"sync"
 	"time"
 
 	"github.com/klauern/muse/config"
+	"github.com/klauern/muse/internal/cache"
 	"github.com/klauern/muse/templates"
 )
 
@@ -16,6 +20,16 @@ type Generator interface {
 
 type CommitMessageGenerator struct {
 	LLMService LLMService
+	// Cache, when set, returns the earlier message for an identical diff,
+	// style, template and model instead of calling the provider again
+	Cache    *cache.Cache
+	Provider string
+	Model    string
+
+	// llmConfig, when set, creates LLMService on the first cache miss, so a
+	// cache hit does not pay for credential lookups
+	llmConfig *config.LLMConfig
+	mu        sync.Mutex
 }
 
 func NewCommitMessageGenerator(cfg *config.Config) (*CommitMessageGenerator, error) {
@@ -23,41 +37,140 @@ func NewCommitMessageGenerator(cfg *config.Config) (*CommitMessageGenerator, err
 		return nil, fmt.Errorf("config is nil")
 	}
 
+	generator := &CommitMessageGenerator{Provider: cfg.LLM.Provider}
+	generator.Model, _ = cfg.LLM.Config["model"].(string)
+
+	if cfg.Cache.Enabled {
+		responseCache, err := cache.FromConfig(cfg.Cache)
+		if err != nil {
+			slog.Warn("Response cache disabled", "error", err)
+		} else {
+			generator.Cache = responseCache
+			generator.llmConfig = &cfg.LLM
+			return generator, nil
+		}
+	}
+
 	llmService, err := NewLLMService(&cfg.LLM)
 	if err != nil {
 		slog.Error("Failed to create LLM service", "error", err)
 		return nil, fmt.Errorf("failed to create LLM service: %w", err)
 	}
+	generator.LLMService = llmService
 
-	return &CommitMessageGenerator{
-		LLMService: llmService,
-	}, nil
+	return generator, nil
 }
 
 func (g *CommitMessageGenerator) Generate(ctx context.Context, diff string, commitStyle templates.CommitStyle) (string, error) {
 	slog.Debug("Generating commit message")
 
+	key := g.cacheKey(ctx, diff, commitStyle)
+	if key != "" {
+		if entry, ok := g.Cache.Get(key); ok {
+			slog.Info("Using cached commit message", "created_at", entry.CreatedAt)
+			RecordUsage(ctx, Usage{Path: "cache"})
+			return entry.Message, nil
+		}
+		// 'muse watch' may already be generating this message
+		if entry, ok := g.Cache.WaitPending(ctx, key); ok {
+			slog.Info("Using pre-generated commit message", "created_at", entry.CreatedAt)
+			RecordUsage(ctx, Usage{Path: "cache"})
+			return entry.Message, nil
+		}
+		release := g.Cache.MarkPending(key)
+		defer release()
+	}
+
+	llmService, err := g.Service()
+	if err != nil {
+		return "", err
+	}
+
 	maxRetries := 3
 	for i := 0; i < maxRetries; i++ {
 		slog.Debug("Attempting to generate commit message", "attempt", i+1)
-		message, err := g.LLMService.GenerateCommitMessage(ctx, diff, commitStyle)
+		message, err := llmService.GenerateCommitMessage(ctx, diff, commitStyle)
 		if err == nil {
 			slog.Debug("Successfully generated commit message", "message", message)
-			// Attempt to parse the JSON to ensure it's valid
+			if key != "" {
+				entry := cache.Entry{Message: message, Provider: g.Provider, Model: g.Model, Style: string(commitStyle)}
+				if err := g.Cache.Put(key, entry); err != nil {
+					slog.Warn("Failed to cache commit message", "error", err)
+				}
+			}
 			return message, nil
 		} else {
 			slog.Error("Failed to generate commit message", "error", err)
 		}
 
+		// Retrying cannot fix rejected credentials
+		if errors.Is(err, ErrAuth) {
+			return "", err
+		}
+
 		if i == maxRetries-1 {
 			slog.Error("Failed to generate valid commit message after %d attempts", "attempts", maxRetries)
 			return "", fmt.Errorf("failed to generate valid commit message after %d attempts: %w", maxRetries, err)
 		}
 
-		// Wait for a short duration before retrying
-		time.Sleep(time.Second * time.Duration(i+1))
+		// Wait for a short duration before retrying, unless the caller gives up
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(time.Second * time.Duration(i+1)):
+		}
+		RecordUsage(ctx, Usage{Retries: 1})
 	}
 
 	slog.Error("Unexpected error: should not reach this point")
 	return "", fmt.Errorf("unexpected error: should not reach this point")
 }
+
+// Service returns the LLM service, creating it on first use when the
+// generator was built with a cache
+func (g *CommitMessageGenerator) Service() (LLMService, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.LLMService == nil && g.llmConfig != nil {
+		llmService, err := NewLLMService(g.llmConfig)
+		if err != nil {
+			slog.Error("Failed to create LLM service", "error", err)
+			return nil, fmt.Errorf("failed to create LLM service: %w", err)
+		}
+		g.LLMService = llmService
+	}
+	if g.LLMService == nil {
+		return nil, fmt.Errorf("no LLM service configured")
+	}
+	return g.LLMService, nil
+}
+
+// cacheKey returns the cache key for a generation, or an empty string when
+// caching is disabled or the style template cannot be read. The examples in
+// ctx are part of the prompt, so they are part of the key.
+func (g *CommitMessageGenerator) cacheKey(ctx context.Context, diff string, commitStyle templates.CommitStyle) string {
+	if g.Cache == nil {
+		return ""
+	}
+	template, err := templates.ReadTemplateFile(fmt.Sprintf("styles/%s.tmpl", commitStyle))
+	if err != nil {
+		slog.Debug("Skipping response cache", "error", err)
+		return ""
+	}
+	var examples []byte
+	if e := ExamplesFrom(ctx); len(e) > 0 {
+		if examples, err = json.Marshal(e); err != nil {
+			slog.Debug("Skipping response cache", "error", err)
+			return ""
+		}
+	}
+	return cache.Key(cache.KeyParts{
+		Diff:     diff,
+		Style:    string(commitStyle),
+		Template: template,
+		Provider: g.Provider,
+		Model:    g.Model,
+		Examples: string(examples),
+	})
+}
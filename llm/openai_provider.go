@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -13,6 +14,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/invopop/jsonschema"
+	"github.com/klauern/muse/internal/credentials"
 	"github.com/klauern/muse/internal/security"
 	"github.com/klauern/muse/templates"
 	"github.com/openai/openai-go"
@@ -25,6 +28,21 @@ func init() {
 	RegisterProvider("openai", &OpenAIProvider{})
 }
 
+// OpenAIConfig describes the llm.config settings of the openai provider
+type OpenAIConfig struct {
+	Model            string `json:"model,omitempty" jsonschema_description:"Model used for generation, e.g. gpt-4o"`
+	APIKey           string `json:"api_key,omitempty" jsonschema_description:"API key; prefer api_key_cmd, api_key_file or 'muse auth login'"`
+	APIKeyCmd        string `json:"api_key_cmd,omitempty" jsonschema_description:"Command that prints the API key, e.g. 'pass show openai'"`
+	APIKeyCmdTimeout string `json:"api_key_cmd_timeout,omitempty" jsonschema:"pattern=^[0-9]+(\\.[0-9]+)?(ms|s|m)$" jsonschema_description:"Timeout for api_key_cmd, e.g. 5s (default 10s)"`
+	APIKeyFile       string `json:"api_key_file,omitempty" jsonschema_description:"File containing the API key; must not be world-readable"`
+	APIBase          string `json:"api_base,omitempty" jsonschema:"format=uri" jsonschema_description:"Base URL of an OpenAI-compatible API; falls back to OPENAI_API_BASE"`
+}
+
+// ConfigSchema implements ConfigSchemaProvider
+func (p *OpenAIProvider) ConfigSchema() *jsonschema.Schema {
+	return ReflectSchema(&OpenAIConfig{})
+}
+
 type OpenAIService struct {
 	client  *openai.Client
 	model   string
@@ -33,15 +51,15 @@ type OpenAIService struct {
 }
 
 func (p *OpenAIProvider) NewService(cfg map[string]any) (LLMService, error) {
-	// Try to get API key from config, then environment variables
-	apiKey, _ := cfg["api_key"].(string)
-	if apiKey == "" {
-		apiKey = os.Getenv("OPENAI_API_KEY")
-		if apiKey == "" {
-			slog.Error("OpenAI API key not set in config or OPENAI_API_KEY environment variable")
-			return nil, fmt.Errorf("openai api key not set")
-		}
+	// Resolve the API key from config, a secret command or file, the
+	// environment or the OS keyring
+	credential, err := credentials.Resolve(context.Background(), "openai", cfg)
+	if err != nil {
+		slog.Error("Failed to resolve OpenAI API key", "error", err)
+		return nil, fmt.Errorf("openai api key not set: %w", err)
 	}
+	apiKey := credential.Key
+	slog.Debug("Using OpenAI API key", "source", credential.Source)
 
 	// Validate the API key
 	if err := security.ValidateCredential(apiKey); err != nil {
@@ -60,7 +78,9 @@ func (p *OpenAIProvider) NewService(cfg map[string]any) (LLMService, error) {
 		}
 		slog.Debug("Using API base from environment or default", "api_base", apiBase)
 	}
-	options = append(options, option.WithBaseURL(apiBase))
+	// The SDK resolves request paths relative to the base URL, so without a
+	// trailing slash the last path segment (e.g. /v1) would be dropped
+	options = append(options, option.WithBaseURL(strings.TrimSuffix(apiBase, "/")+"/"))
 
 	client := openai.NewClient(options...)
 
@@ -82,6 +102,7 @@ func (p *OpenAIProvider) NewService(cfg map[string]any) (LLMService, error) {
 
 func (s *OpenAIService) GenerateCommitMessage(ctx context.Context, diff string, style templates.CommitStyle) (string, error) {
 	templateManager := templates.NewTemplateManager(diff, style)
+	templateManager.SetExamples(ExamplesFrom(ctx))
 
 	commitTemplate, err := templateManager.CompileTemplate(style)
 	if err != nil {
@@ -100,36 +121,175 @@ func (s *OpenAIService) GenerateCommitMessage(ctx context.Context, diff string,
 	// Use raw HTTP for gpt-4.1 to handle API gateway content-type issues
 	if s.model == "gpt-4.1" {
 		slog.Debug("Using raw HTTP client for gpt-4.1 due to API gateway compatibility")
-		return s.generateWithRawHTTP(ctx, commitTemplate, templateManager)
+		return s.rawHTTPAfter(ctx, nil, commitTemplate, templateManager)
 	}
 
+	// path lists the methods tried, for the usage ledger
+	var path []string
+
 	// Try structured outputs first for compatible models, but fall back on error
 	if s.supportsStructuredOutputs() {
+		path = append(path, "structured")
 		result, err := s.generateWithStructuredOutputs(ctx, commitTemplate, templateManager)
 		if err == nil {
+			recordPath(ctx, path)
 			return result, nil
 		}
 		// Check if this is a content-type issue that requires raw HTTP
 		if s.isContentTypeError(err) {
 			slog.Warn("Structured outputs failed due to content-type issue, falling back to raw HTTP", "error", err)
-			return s.generateWithRawHTTP(ctx, commitTemplate, templateManager)
+			return s.rawHTTPAfter(ctx, path, commitTemplate, templateManager)
 		}
 		slog.Warn("Structured outputs failed, falling back to regular completion", "error", err)
 	}
 
 	// Fallback to regular chat completion
+	path = append(path, "completion")
 	result, err := s.generateWithRegularCompletion(ctx, commitTemplate, templateManager)
 	if err != nil {
 		// Check if this is a content-type issue that requires raw HTTP
 		if s.isContentTypeError(err) {
 			slog.Warn("Regular completion failed due to content-type issue, falling back to raw HTTP", "error", err)
-			return s.generateWithRawHTTP(ctx, commitTemplate, templateManager)
+			return s.rawHTTPAfter(ctx, path, commitTemplate, templateManager)
 		}
-		return "", err
+		return "", providerError(err)
 	}
+	recordPath(ctx, path)
 	return result, nil
 }
 
+// rawHTTPAfter generates with raw HTTP once the methods in path have failed
+func (s *OpenAIService) rawHTTPAfter(ctx context.Context, path []string, commitTemplate templates.CommitTemplate, templateManager *templates.TemplateManager) (string, error) {
+	result, err := s.generateWithRawHTTP(ctx, commitTemplate, templateManager)
+	if err == nil {
+		recordPath(ctx, append(path, "raw_http"))
+	}
+	return result, err
+}
+
+// recordPath records the methods that produced a message, in the order they
+// were tried
+func recordPath(ctx context.Context, path []string) {
+	RecordUsage(ctx, Usage{Path: strings.Join(path, ">")})
+}
+
+// Ping checks that the API is reachable and the configured model is available
+func (s *OpenAIService) Ping(ctx context.Context) error {
+	if _, err := s.client.Models.Get(ctx, s.model); err != nil {
+		return providerError(fmt.Errorf("failed to get model %s: %w", s.model, err))
+	}
+	return nil
+}
+
+// Complete implements Completer
+func (s *OpenAIService) Complete(ctx context.Context, prompt string) (string, error) {
+	chat, err := s.client.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
+		Messages: openai.F([]openai.ChatCompletionMessageParamUnion{
+			openai.UserMessage(prompt),
+		}),
+		Model: openai.F(s.model),
+	})
+	if err != nil {
+		return "", providerError(fmt.Errorf("failed to create chat completion: %w", err))
+	}
+	recordChatUsage(ctx, chat)
+	if len(chat.Choices) == 0 {
+		return "", fmt.Errorf("chat completion returned no choices")
+	}
+	return strings.TrimSpace(chat.Choices[0].Message.Content), nil
+}
+
+// CompleteStream implements StreamCompleter
+func (s *OpenAIService) CompleteStream(ctx context.Context, prompt string, onDelta func(string)) (string, error) {
+	stream := s.client.Chat.Completions.NewStreaming(ctx, openai.ChatCompletionNewParams{
+		Messages: openai.F([]openai.ChatCompletionMessageParamUnion{
+			openai.UserMessage(prompt),
+		}),
+		Model:         openai.F(s.model),
+		StreamOptions: openai.F(openai.ChatCompletionStreamOptionsParam{IncludeUsage: openai.F(true)}),
+	})
+	defer stream.Close()
+
+	var response strings.Builder
+	usage := Usage{Requests: 1}
+	for stream.Next() {
+		chunk := stream.Current()
+		// With include_usage the last chunk has the usage and no choices
+		if chunk.Usage.TotalTokens > 0 {
+			usage.PromptTokens, usage.CompletionTokens = chunk.Usage.PromptTokens, chunk.Usage.CompletionTokens
+		}
+		if len(chunk.Choices) == 0 || chunk.Choices[0].Delta.Content == "" {
+			continue
+		}
+		response.WriteString(chunk.Choices[0].Delta.Content)
+		onDelta(chunk.Choices[0].Delta.Content)
+	}
+	if err := stream.Err(); err != nil {
+		return "", providerError(fmt.Errorf("failed to stream chat completion: %w", err))
+	}
+	RecordUsage(ctx, usage)
+	return strings.TrimSpace(response.String()), nil
+}
+
+// CompleteStructured implements StructuredCompleter. Models without
+// structured outputs get a plain completion, so callers must still validate
+// the response.
+func (s *OpenAIService) CompleteStructured(ctx context.Context, prompt, name string, schema *jsonschema.Schema) (string, error) {
+	if !s.supportsStructuredOutputs() {
+		return s.Complete(ctx, prompt)
+	}
+
+	chat, err := s.client.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
+		Messages: openai.F([]openai.ChatCompletionMessageParamUnion{
+			openai.UserMessage(prompt),
+		}),
+		ResponseFormat: openai.F[openai.ChatCompletionNewParamsResponseFormatUnion](
+			openai.ResponseFormatJSONSchemaParam{
+				Type: openai.F(openai.ResponseFormatJSONSchemaTypeJSONSchema),
+				JSONSchema: openai.F(openai.ResponseFormatJSONSchemaJSONSchemaParam{
+					Name:   openai.F(name),
+					Strict: openai.Bool(true),
+					Schema: openai.F(interface{}(schema)),
+				}),
+			},
+		),
+		Model: openai.F(s.model),
+	})
+	if err != nil {
+		if !structuredOutputsRejected(err) {
+			return "", providerError(fmt.Errorf("failed to create structured chat completion: %w", err))
+		}
+		slog.Warn("Structured outputs rejected, falling back to regular completion", "error", err)
+		return s.Complete(ctx, prompt)
+	}
+	recordChatUsage(ctx, chat)
+	if len(chat.Choices) == 0 {
+		return "", fmt.Errorf("chat completion returned no choices")
+	}
+	return chat.Choices[0].Message.Content, nil
+}
+
+// recordChatUsage records the token usage of a chat completion
+func recordChatUsage(ctx context.Context, chat *openai.ChatCompletion) {
+	RecordUsage(ctx, Usage{
+		Requests:         1,
+		PromptTokens:     chat.Usage.PromptTokens,
+		CompletionTokens: chat.Usage.CompletionTokens,
+	})
+}
+
+// recordRawUsage records the token usage of a chat completion decoded
+// without the SDK
+func recordRawUsage(ctx context.Context, response map[string]interface{}) {
+	u := Usage{Requests: 1}
+	if usage, ok := response["usage"].(map[string]interface{}); ok {
+		prompt, _ := usage["prompt_tokens"].(float64)
+		completion, _ := usage["completion_tokens"].(float64)
+		u.PromptTokens, u.CompletionTokens = int64(prompt), int64(completion)
+	}
+	RecordUsage(ctx, u)
+}
+
 // executeTemplate executes the template with data to generate the final prompt
 func (s *OpenAIService) executeTemplate(commitTemplate templates.CommitTemplate, templateManager *templates.TemplateManager) (string, error) {
 	data := templateManager.GetTemplateData()
@@ -159,6 +319,19 @@ func (s *OpenAIService) supportsStructuredOutputs() bool {
 	return structuredOutputModels[s.model]
 }
 
+// structuredOutputsRejected reports whether err is the API refusing the
+// response format, as it does for models that do not support structured
+// outputs. Other failures such as rejected keys or rate limits would only
+// repeat on a second request.
+func structuredOutputsRejected(err error) bool {
+	var apiErr *openai.Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		return false
+	}
+	message := strings.ToLower(apiErr.Error())
+	return strings.Contains(message, "response_format") || strings.Contains(message, "json_schema")
+}
+
 // isContentTypeError checks if the error is related to content-type issues
 func (s *OpenAIService) isContentTypeError(err error) bool {
 	if err == nil {
@@ -201,6 +374,7 @@ func (s *OpenAIService) generateWithStructuredOutputs(ctx context.Context, commi
 		slog.Debug("Structured outputs error details", "error", err)
 		return "", fmt.Errorf("failed to create chat completion: %w", err)
 	}
+	recordChatUsage(ctx, chat)
 
 	conventionalCommit := templates.ConventionalCommit{}
 	err = json.Unmarshal([]byte(chat.Choices[0].Message.Content), &conventionalCommit)
@@ -230,6 +404,7 @@ func (s *OpenAIService) generateWithRegularCompletion(ctx context.Context, commi
 		slog.Debug("Regular completion error details", "error", err)
 		return "", fmt.Errorf("failed to create chat completion: %w", err)
 	}
+	recordChatUsage(ctx, chat)
 
 	// Return the raw response as the commit message
 	commitMessage := strings.TrimSpace(chat.Choices[0].Message.Content)
@@ -301,7 +476,7 @@ func (s *OpenAIService) generateWithRawHTTP(ctx context.Context, commitTemplate
 	// Check for HTTP errors
 	if resp.StatusCode >= 400 {
 		slog.Error("API request failed", "status_code", resp.StatusCode, "response", string(bodyBytes))
-		return "", fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+		return "", withStatus(resp.StatusCode, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(bodyBytes)))
 	}
 
 	// Check for empty response
@@ -313,6 +488,7 @@ func (s *OpenAIService) generateWithRawHTTP(ctx context.Context, commitTemplate
 	// Try to parse as JSON first
 	var response map[string]interface{}
 	if err := json.Unmarshal(bodyBytes, &response); err == nil {
+		recordRawUsage(ctx, response)
 		// Successfully parsed as JSON - extract the message
 		if choices, ok := response["choices"].([]interface{}); ok && len(choices) > 0 {
 			if choice, ok := choices[0].(map[string]interface{}); ok {
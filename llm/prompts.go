@@ -0,0 +1,148 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/invopop/jsonschema"
+	"github.com/klauern/muse/templates"
+)
+
+// CompletePrompt renders the prompt template name with data and sends it to
+// service
+func CompletePrompt(ctx context.Context, service LLMService, name string, data map[string]any) (string, error) {
+	completer, ok := service.(Completer)
+	if !ok {
+		return "", fmt.Errorf("the configured provider does not support free-form prompts")
+	}
+
+	prompt, err := templates.RenderPrompt(name, data)
+	if err != nil {
+		return "", err
+	}
+	slog.Debug("Sending prompt", "name", name, "length", len(prompt))
+
+	response, err := completer.Complete(ctx, prompt)
+	if err != nil {
+		return "", fmt.Errorf("failed to complete %s prompt: %w", name, err)
+	}
+	return response, nil
+}
+
+// StreamPrompt renders the prompt template name with data and sends it to
+// service, passing the response to onDelta as it arrives. Services that
+// cannot stream pass the whole response to onDelta at once.
+func StreamPrompt(ctx context.Context, service LLMService, name string, data map[string]any, onDelta func(string)) (string, error) {
+	streamer, ok := service.(StreamCompleter)
+	if !ok {
+		response, err := CompletePrompt(ctx, service, name, data)
+		if err == nil {
+			onDelta(response)
+		}
+		return response, err
+	}
+
+	prompt, err := templates.RenderPrompt(name, data)
+	if err != nil {
+		return "", err
+	}
+	slog.Debug("Streaming prompt", "name", name, "length", len(prompt))
+
+	response, err := streamer.CompleteStream(ctx, prompt, onDelta)
+	if err != nil {
+		return "", fmt.Errorf("failed to complete %s prompt: %w", name, err)
+	}
+	return response, nil
+}
+
+// CompleteStructuredPrompt renders the prompt template name with data,
+// asks service for a response following schema and decodes it into v.
+// Services that cannot enforce a schema get a plain completion, and the
+// first JSON object in their response is used.
+func CompleteStructuredPrompt(ctx context.Context, service LLMService, name string, data map[string]any, schema *jsonschema.Schema, v any) error {
+	prompt, err := templates.RenderPrompt(name, data)
+	if err != nil {
+		return err
+	}
+	return CompleteStructured(ctx, service, name, prompt, schema, v)
+}
+
+// CompleteStructured sends an already rendered prompt, named name in logs
+// and errors, and decodes the response into v as CompleteStructuredPrompt
+// does
+func CompleteStructured(ctx context.Context, service LLMService, name, prompt string, schema *jsonschema.Schema, v any) error {
+	slog.Debug("Sending structured prompt", "name", name, "length", len(prompt))
+
+	var (
+		response string
+		err      error
+	)
+	switch s := service.(type) {
+	case StructuredCompleter:
+		response, err = s.CompleteStructured(ctx, prompt, name, schema)
+	case Completer:
+		response, err = s.Complete(ctx, prompt)
+	default:
+		return fmt.Errorf("the configured provider does not support free-form prompts")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to complete %s prompt: %w", name, err)
+	}
+
+	start := strings.Index(response, "{")
+	end := strings.LastIndex(response, "}")
+	if start < 0 || end < start {
+		return fmt.Errorf("the %s response did not contain JSON", name)
+	}
+	if err := json.Unmarshal([]byte(response[start:end+1]), v); err != nil {
+		return fmt.Errorf("failed to decode the %s response: %w", name, err)
+	}
+	return nil
+}
+
+// Revision asks for a new commit message for Diff that follows Hint, such
+// as "mention the migration" or "shorter"
+type Revision struct {
+	Diff  string
+	Style templates.CommitStyle
+	// Previous is the message being replaced, if any
+	Previous string
+	Hint     string
+}
+
+// Regenerate writes a commit message for a revision, passing it to onDelta
+// as it is generated when onDelta is not nil
+func Regenerate(ctx context.Context, service LLMService, r Revision, onDelta func(string)) (string, error) {
+	if onDelta == nil {
+		onDelta = func(string) {}
+	}
+	response, err := StreamPrompt(ctx, service, "regenerate", map[string]any{
+		"Diff":     r.Diff,
+		"Style":    string(r.Style),
+		"Previous": r.Previous,
+		"Hint":     r.Hint,
+	}, onDelta)
+	if err != nil {
+		return "", err
+	}
+	return stripCodeFence(response), nil
+}
+
+// stripCodeFence removes a code fence the model put around a message
+// despite being asked not to
+func stripCodeFence(response string) string {
+	response = strings.TrimSpace(response)
+	if !strings.HasPrefix(response, "```") || !strings.HasSuffix(response, "```") || len(response) < 6 {
+		return response
+	}
+	_, inner, _ := strings.Cut(strings.TrimSuffix(response, "```"), "\n")
+	return strings.TrimSpace(inner)
+}
+
+// Summarize describes a range of commits given their log
+func Summarize(ctx context.Context, service LLMService, commits string) (string, error) {
+	return CompletePrompt(ctx, service, "summarize", map[string]any{"Commits": commits})
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log/slog"
 
+	"github.com/invopop/jsonschema"
 	"github.com/klauern/muse/config"
 	"github.com/klauern/muse/templates"
 )
@@ -14,11 +15,42 @@ type LLMService interface {
 	GenerateCommitMessage(ctx context.Context, diff string, style templates.CommitStyle) (string, error)
 }
 
+// Pinger is implemented by services that can cheaply verify that the
+// provider is reachable and the credentials are accepted
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Completer is implemented by services that can answer a free-form prompt,
+// which commands other than commit message generation rely on
+type Completer interface {
+	Complete(ctx context.Context, prompt string) (string, error)
+}
+
+// StreamCompleter is implemented by services that can send a free-form
+// response as it is generated. onDelta receives each piece of text in order,
+// and the full response is returned once it is complete.
+type StreamCompleter interface {
+	CompleteStream(ctx context.Context, prompt string, onDelta func(string)) (string, error)
+}
+
+// StructuredCompleter is implemented by services that can constrain a
+// response to a JSON schema
+type StructuredCompleter interface {
+	CompleteStructured(ctx context.Context, prompt, name string, schema *jsonschema.Schema) (string, error)
+}
+
 // LLMProvider defines the interface for creating LLM services
 type LLMProvider interface {
 	NewService(config map[string]interface{}) (LLMService, error)
 }
 
+// ConfigSchemaProvider is implemented by providers that describe the
+// settings they accept under llm.config
+type ConfigSchemaProvider interface {
+	ConfigSchema() *jsonschema.Schema
+}
+
 var providers = make(map[string]LLMProvider)
 
 // RegisterProvider registers a new LLM provider
@@ -36,3 +68,31 @@ func NewLLMService(cfg *config.LLMConfig) (LLMService, error) {
 
 	return provider.NewService(cfg.Config)
 }
+
+// ProviderConfigSchemas returns the llm.config schema of every registered
+// provider. Providers without a schema accept any settings.
+func ProviderConfigSchemas() map[string]*jsonschema.Schema {
+	schemas := make(map[string]*jsonschema.Schema, len(providers))
+	for name, provider := range providers {
+		schemas[name] = jsonschema.TrueSchema
+		if p, ok := provider.(ConfigSchemaProvider); ok {
+			schemas[name] = p.ConfigSchema()
+		}
+	}
+	return schemas
+}
+
+// ReflectSchema builds a closed schema for v to embed in another document,
+// such as a provider's settings in the config schema or the input of an MCP
+// tool
+func ReflectSchema(v any) *jsonschema.Schema {
+	reflector := jsonschema.Reflector{
+		AllowAdditionalProperties:  false,
+		DoNotReference:             true,
+		RequiredFromJSONSchemaTags: true,
+	}
+	schema := reflector.Reflect(v)
+	schema.Version = ""
+	schema.ID = ""
+	return schema
+}
@@ -0,0 +1,73 @@
+package llm
+
+import (
+	"context"
+	"sync"
+)
+
+// Usage counts provider requests and the tokens they used
+type Usage struct {
+	Requests         int   `json:"requests"`
+	PromptTokens     int64 `json:"prompt_tokens"`
+	CompletionTokens int64 `json:"completion_tokens"`
+	// Retries counts generations that were attempted again after failing
+	Retries int `json:"retries,omitempty"`
+	// Path is how the last message was produced, such as "cache" or
+	// "structured>completion" when structured outputs fell back to a plain
+	// completion
+	Path string `json:"path,omitempty"`
+}
+
+// TotalTokens returns the prompt and completion tokens together
+func (u Usage) TotalTokens() int64 {
+	return u.PromptTokens + u.CompletionTokens
+}
+
+// Add returns the sum of u and other, with the path of other when it has one
+func (u Usage) Add(other Usage) Usage {
+	sum := Usage{
+		Requests:         u.Requests + other.Requests,
+		PromptTokens:     u.PromptTokens + other.PromptTokens,
+		CompletionTokens: u.CompletionTokens + other.CompletionTokens,
+		Retries:          u.Retries + other.Retries,
+		Path:             u.Path,
+	}
+	if other.Path != "" {
+		sum.Path = other.Path
+	}
+	return sum
+}
+
+type usageKey struct{}
+
+type usageTracker struct {
+	mu    sync.Mutex
+	usage Usage
+	// parent is the tracker of an enclosing TrackUsage, which sees the
+	// usage recorded here too
+	parent *usageTracker
+}
+
+// TrackUsage returns a context in which provider requests record their
+// usage, and a function that returns the usage recorded so far. Usage
+// recorded in the context also counts towards any enclosing TrackUsage.
+func TrackUsage(ctx context.Context) (context.Context, func() Usage) {
+	parent, _ := ctx.Value(usageKey{}).(*usageTracker)
+	tracker := &usageTracker{parent: parent}
+	return context.WithValue(ctx, usageKey{}, tracker), func() Usage {
+		tracker.mu.Lock()
+		defer tracker.mu.Unlock()
+		return tracker.usage
+	}
+}
+
+// RecordUsage adds u to the usage tracked by ctx, if any. Providers call it
+// for every request they make.
+func RecordUsage(ctx context.Context, u Usage) {
+	tracker, _ := ctx.Value(usageKey{}).(*usageTracker)
+	for ; tracker != nil; tracker = tracker.parent {
+		tracker.mu.Lock()
+		tracker.usage = tracker.usage.Add(u)
+		tracker.mu.Unlock()
+	}
+}
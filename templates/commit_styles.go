@@ -39,10 +39,18 @@ type GitmojiCommitSchema struct {
 	Gitmoji string `json:"gitmoji" jsonschema:"description=an appropriate emoji for the change"`
 }
 
+// Example is a message generated for the repository and the message it was
+// committed with after the user edited it
+type Example struct {
+	Generated string `json:"generated"`
+	Committed string `json:"committed"`
+}
+
 // TemplateManager manages different commit templates
 type TemplateManager struct {
-	diff  string
-	style CommitStyle
+	diff     string
+	style    CommitStyle
+	examples []Example
 }
 
 // NewTemplateManager creates and returns a new TemplateManager
@@ -53,6 +61,12 @@ func NewTemplateManager(diff string, style CommitStyle) *TemplateManager {
 	}
 }
 
+// SetExamples sets the edited messages that style templates show as
+// .Examples
+func (tm *TemplateManager) SetExamples(examples []Example) {
+	tm.examples = examples
+}
+
 // CompileTemplate compiles a specific commit template using single-pass compilation with caching
 func (tm *TemplateManager) CompileTemplate(templateType CommitStyle) (CommitTemplate, error) {
 	// Check cache first
@@ -107,9 +121,18 @@ func (tm *TemplateManager) GetTemplateData() map[string]interface{} {
 	// Generate schema for the current style
 	schema := tm.generateSchemaForStyle(tm.style)
 
+	examples := make([]Example, len(tm.examples))
+	for i, example := range tm.examples {
+		examples[i] = Example{
+			Generated: sanitizeTemplateInput(example.Generated),
+			Committed: sanitizeTemplateInput(example.Committed),
+		}
+	}
+
 	return map[string]interface{}{
-		"Diff":   sanitizedDiff,
-		"Schema": schema,
+		"Diff":     sanitizedDiff,
+		"Schema":   schema,
+		"Examples": examples,
 	}
 }
 
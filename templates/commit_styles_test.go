@@ -190,6 +190,38 @@ index 1234567..abcdefg 100644
 	}
 }
 
+func TestTemplateExecution_WithExamples(t *testing.T) {
+	GetRegistry().Clear()
+
+	for _, style := range []CommitStyle{"conventional", "gitmoji", "default"} {
+		t.Run(string(style), func(t *testing.T) {
+			tm := NewTemplateManager("diff --git a/a.go b/a.go", style)
+			result, err := tm.CompileTemplate(style)
+			if err != nil {
+				t.Fatalf("failed to compile template: %v", err)
+			}
+
+			var buf strings.Builder
+			if err := result.Template.Execute(&buf, tm.GetTemplateData()); err != nil {
+				t.Fatalf("failed to execute template: %v", err)
+			}
+			if strings.Contains(buf.String(), "Committed:") {
+				t.Error("output without examples should not mention them")
+			}
+
+			tm.SetExamples([]Example{{Generated: "fix: update things", Committed: "fix(api): retry {{timeouts}}"}})
+			buf.Reset()
+			if err := result.Template.Execute(&buf, tm.GetTemplateData()); err != nil {
+				t.Fatalf("failed to execute template: %v", err)
+			}
+			output := buf.String()
+			if !strings.Contains(output, "fix: update things") || !strings.Contains(output, "fix(api): retry &#123;&#123;timeouts&#125;&#125;") {
+				t.Errorf("output should contain the sanitized example, got:\n%s", output)
+			}
+		})
+	}
+}
+
 func TestTemplateManager_ConcurrentAccess(t *testing.T) {
 	// Clear registry before test
 	GetRegistry().Clear()
@@ -307,3 +339,20 @@ func TestListTemplateFiles(t *testing.T) {
 		}
 	}
 }
+
+func TestAvailableStyles(t *testing.T) {
+	styles, err := AvailableStyles()
+	if err != nil {
+		t.Fatalf("failed to list styles: %v", err)
+	}
+
+	want := map[CommitStyle]bool{"conventional": true, "default": true, "gitmoji": true}
+	if len(styles) != len(want) {
+		t.Errorf("expected %d styles, got %v", len(want), styles)
+	}
+	for _, style := range styles {
+		if !want[style] {
+			t.Errorf("unexpected style %q", style)
+		}
+	}
+}
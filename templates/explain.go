@@ -0,0 +1,84 @@
+package templates
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"strings"
+	"text/template"
+
+	"github.com/invopop/jsonschema"
+)
+
+//go:embed audiences/*.tmpl
+var audienceFS embed.FS
+
+// Explanation is the structured explanation of a commit or range
+type Explanation struct {
+	Summary string   `json:"summary" jsonschema_description:"One or two sentences on what the changes do"`
+	Changes []string `json:"changes" jsonschema_description:"The notable changes, one per entry"`
+	Impact  string   `json:"impact" jsonschema_description:"Why the changes matter to the reader"`
+	Risks   []string `json:"risks" jsonschema_description:"Things that could go wrong or deserve attention; empty when there are none"`
+}
+
+// ExplanationSchema returns the JSON schema structured explanations must
+// follow
+func ExplanationSchema() *jsonschema.Schema {
+	reflector := jsonschema.Reflector{
+		AllowAdditionalProperties: false,
+		DoNotReference:            true,
+	}
+	return reflector.Reflect(Explanation{})
+}
+
+// Audiences returns the audiences that have a template in audiences/
+func Audiences() ([]string, error) {
+	entries, err := fs.ReadDir(audienceFS, "audiences")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audience directory: %w", err)
+	}
+
+	var audiences []string
+	for _, entry := range entries {
+		if name, ok := strings.CutSuffix(entry.Name(), ".tmpl"); ok && !entry.IsDir() {
+			audiences = append(audiences, name)
+		}
+	}
+	return audiences, nil
+}
+
+// RenderExplainPrompt renders the explain prompt with the guidance for
+// audience. Data is sanitized as in RenderPrompt.
+func RenderExplainPrompt(audience string, data map[string]any) (string, error) {
+	key := promptRegistryPrefix + "explain:" + audience
+	tmpl, _, exists := GetRegistry().Get(key)
+	if !exists {
+		var err error
+		if tmpl, err = compileExplainPrompt(audience); err != nil {
+			return "", err
+		}
+		GetRegistry().Set(key, tmpl, nil)
+	}
+	return executePrompt(tmpl, data)
+}
+
+func compileExplainPrompt(audience string) (*template.Template, error) {
+	content, err := fs.ReadFile(audienceFS, "audiences/"+audience+".tmpl")
+	if err != nil {
+		audiences, _ := Audiences()
+		return nil, fmt.Errorf("unknown audience %q; available audiences: %s", audience, strings.Join(audiences, ", "))
+	}
+
+	base, err := compilePrompt("explain")
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := base.Clone()
+	if err != nil {
+		return nil, fmt.Errorf("failed to clone explain prompt: %w", err)
+	}
+	if _, err := tmpl.New("audience").Parse(strings.TrimSpace(string(content))); err != nil {
+		return nil, fmt.Errorf("failed to parse audience %s: %w", audience, err)
+	}
+	return tmpl, nil
+}
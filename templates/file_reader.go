@@ -4,6 +4,7 @@ import (
 	"embed"
 	"fmt"
 	"io/fs"
+	"strings"
 )
 
 //go:embed styles/*.tmpl
@@ -34,3 +35,17 @@ func ListTemplateFiles() ([]string, error) {
 
 	return files, nil
 }
+
+// AvailableStyles returns the commit styles that have a template file
+func AvailableStyles() ([]CommitStyle, error) {
+	files, err := ListTemplateFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	styles := make([]CommitStyle, 0, len(files))
+	for _, file := range files {
+		styles = append(styles, CommitStyle(strings.TrimSuffix(file, ".tmpl")))
+	}
+	return styles, nil
+}
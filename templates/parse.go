@@ -0,0 +1,117 @@
+package templates
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// headerPattern matches "type(scope)!: subject"; scope and ! are optional
+var headerPattern = regexp.MustCompile(`^([A-Za-z]+)(?:\(([^()]*)\))?(!)?: (.*)$`)
+
+// gitmojiPattern matches a leading :shortcode: or emoji followed by a space
+var gitmojiPattern = regexp.MustCompile(`^(:[a-z0-9_+-]+:|[^\x00-\x7F]+)\s+`)
+
+// footerPattern matches a git trailer or BREAKING CHANGE note
+var footerPattern = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9-]*(: | #)|BREAKING[ -]CHANGE: )`)
+
+var paragraphSeparator = regexp.MustCompile(`\n\s*\n`)
+
+// ParsedCommit is a commit message split into its conventional parts
+type ParsedCommit struct {
+	ConventionalCommit
+	// Gitmoji is the leading emoji or :shortcode:, if any
+	Gitmoji string `json:"gitmoji,omitempty"`
+	// Breaking is set by a ! after the type or scope, or a BREAKING CHANGE
+	// footer
+	Breaking bool `json:"breaking"`
+}
+
+// StripComments removes the # comment lines git adds to commit message
+// templates
+func StripComments(message string) string {
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(message, "\r\n", "\n"), "\n") {
+		if !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// ParseConventionalCommit splits a message such as "feat(cli)!: add watch"
+// into type, scope, subject, body and footer. A leading gitmoji is
+// accepted. An error is returned when the header does not follow the
+// conventional commit format.
+func ParseConventionalCommit(message string) (*ParsedCommit, error) {
+	message = StripComments(message)
+	header, rest, _ := strings.Cut(message, "\n")
+	header = strings.TrimSpace(header)
+
+	parsed := &ParsedCommit{}
+	if m := gitmojiPattern.FindStringSubmatch(header); m != nil {
+		parsed.Gitmoji = m[1]
+		header = header[len(m[0]):]
+	}
+
+	m := headerPattern.FindStringSubmatch(header)
+	if m == nil {
+		return nil, fmt.Errorf("header %q is not in the form type(scope): subject", header)
+	}
+	parsed.Type = strings.ToLower(m[1])
+	parsed.Scope = m[2]
+	parsed.Breaking = m[3] == "!"
+	parsed.Subject = strings.TrimSpace(m[4])
+
+	paragraphs := splitParagraphs(rest)
+	if n := len(paragraphs); n > 0 && isFooter(paragraphs[n-1]) {
+		parsed.Footer = paragraphs[n-1]
+		paragraphs = paragraphs[:n-1]
+	}
+	parsed.Body = strings.Join(paragraphs, "\n\n")
+
+	for _, line := range strings.Split(parsed.Footer, "\n") {
+		if strings.HasPrefix(line, "BREAKING CHANGE: ") || strings.HasPrefix(line, "BREAKING-CHANGE: ") {
+			parsed.Breaking = true
+		}
+	}
+	return parsed, nil
+}
+
+// ParseCommit splits message into its conventional commit parts, or into a
+// subject and body when it is not a conventional commit
+func ParseCommit(message string) *ParsedCommit {
+	if parsed, err := ParseConventionalCommit(message); err == nil {
+		return parsed
+	}
+	subject, body, _ := strings.Cut(StripComments(message), "\n")
+	parsed := &ParsedCommit{}
+	parsed.Subject = strings.TrimSpace(subject)
+	parsed.Body = strings.TrimSpace(body)
+	return parsed
+}
+
+func splitParagraphs(text string) []string {
+	var paragraphs []string
+	for _, p := range paragraphSeparator.Split(strings.TrimSpace(text), -1) {
+		if p = strings.TrimSpace(p); p != "" {
+			paragraphs = append(paragraphs, p)
+		}
+	}
+	return paragraphs
+}
+
+// isFooter reports whether every line of paragraph is a trailer, allowing
+// indented continuation lines
+func isFooter(paragraph string) bool {
+	lines := strings.Split(paragraph, "\n")
+	if !footerPattern.MatchString(lines[0]) {
+		return false
+	}
+	for _, line := range lines[1:] {
+		if !footerPattern.MatchString(line) && !strings.HasPrefix(line, " ") {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,93 @@
+package templates
+
+import "testing"
+
+func TestParseConventionalCommit(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    ParsedCommit
+		wantErr bool
+	}{
+		{
+			name:    "header only",
+			message: "fix: handle empty diff",
+			want:    ParsedCommit{ConventionalCommit: ConventionalCommit{Type: "fix", Subject: "handle empty diff"}},
+		},
+		{
+			name:    "scope body and footer",
+			message: "feat(cli): add watch command\n\nWatches the index.\n\nSecond paragraph.\n\nCloses #12\nReviewed-by: A",
+			want: ParsedCommit{ConventionalCommit: ConventionalCommit{
+				Type:    "feat",
+				Scope:   "cli",
+				Subject: "add watch command",
+				Body:    "Watches the index.\n\nSecond paragraph.",
+				Footer:  "Closes #12\nReviewed-by: A",
+			}},
+		},
+		{
+			name:    "breaking marker",
+			message: "refactor(api)!: drop v1 endpoints",
+			want: ParsedCommit{
+				ConventionalCommit: ConventionalCommit{Type: "refactor", Scope: "api", Subject: "drop v1 endpoints"},
+				Breaking:           true,
+			},
+		},
+		{
+			name:    "breaking footer",
+			message: "feat: new config format\n\nBREAKING CHANGE: muse.yaml keys were renamed",
+			want: ParsedCommit{
+				ConventionalCommit: ConventionalCommit{Type: "feat", Subject: "new config format", Footer: "BREAKING CHANGE: muse.yaml keys were renamed"},
+				Breaking:           true,
+			},
+		},
+		{
+			name:    "gitmoji and comments",
+			message: "✨ feat(ui): add spinner\n# Please enter the commit message\n",
+			want: ParsedCommit{
+				ConventionalCommit: ConventionalCommit{Type: "feat", Scope: "ui", Subject: "add spinner"},
+				Gitmoji:            "✨",
+			},
+		},
+		{
+			name:    "shortcode gitmoji",
+			message: ":bug: fix: off by one",
+			want: ParsedCommit{
+				ConventionalCommit: ConventionalCommit{Type: "fix", Subject: "off by one"},
+				Gitmoji:            ":bug:",
+			},
+		},
+		{name: "free-form", message: "Update README", wantErr: true},
+		{name: "missing space", message: "feat:add x", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseConventionalCommit(tt.message)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseConventionalCommit() = %+v, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseConventionalCommit() error = %v", err)
+			}
+			if *got != tt.want {
+				t.Errorf("ParseConventionalCommit() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCommit(t *testing.T) {
+	parsed := ParseCommit("feat(cli): add rpc\n\nServe editors over stdio.")
+	if parsed.Type != "feat" || parsed.Scope != "cli" || parsed.Body != "Serve editors over stdio." {
+		t.Errorf("ParseCommit() = %+v, want the conventional parts", parsed)
+	}
+
+	parsed = ParseCommit("Add rpc mode\n\nServe editors over stdio.\n# comment")
+	if parsed.Type != "" || parsed.Subject != "Add rpc mode" || parsed.Body != "Serve editors over stdio." {
+		t.Errorf("ParseCommit() = %+v, want subject and body of a free-form message", parsed)
+	}
+}
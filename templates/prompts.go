@@ -0,0 +1,62 @@
+package templates
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"strings"
+	"text/template"
+)
+
+//go:embed prompts/*.tmpl
+var promptFS embed.FS
+
+// promptRegistryPrefix keeps prompt templates apart from commit styles in
+// the registry
+const promptRegistryPrefix = "prompt:"
+
+// RenderPrompt executes the prompt template prompts/<name>.tmpl with data.
+// String values are sanitized the same way as diffs.
+func RenderPrompt(name string, data map[string]any) (string, error) {
+	tmpl, err := compilePrompt(name)
+	if err != nil {
+		return "", err
+	}
+	return executePrompt(tmpl, data)
+}
+
+// executePrompt runs tmpl with the string values of data sanitized
+func executePrompt(tmpl *template.Template, data map[string]any) (string, error) {
+	sanitized := make(map[string]any, len(data))
+	for key, value := range data {
+		if s, ok := value.(string); ok {
+			value = sanitizeTemplateInput(s)
+		}
+		sanitized[key] = value
+	}
+
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, sanitized); err != nil {
+		return "", fmt.Errorf("failed to execute prompt %s: %w", tmpl.Name(), err)
+	}
+	return buf.String(), nil
+}
+
+func compilePrompt(name string) (*template.Template, error) {
+	key := promptRegistryPrefix + name
+	if tmpl, _, exists := GetRegistry().Get(key); exists {
+		return tmpl, nil
+	}
+
+	content, err := fs.ReadFile(promptFS, "prompts/"+name+".tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prompt %s: %w", name, err)
+	}
+	tmpl, err := template.New(name).Funcs(SafeFuncMap()).Parse(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse prompt %s: %w", name, err)
+	}
+
+	GetRegistry().Set(key, tmpl, nil)
+	return tmpl, nil
+}
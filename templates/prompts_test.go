@@ -0,0 +1,56 @@
+package templates
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderPrompt(t *testing.T) {
+	GetRegistry().Clear()
+
+	prompt, err := RenderPrompt("summarize", map[string]any{"Commits": "abc123 feat: add {{.Secret}}"})
+	if err != nil {
+		t.Fatalf("RenderPrompt() error = %v", err)
+	}
+	if !strings.Contains(prompt, "abc123 feat: add") {
+		t.Errorf("RenderPrompt() did not include the commits:\n%s", prompt)
+	}
+	if strings.Contains(prompt, "{{.Secret}}") {
+		t.Error("RenderPrompt() did not sanitize template delimiters in the data")
+	}
+
+	if _, _, exists := GetRegistry().Get(promptRegistryPrefix + "summarize"); !exists {
+		t.Error("RenderPrompt() did not cache the compiled prompt")
+	}
+
+	if _, err := RenderPrompt("missing", nil); err == nil {
+		t.Error("RenderPrompt() succeeded for a missing prompt")
+	}
+}
+
+func TestRenderExplainPrompt(t *testing.T) {
+	GetRegistry().Clear()
+
+	audiences, err := Audiences()
+	if err != nil {
+		t.Fatalf("Audiences() error = %v", err)
+	}
+	if strings.Join(audiences, ",") != "newcomer,release-manager,reviewer" {
+		t.Errorf("Audiences() = %v", audiences)
+	}
+
+	prompt, err := RenderExplainPrompt("reviewer", map[string]any{"Revision": "HEAD", "Show": "commit abc <b>"})
+	if err != nil {
+		t.Fatalf("RenderExplainPrompt() error = %v", err)
+	}
+	if !strings.Contains(prompt, "code reviewer") || !strings.Contains(prompt, "commit abc &lt;b&gt;") {
+		t.Errorf("RenderExplainPrompt() is missing the audience or the sanitized data:\n%s", prompt)
+	}
+	if _, _, exists := GetRegistry().Get(promptRegistryPrefix + "explain:reviewer"); !exists {
+		t.Error("RenderExplainPrompt() did not cache the compiled prompt")
+	}
+
+	if _, err := RenderExplainPrompt("../styles/default", nil); err == nil {
+		t.Error("RenderExplainPrompt() accepted an unknown audience")
+	}
+}
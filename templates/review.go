@@ -0,0 +1,30 @@
+package templates
+
+import (
+	"github.com/invopop/jsonschema"
+)
+
+// ReviewIssue is a single problem found while reviewing a diff
+type ReviewIssue struct {
+	File       string `json:"file" jsonschema_description:"Path of the file the issue is in, as shown in the diff"`
+	Line       int    `json:"line" jsonschema_description:"Line number in the new version of the file, or 0 when the issue is not tied to a line"`
+	Severity   string `json:"severity" jsonschema:"enum=error,enum=warning,enum=info" jsonschema_description:"error for bugs and security problems, warning for likely mistakes and risky code, info for style and small improvements"`
+	Message    string `json:"message" jsonschema_description:"What is wrong and why it matters"`
+	Suggestion string `json:"suggestion" jsonschema_description:"How to fix the issue, or an empty string"`
+}
+
+// CodeReview is the structured review of a diff
+type CodeReview struct {
+	Summary string        `json:"summary" jsonschema_description:"One or two sentences on the overall quality of the change"`
+	Issues  []ReviewIssue `json:"issues" jsonschema_description:"Problems found in the change; empty when there are none"`
+}
+
+// ReviewSchema returns the JSON schema reviews must follow, in the subset
+// supported by structured outputs
+func ReviewSchema() *jsonschema.Schema {
+	reflector := jsonschema.Reflector{
+		AllowAdditionalProperties: false,
+		DoNotReference:            true,
+	}
+	return reflector.Reflect(CodeReview{})
+}
@@ -1,6 +1,7 @@
 package templates
 
 import (
+	"encoding/json"
 	"fmt"
 	"path/filepath"
 	"strings"
@@ -41,6 +42,10 @@ func SafeFuncMap() template.FuncMap {
 			return fmt.Sprintf("%q", s)
 		},
 		"sprintf": fmt.Sprintf,
+		"json": func(v any) (string, error) {
+			data, err := json.MarshalIndent(v, "", "  ")
+			return string(data), err
+		},
 
 		// Safe utility functions
 		"len": func(s string) int {
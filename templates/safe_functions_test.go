@@ -29,7 +29,7 @@ func TestSafeFuncMap_OnlySafeFunctions(t *testing.T) {
 	expectedFuncs := []string{
 		"upper", "lower", "title", "trim", "contains",
 		"hasPrefix", "hasSuffix", "basename", "extname",
-		"cleanPath", "quote", "sanitize",
+		"cleanPath", "quote", "json", "sanitize",
 	}
 
 	for _, expectedFunc := range expectedFuncs {